@@ -1,15 +1,23 @@
 package main
 
 import (
+	"context"
 	"ecom_apiv1/db"
+	"ecom_apiv1/internal/blob"
 	"ecom_apiv1/internal/handler"
+	"ecom_apiv1/internal/moderation"
+	"ecom_apiv1/internal/money"
 	"ecom_apiv1/internal/server"
+	"ecom_apiv1/internal/shipping"
 	"ecom_apiv1/internal/storer"
+	"ecom_apiv1/internal/tax"
 	"log"
 	"os"
+	"time"
 
 	_ "github.com/go-sql-driver/mysql"
 	"github.com/joho/godotenv"
+	_ "modernc.org/sqlite"
 )
 
 const minSecretKeySize = 32
@@ -23,17 +31,92 @@ func main() {
 	if len(secretKey) < minSecretKeySize {
 		log.Fatalf("SECRET_KEY must be at least %d characters", minSecretKeySize)
 	}
-	sqlx, err := db.GetConnection()
-	if err != nil {
-		log.Fatalf("error opening database: %v", err)
+	driver := os.Getenv("DB_DRIVER")
+	if driver == "" {
+		driver = "mysql"
 	}
-	defer sqlx.Close()
-	log.Println("Succesfully connecting database")
 
-	str := storer.NewMySQLStorage(sqlx)
-	srv := server.NewServer(str)
+	var str storer.Storer
+	if driver == "memory" {
+		str = storer.NewMemoryStorage()
+	} else {
+		sqlx, err := db.GetConnection(driver, os.Getenv("DB_DSN"))
+		if err != nil {
+			log.Fatalf("error opening database: %v", err)
+		}
+		defer sqlx.Close()
+		log.Println("Succesfully connecting database")
+
+		if os.Getenv("DB_AUTO_MIGRATE") != "false" {
+			migrator, err := db.NewMigrator(sqlx)
+			if err != nil {
+				log.Fatalf("error loading migrations: %v", err)
+			}
+			if err := migrator.Up(context.Background()); err != nil {
+				log.Fatalf("error running migrations: %v", err)
+			}
+		}
+
+		if driver == "sqlite" {
+			str = storer.NewSQLiteStorage(sqlx)
+		} else {
+			str = storer.NewMySQLStorage(sqlx)
+		}
+	}
+	zones := shipping.DefaultZones
+	if path := os.Getenv("SHIPPING_ZONES_FILE"); path != "" {
+		zones, err = shipping.LoadZones(path)
+		if err != nil {
+			log.Fatalf("error loading shipping zones: %v", err)
+		}
+	}
+	imageDir := os.Getenv("IMAGE_DIR")
+	if imageDir == "" {
+		imageDir = "uploads"
+	}
+	blobs, err := blob.NewLocalStore(imageDir)
+	if err != nil {
+		log.Fatalf("error opening image storage: %v", err)
+	}
+	bannedWords := moderation.DefaultBannedWords
+	if path := os.Getenv("BANNED_WORDS_FILE"); path != "" {
+		bannedWords, err = moderation.LoadWords(path)
+		if err != nil {
+			log.Fatalf("error loading banned words: %v", err)
+		}
+	}
+	guestCartTTL := 30 * 24 * time.Hour
+	if v := os.Getenv("GUEST_CART_TTL"); v != "" {
+		guestCartTTL, err = time.ParseDuration(v)
+		if err != nil || guestCartTTL <= 0 {
+			log.Fatalf("invalid GUEST_CART_TTL %q", v)
+		}
+	}
+	currency := money.DefaultCurrency
+	if v := os.Getenv("CURRENCY"); v != "" {
+		currency, err = money.ParseCurrency(v)
+		if err != nil {
+			log.Fatalf("invalid CURRENCY %q", v)
+		}
+	}
+	srv := server.NewServer(str, tax.NewRuleCalculator(str), shipping.NewTableCalculator(zones), blobs, moderation.NewWordFilter(bannedWords), currency, guestCartTTL)
+	go expireGuestCarts(srv, min(guestCartTTL, time.Hour))
 
 	hdl := handler.NewHandler(srv, secretKey)
 	handler.RegisterRoutes(hdl)
 	handler.Start(":8000")
 }
+
+// expireGuestCarts deletes abandoned guest carts every interval.
+func expireGuestCarts(srv *server.Server, interval time.Duration) {
+	for range time.Tick(interval) {
+		n, err := srv.ExpireGuestCarts(context.Background())
+		if err != nil {
+			log.Printf("error expiring guest carts: %v", err)
+			continue
+		}
+		if n > 0 {
+			log.Printf("expired %d guest carts", n)
+		}
+	}
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"ecom_apiv1/db"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	_ "github.com/go-sql-driver/mysql"
+	"github.com/joho/godotenv"
+	_ "modernc.org/sqlite"
+)
+
+func usage() {
+	fmt.Fprintln(os.Stderr, "usage: migrate [up | down [steps] | version]")
+	os.Exit(2)
+}
+
+func main() {
+	// the .env file is optional here, the DB_* variables may come from the shell
+	godotenv.Load("../../.env")
+
+	flag.Parse()
+	if flag.NArg() == 0 {
+		usage()
+	}
+
+	driver := os.Getenv("DB_DRIVER")
+	if driver == "" {
+		driver = "mysql"
+	}
+	sqlx, err := db.GetConnection(driver, os.Getenv("DB_DSN"))
+	if err != nil {
+		log.Fatalf("error opening database: %v", err)
+	}
+	defer sqlx.Close()
+
+	migrator, err := db.NewMigrator(sqlx)
+	if err != nil {
+		log.Fatalf("error loading migrations: %v", err)
+	}
+
+	ctx := context.Background()
+	switch flag.Arg(0) {
+	case "up":
+		err = migrator.Up(ctx)
+	case "down":
+		steps := 1
+		if flag.NArg() > 1 {
+			steps, err = strconv.Atoi(flag.Arg(1))
+			if err != nil || steps < 1 {
+				log.Fatalf("invalid number of steps %q", flag.Arg(1))
+			}
+		}
+		err = migrator.Down(ctx, steps)
+	case "version":
+		var version int
+		version, err = migrator.Version(ctx)
+		if err == nil {
+			fmt.Println(version)
+		}
+	default:
+		usage()
+	}
+	if err != nil {
+		log.Fatal(err)
+	}
+}
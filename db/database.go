@@ -6,10 +6,27 @@ import (
 	"github.com/jmoiron/sqlx"
 )
 
-func GetConnection() (*sqlx.DB, error) {
-	db, err := sqlx.Open("mysql", "dev:dev@tcp(localhost:3306)/go_kasus_4?parseTime=true")
+const (
+	DefaultMySQLDSN  = "dev:dev@tcp(localhost:3306)/go_kasus_4?parseTime=true"
+	DefaultSQLiteDSN = "file:ecom.db?_pragma=foreign_keys(1)&_pragma=busy_timeout(5000)&_time_format=sqlite&_txlock=immediate"
+)
+
+// GetConnection opens a database for the given driver ("mysql" or "sqlite").
+// An empty dsn falls back to the default for that driver.
+func GetConnection(driver, dsn string) (*sqlx.DB, error) {
+	if dsn == "" {
+		switch driver {
+		case "mysql":
+			dsn = DefaultMySQLDSN
+		case "sqlite":
+			dsn = DefaultSQLiteDSN
+		default:
+			return nil, fmt.Errorf("unsupported database driver %q", driver)
+		}
+	}
+	db, err := sqlx.Open(driver, dsn)
 	if err != nil {
-		return nil, fmt.Errorf("Error opening database: %w", err)
+		return nil, fmt.Errorf("error opening database: %w", err)
 	}
 	return db, nil
 }
@@ -0,0 +1,313 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"embed"
+	"errors"
+	"fmt"
+	"io/fs"
+	"path"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/jmoiron/sqlx"
+)
+
+//go:embed migrations
+var migrationFS embed.FS
+
+// migrationLockName is the advisory lock that keeps two instances from
+// migrating the same MySQL database at once.
+const migrationLockName = "ecom_schema_migrations"
+
+type migration struct {
+	Version int
+	Name    string
+	Up      string
+	Down    string
+}
+
+// Migrator applies the SQL files embedded under migrations/<driver>. Applied
+// versions are tracked in the schema_migrations table.
+//
+// On SQLite a whole run is one transaction, so a failed migration leaves
+// nothing behind. MySQL commits every DDL statement on its own, so a
+// migration that fails part way cannot be rolled back and stays half
+// applied. The statements that ran are counted in schema_migration_progress,
+// and the next run of the same direction resumes after them rather than
+// repeating them. A fix to the failing statement must therefore leave the
+// statements before it unchanged. The only statement that can run twice is
+// one whose count was not recorded because the process died right after it.
+type Migrator struct {
+	db         *sqlx.DB
+	migrations []migration
+}
+
+func NewMigrator(db *sqlx.DB) (*Migrator, error) {
+	migrations, err := loadMigrations(db.DriverName())
+	if err != nil {
+		return nil, err
+	}
+	return &Migrator{
+		db:         db,
+		migrations: migrations,
+	}, nil
+}
+
+// Up applies every migration that has not been applied yet.
+func (m *Migrator) Up(ctx context.Context) error {
+	return m.withLock(ctx, func(conn *sqlx.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for _, mg := range m.migrations {
+			if applied[mg.Version] {
+				continue
+			}
+			if err := execScript(ctx, conn, mg.Version, "up", mg.Up); err != nil {
+				return fmt.Errorf("error applying migration %06d_%s: %w", mg.Version, mg.Name, err)
+			}
+			_, err := conn.ExecContext(ctx, "INSERT INTO schema_migrations (version) VALUES (?)", mg.Version)
+			if err != nil {
+				return fmt.Errorf("error recording migration %06d: %w", mg.Version, err)
+			}
+			if err := clearProgress(ctx, conn, mg.Version); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+// Down rolls back the last steps applied migrations.
+func (m *Migrator) Down(ctx context.Context, steps int) error {
+	return m.withLock(ctx, func(conn *sqlx.Conn) error {
+		applied, err := appliedVersions(ctx, conn)
+		if err != nil {
+			return err
+		}
+		for i := len(m.migrations) - 1; i >= 0 && steps > 0; i-- {
+			mg := m.migrations[i]
+			if !applied[mg.Version] {
+				continue
+			}
+			if err := execScript(ctx, conn, mg.Version, "down", mg.Down); err != nil {
+				return fmt.Errorf("error reverting migration %06d_%s: %w", mg.Version, mg.Name, err)
+			}
+			_, err := conn.ExecContext(ctx, "DELETE FROM schema_migrations WHERE version=?", mg.Version)
+			if err != nil {
+				return fmt.Errorf("error removing migration %06d: %w", mg.Version, err)
+			}
+			if err := clearProgress(ctx, conn, mg.Version); err != nil {
+				return err
+			}
+			steps--
+		}
+		return nil
+	})
+}
+
+// Version returns the highest applied migration version, or 0 when the
+// database has not been migrated yet.
+func (m *Migrator) Version(ctx context.Context) (int, error) {
+	var version int
+	err := m.withLock(ctx, func(conn *sqlx.Conn) error {
+		return conn.GetContext(ctx, &version, "SELECT COALESCE(MAX(version), 0) FROM schema_migrations")
+	})
+	if err != nil {
+		return 0, fmt.Errorf("error getting schema version: %w", err)
+	}
+	return version, nil
+}
+
+// withLock runs fn on a single connection while holding the migration lock.
+// MySQL uses a named advisory lock; SQLite takes the database write lock with
+// BEGIN IMMEDIATE, which also makes the whole run atomic.
+func (m *Migrator) withLock(ctx context.Context, fn func(conn *sqlx.Conn) error) error {
+	conn, err := m.db.Connx(ctx)
+	if err != nil {
+		return fmt.Errorf("error getting connection: %w", err)
+	}
+	defer conn.Close()
+
+	switch m.db.DriverName() {
+	case "mysql":
+		var locked int
+		err := conn.GetContext(ctx, &locked, "SELECT GET_LOCK(?, 60)", migrationLockName)
+		if err != nil {
+			return fmt.Errorf("error acquiring migration lock: %w", err)
+		}
+		if locked != 1 {
+			return fmt.Errorf("timed out waiting for migration lock")
+		}
+		defer conn.ExecContext(context.Background(), "SELECT RELEASE_LOCK(?)", migrationLockName)
+
+		if err := createMigrationsTable(ctx, conn); err != nil {
+			return err
+		}
+		return fn(conn)
+	case "sqlite":
+		if _, err := conn.ExecContext(ctx, "BEGIN IMMEDIATE"); err != nil {
+			return fmt.Errorf("error acquiring migration lock: %w", err)
+		}
+		err := createMigrationsTable(ctx, conn)
+		if err == nil {
+			err = fn(conn)
+		}
+		if err != nil {
+			conn.ExecContext(context.Background(), "ROLLBACK")
+			return err
+		}
+		if _, err := conn.ExecContext(ctx, "COMMIT"); err != nil {
+			return fmt.Errorf("error committing migrations: %w", err)
+		}
+		return nil
+	default:
+		return fmt.Errorf("unsupported database driver %q", m.db.DriverName())
+	}
+}
+
+func createMigrationsTable(ctx context.Context, conn *sqlx.Conn) error {
+	_, err := conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migrations (version BIGINT NOT NULL PRIMARY KEY, applied_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP)")
+	if err != nil {
+		return fmt.Errorf("error creating schema_migrations table: %w", err)
+	}
+	_, err = conn.ExecContext(ctx, "CREATE TABLE IF NOT EXISTS schema_migration_progress (version BIGINT NOT NULL PRIMARY KEY, direction VARCHAR(4) NOT NULL, statements INT NOT NULL)")
+	if err != nil {
+		return fmt.Errorf("error creating schema_migration_progress table: %w", err)
+	}
+	return nil
+}
+
+func appliedVersions(ctx context.Context, conn *sqlx.Conn) (map[int]bool, error) {
+	var versions []int
+	err := conn.SelectContext(ctx, &versions, "SELECT version FROM schema_migrations")
+	if err != nil {
+		return nil, fmt.Errorf("error listing applied migrations: %w", err)
+	}
+	applied := make(map[int]bool, len(versions))
+	for _, v := range versions {
+		applied[v] = true
+	}
+	return applied, nil
+}
+
+// execScript runs the up or down file of a migration one statement at a
+// time, since the MySQL driver rejects multiple statements per Exec by
+// default. Statements are split on a semicolon that ends a line. The count of
+// statements run is recorded after each of them, and statements an earlier
+// run of the same direction got through are skipped.
+func execScript(ctx context.Context, conn *sqlx.Conn, version int, direction, script string) error {
+	var stmts []string
+	for _, stmt := range strings.Split(script, ";\n") {
+		stmt = strings.TrimSpace(strings.TrimSuffix(strings.TrimSpace(stmt), ";"))
+		if stmt != "" {
+			stmts = append(stmts, stmt)
+		}
+	}
+
+	done, err := startProgress(ctx, conn, version, direction)
+	if err != nil {
+		return err
+	}
+	for i := done; i < len(stmts); i++ {
+		if _, err := conn.ExecContext(ctx, stmts[i]); err != nil {
+			return fmt.Errorf("statement %d: %w", i+1, err)
+		}
+		_, err := conn.ExecContext(ctx, "UPDATE schema_migration_progress SET statements=? WHERE version=?", i+1, version)
+		if err != nil {
+			return fmt.Errorf("error recording migration progress: %w", err)
+		}
+	}
+	return nil
+}
+
+// startProgress returns how many statements of the version's script in the
+// given direction have already run. Progress left by the other direction is
+// reset, as it describes a different script.
+func startProgress(ctx context.Context, conn *sqlx.Conn, version int, direction string) (int, error) {
+	var progress struct {
+		Direction  string `db:"direction"`
+		Statements int    `db:"statements"`
+	}
+	err := conn.GetContext(ctx, &progress, "SELECT direction, statements FROM schema_migration_progress WHERE version=?", version)
+	switch {
+	case errors.Is(err, sql.ErrNoRows):
+		_, err = conn.ExecContext(ctx, "INSERT INTO schema_migration_progress (version, direction, statements) VALUES (?, ?, 0)", version, direction)
+	case err == nil && progress.Direction != direction:
+		progress.Statements = 0
+		_, err = conn.ExecContext(ctx, "UPDATE schema_migration_progress SET direction=?, statements=0 WHERE version=?", direction, version)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("error getting migration progress: %w", err)
+	}
+	return progress.Statements, nil
+}
+
+func clearProgress(ctx context.Context, conn *sqlx.Conn, version int) error {
+	_, err := conn.ExecContext(ctx, "DELETE FROM schema_migration_progress WHERE version=?", version)
+	if err != nil {
+		return fmt.Errorf("error clearing migration progress %06d: %w", version, err)
+	}
+	return nil
+}
+
+// loadMigrations reads migrations/<driver>/NNNNNN_name.{up,down}.sql.
+func loadMigrations(driver string) ([]migration, error) {
+	dir := path.Join("migrations", driver)
+	entries, err := fs.ReadDir(migrationFS, dir)
+	if err != nil {
+		return nil, fmt.Errorf("no migrations for driver %q: %w", driver, err)
+	}
+
+	byVersion := make(map[int]*migration)
+	for _, e := range entries {
+		name := e.Name()
+		var direction string
+		switch {
+		case strings.HasSuffix(name, ".up.sql"):
+			direction = "up"
+		case strings.HasSuffix(name, ".down.sql"):
+			direction = "down"
+		default:
+			continue
+		}
+		base := strings.TrimSuffix(name, "."+direction+".sql")
+		versionStr, label, ok := strings.Cut(base, "_")
+		if !ok {
+			return nil, fmt.Errorf("invalid migration file name %q", name)
+		}
+		version, err := strconv.Atoi(versionStr)
+		if err != nil {
+			return nil, fmt.Errorf("invalid migration version in %q: %w", name, err)
+		}
+		body, err := fs.ReadFile(migrationFS, path.Join(dir, name))
+		if err != nil {
+			return nil, fmt.Errorf("error reading migration %q: %w", name, err)
+		}
+
+		mg, ok := byVersion[version]
+		if !ok {
+			mg = &migration{Version: version, Name: label}
+			byVersion[version] = mg
+		}
+		if direction == "up" {
+			mg.Up = string(body)
+		} else {
+			mg.Down = string(body)
+		}
+	}
+
+	migrations := make([]migration, 0, len(byVersion))
+	for _, mg := range byVersion {
+		if mg.Up == "" {
+			return nil, fmt.Errorf("migration %06d_%s has no up file", mg.Version, mg.Name)
+		}
+		migrations = append(migrations, *mg)
+	}
+	sort.Slice(migrations, func(i, j int) bool { return migrations[i].Version < migrations[j].Version })
+	return migrations, nil
+}
@@ -0,0 +1,108 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "modernc.org/sqlite"
+)
+
+// testDB returns an empty in-memory SQLite database. Every connection to
+// file::memory: opens a database of its own, so the pool is kept to one.
+func testDB(t *testing.T) *sqlx.DB {
+	t.Helper()
+	x, err := GetConnection("sqlite", "file::memory:?_pragma=foreign_keys(1)&_time_format=sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMaxOpenConns(1)
+	t.Cleanup(func() { x.Close() })
+	return x
+}
+
+func TestMigratorUpDown(t *testing.T) {
+	ctx := context.Background()
+	m, err := NewMigrator(testDB(t))
+	if err != nil {
+		t.Fatal(err)
+	}
+	latest := m.migrations[len(m.migrations)-1].Version
+
+	steps := []struct {
+		name string
+		run  func() error
+		want int
+	}{
+		{"up", func() error { return m.Up(ctx) }, latest},
+		{"up again", func() error { return m.Up(ctx) }, latest},
+		{"down one", func() error { return m.Down(ctx, 1) }, m.migrations[len(m.migrations)-2].Version},
+		{"down all", func() error { return m.Down(ctx, len(m.migrations)) }, 0},
+		{"up from scratch", func() error { return m.Up(ctx) }, latest},
+	}
+	for _, step := range steps {
+		if err := step.run(); err != nil {
+			t.Fatalf("%s: %v", step.name, err)
+		}
+		got, err := m.Version(ctx)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != step.want {
+			t.Errorf("%s: version = %d, want %d", step.name, got, step.want)
+		}
+		var left int
+		if err := m.db.GetContext(ctx, &left, "SELECT COUNT(*) FROM schema_migration_progress"); err != nil {
+			t.Fatal(err)
+		}
+		if left != 0 {
+			t.Errorf("%s: %d migrations left in progress", step.name, left)
+		}
+	}
+}
+
+// TestExecScriptResumes runs a script outside of a transaction, the way every
+// MySQL migration runs, and checks that a second run skips the statements
+// the failed one got through.
+func TestExecScriptResumes(t *testing.T) {
+	ctx := context.Background()
+	conn, err := testDB(t).Connx(ctx)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer conn.Close()
+	if err := createMigrationsTable(ctx, conn); err != nil {
+		t.Fatal(err)
+	}
+
+	script := "CREATE TABLE a (id INT);\nINSERT INTO b (id) VALUES (1);\nINSERT INTO a (id) VALUES (2);\n"
+	if err := execScript(ctx, conn, 1, "up", script); err == nil {
+		t.Fatal("first run succeeded, want an error from the missing table")
+	}
+	if _, err := conn.ExecContext(ctx, "CREATE TABLE b (id INT)"); err != nil {
+		t.Fatal(err)
+	}
+	if err := execScript(ctx, conn, 1, "up", script); err != nil {
+		t.Fatalf("second run: %v", err)
+	}
+
+	var rows int
+	if err := conn.GetContext(ctx, &rows, "SELECT (SELECT COUNT(*) FROM a) + (SELECT COUNT(*) FROM b)"); err != nil {
+		t.Fatal(err)
+	}
+	if rows != 2 {
+		t.Errorf("got %d rows, want 2", rows)
+	}
+
+	// Progress of the other direction does not count for this one.
+	if err := execScript(ctx, conn, 1, "down", "DROP TABLE a;\nDROP TABLE b;\n"); err != nil {
+		t.Fatalf("down: %v", err)
+	}
+	var tables int
+	if err := conn.GetContext(ctx, &tables, "SELECT COUNT(*) FROM sqlite_master WHERE type='table' AND name IN ('a', 'b')"); err != nil {
+		t.Fatal(err)
+	}
+	if tables != 0 {
+		t.Errorf("%d tables left after down, want 0", tables)
+	}
+}
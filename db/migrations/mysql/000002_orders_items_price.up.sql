@@ -0,0 +1 @@
+ALTER TABLE orders ADD COLUMN items_price DECIMAL(10, 2) NOT NULL DEFAULT 0 AFTER payment_method;
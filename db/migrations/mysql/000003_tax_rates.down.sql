@@ -0,0 +1,10 @@
+ALTER TABLE order_items
+    DROP COLUMN tax_inclusive,
+    DROP COLUMN tax_price,
+    DROP COLUMN tax_rate;
+
+ALTER TABLE orders
+    DROP COLUMN shipping_region,
+    DROP COLUMN shipping_country;
+
+DROP TABLE IF EXISTS tax_rates;
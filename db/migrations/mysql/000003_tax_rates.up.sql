@@ -0,0 +1,22 @@
+CREATE TABLE tax_rates (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    country VARCHAR(2) NOT NULL DEFAULT '',
+    region VARCHAR(255) NOT NULL DEFAULT '',
+    category VARCHAR(255) NOT NULL DEFAULT '',
+    rate DECIMAL(6, 4) NOT NULL,
+    inclusive BOOLEAN NOT NULL DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+INSERT INTO tax_rates (name, rate) VALUES ('Default', 0.15);
+
+ALTER TABLE orders
+    ADD COLUMN shipping_country VARCHAR(2) NOT NULL DEFAULT '' AFTER payment_method,
+    ADD COLUMN shipping_region VARCHAR(255) NOT NULL DEFAULT '' AFTER shipping_country;
+
+ALTER TABLE order_items
+    ADD COLUMN tax_rate DECIMAL(6, 4) NOT NULL DEFAULT 0 AFTER price,
+    ADD COLUMN tax_price DECIMAL(10, 2) NOT NULL DEFAULT 0 AFTER tax_rate,
+    ADD COLUMN tax_inclusive BOOLEAN NOT NULL DEFAULT FALSE AFTER tax_price;
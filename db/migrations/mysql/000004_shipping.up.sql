@@ -0,0 +1,3 @@
+ALTER TABLE products ADD COLUMN weight DECIMAL(10, 3) NOT NULL DEFAULT 0 AFTER price;
+
+ALTER TABLE orders ADD COLUMN shipping_method VARCHAR(50) NOT NULL DEFAULT '' AFTER shipping_region;
@@ -0,0 +1,2 @@
+DROP INDEX idx_products_category ON products;
+DROP INDEX ft_products_name_description ON products;
@@ -0,0 +1,2 @@
+ALTER TABLE products ADD FULLTEXT INDEX ft_products_name_description (name, description);
+CREATE INDEX idx_products_category ON products (category);
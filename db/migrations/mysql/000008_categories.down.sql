@@ -0,0 +1,9 @@
+ALTER TABLE products DROP FOREIGN KEY fk_products_category;
+ALTER TABLE products DROP INDEX idx_products_category_id;
+ALTER TABLE products ADD COLUMN category VARCHAR(255) NOT NULL DEFAULT '' AFTER image;
+
+UPDATE products SET category = COALESCE((SELECT name FROM categories WHERE categories.id = products.category_id), '');
+
+ALTER TABLE products DROP COLUMN category_id;
+CREATE INDEX idx_products_category ON products (category);
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,32 @@
+CREATE TABLE categories (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    parent_id INT NULL,
+    name VARCHAR(255) NOT NULL,
+    slug VARCHAR(255) NOT NULL,
+    sort_order INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL,
+    UNIQUE INDEX idx_categories_slug (slug),
+    INDEX idx_categories_parent_id (parent_id),
+    CONSTRAINT fk_categories_parent FOREIGN KEY (parent_id) REFERENCES categories (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- Every distinct free-text category becomes a top-level category.
+INSERT INTO categories (name, slug)
+SELECT MIN(TRIM(category)), LOWER(REPLACE(TRIM(category), ' ', '-'))
+FROM products
+WHERE TRIM(category) <> ''
+GROUP BY LOWER(REPLACE(TRIM(category), ' ', '-'));
+
+ALTER TABLE products ADD COLUMN category_id INT NULL AFTER image;
+
+UPDATE products SET category_id = (
+    SELECT id FROM categories WHERE categories.slug = LOWER(REPLACE(TRIM(products.category), ' ', '-'))
+);
+
+-- Tax rates now match categories by slug.
+UPDATE tax_rates SET category = LOWER(REPLACE(TRIM(category), ' ', '-')) WHERE category <> '';
+
+DROP INDEX idx_products_category ON products;
+ALTER TABLE products DROP COLUMN category;
+ALTER TABLE products ADD INDEX idx_products_category_id (category_id), ADD CONSTRAINT fk_products_category FOREIGN KEY (category_id) REFERENCES categories (id);
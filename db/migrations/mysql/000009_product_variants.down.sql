@@ -0,0 +1,4 @@
+ALTER TABLE order_items DROP COLUMN variant_id, DROP COLUMN sku;
+
+DROP TABLE IF EXISTS product_variants;
+DROP TABLE IF EXISTS product_options;
@@ -0,0 +1,28 @@
+CREATE TABLE product_options (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    product_id INT NOT NULL,
+    name VARCHAR(255) NOT NULL,
+    choices TEXT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    INDEX idx_product_options_product_id (product_id),
+    CONSTRAINT fk_product_options_product FOREIGN KEY (product_id) REFERENCES products (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE product_variants (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    product_id INT NOT NULL,
+    sku VARCHAR(64) NOT NULL,
+    option_values TEXT NOT NULL,
+    price DECIMAL(10, 2) NULL,
+    image VARCHAR(255) NOT NULL DEFAULT '',
+    count_in_stock INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL,
+    UNIQUE INDEX idx_product_variants_sku (sku),
+    INDEX idx_product_variants_product_id (product_id),
+    CONSTRAINT fk_product_variants_product FOREIGN KEY (product_id) REFERENCES products (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+ALTER TABLE order_items
+    ADD COLUMN sku VARCHAR(64) NOT NULL DEFAULT '' AFTER name,
+    ADD COLUMN variant_id INT NULL AFTER product_id;
@@ -0,0 +1,13 @@
+CREATE TABLE product_images (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    product_id INT NOT NULL,
+    position INT NOT NULL DEFAULT 0,
+    blob_key VARCHAR(255) NOT NULL,
+    content_type VARCHAR(50) NOT NULL,
+    width INT NOT NULL,
+    height INT NOT NULL,
+    thumbnails TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_product_images_product_id (product_id, position),
+    CONSTRAINT fk_product_images_product FOREIGN KEY (product_id) REFERENCES products (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
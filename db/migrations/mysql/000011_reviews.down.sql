@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS reviews;
+
+ALTER TABLE products MODIFY rating INT NOT NULL DEFAULT 0;
@@ -0,0 +1,20 @@
+-- Ratings are now averages of the product reviews. The old values were set
+-- by hand and have no reviews behind them.
+ALTER TABLE products MODIFY rating DECIMAL(3, 2) NOT NULL DEFAULT 0;
+
+UPDATE products SET rating = 0, num_reviews = 0;
+
+CREATE TABLE reviews (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    product_id INT NOT NULL,
+    user_id INT NOT NULL,
+    rating INT NOT NULL,
+    title VARCHAR(120) NOT NULL,
+    body TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL,
+    UNIQUE KEY uq_reviews_product_user (product_id, user_id),
+    INDEX idx_reviews_product_rating (product_id, rating),
+    CONSTRAINT fk_reviews_product FOREIGN KEY (product_id) REFERENCES products (id),
+    CONSTRAINT fk_reviews_user FOREIGN KEY (user_id) REFERENCES users (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
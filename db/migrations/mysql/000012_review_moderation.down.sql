@@ -0,0 +1,5 @@
+ALTER TABLE reviews
+    DROP INDEX idx_reviews_status,
+    DROP COLUMN moderated_at,
+    DROP COLUMN moderation_reason,
+    DROP COLUMN status;
@@ -0,0 +1,6 @@
+-- Reviews written before moderation existed stay published.
+ALTER TABLE reviews
+    ADD COLUMN status VARCHAR(20) NOT NULL DEFAULT 'approved',
+    ADD COLUMN moderation_reason VARCHAR(255) NOT NULL DEFAULT '',
+    ADD COLUMN moderated_at TIMESTAMP NULL,
+    ADD INDEX idx_reviews_status (status, id);
@@ -0,0 +1,9 @@
+DELETE FROM cart_items WHERE cart_id IN (SELECT id FROM carts WHERE user_id IS NULL);
+
+DELETE FROM carts WHERE user_id IS NULL;
+
+ALTER TABLE carts
+    DROP INDEX idx_carts_updated_at,
+    DROP INDEX idx_carts_token,
+    DROP COLUMN token,
+    MODIFY user_id INT NOT NULL;
@@ -0,0 +1,6 @@
+-- Guest carts have no user and are found by their token instead.
+ALTER TABLE carts
+    MODIFY user_id INT NULL,
+    ADD COLUMN token VARCHAR(64) NULL AFTER user_id,
+    ADD UNIQUE INDEX idx_carts_token (token),
+    ADD INDEX idx_carts_updated_at (updated_at);
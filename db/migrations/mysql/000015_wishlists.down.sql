@@ -0,0 +1,3 @@
+DROP TABLE wishlist_items;
+
+DROP TABLE wishlists;
@@ -0,0 +1,9 @@
+ALTER TABLE orders DROP COLUMN discount_price, DROP COLUMN coupon_code, DROP COLUMN coupon_id;
+
+DROP TABLE coupon_redemptions;
+
+DROP TABLE coupon_products;
+
+DROP TABLE coupon_categories;
+
+DROP TABLE coupons;
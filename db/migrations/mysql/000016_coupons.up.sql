@@ -0,0 +1,49 @@
+CREATE TABLE coupons (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    code VARCHAR(64) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    value DECIMAL(10, 2) NOT NULL,
+    min_order_value DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP NULL,
+    ends_at TIMESTAMP NULL,
+    usage_limit INT NOT NULL DEFAULT 0,
+    per_customer_limit INT NOT NULL DEFAULT 0,
+    times_used INT NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL,
+    UNIQUE INDEX idx_coupons_code (code)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- The restrictions keep no foreign key on the category or product, so
+-- deleting one narrows the coupon instead of failing or widening it.
+CREATE TABLE coupon_categories (
+    coupon_id INT NOT NULL,
+    category_id INT NOT NULL,
+    PRIMARY KEY (coupon_id, category_id),
+    CONSTRAINT fk_coupon_categories_coupon FOREIGN KEY (coupon_id) REFERENCES coupons (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE coupon_products (
+    coupon_id INT NOT NULL,
+    product_id INT NOT NULL,
+    PRIMARY KEY (coupon_id, product_id),
+    CONSTRAINT fk_coupon_products_coupon FOREIGN KEY (coupon_id) REFERENCES coupons (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE coupon_redemptions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    coupon_id INT NOT NULL,
+    user_id INT NOT NULL,
+    order_id INT NOT NULL,
+    discount DECIMAL(10, 2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    INDEX idx_coupon_redemptions_coupon_user (coupon_id, user_id),
+    UNIQUE INDEX idx_coupon_redemptions_order_id (order_id),
+    CONSTRAINT fk_coupon_redemptions_coupon FOREIGN KEY (coupon_id) REFERENCES coupons (id),
+    CONSTRAINT fk_coupon_redemptions_order FOREIGN KEY (order_id) REFERENCES orders (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+ALTER TABLE orders
+    ADD COLUMN coupon_id INT NULL AFTER shipping_method,
+    ADD COLUMN coupon_code VARCHAR(64) NOT NULL DEFAULT '' AFTER coupon_id,
+    ADD COLUMN discount_price DECIMAL(10, 2) NOT NULL DEFAULT 0 AFTER items_price;
@@ -0,0 +1,7 @@
+ALTER TABLE orders DROP COLUMN promotion_price;
+
+DROP TABLE promotion_products;
+
+DROP TABLE promotion_categories;
+
+DROP TABLE promotions;
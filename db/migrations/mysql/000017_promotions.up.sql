@@ -0,0 +1,30 @@
+CREATE TABLE promotions (
+    id INT AUTO_INCREMENT PRIMARY KEY,
+    name VARCHAR(255) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    percent DECIMAL(5, 2) NOT NULL DEFAULT 0,
+    buy_quantity INT NOT NULL DEFAULT 0,
+    get_quantity INT NOT NULL DEFAULT 0,
+    starts_at TIMESTAMP NULL,
+    ends_at TIMESTAMP NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NULL
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+-- Like the coupon restrictions, these keep no foreign key on the category
+-- or product.
+CREATE TABLE promotion_categories (
+    promotion_id INT NOT NULL,
+    category_id INT NOT NULL,
+    PRIMARY KEY (promotion_id, category_id),
+    CONSTRAINT fk_promotion_categories_promotion FOREIGN KEY (promotion_id) REFERENCES promotions (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+CREATE TABLE promotion_products (
+    promotion_id INT NOT NULL,
+    product_id INT NOT NULL,
+    PRIMARY KEY (promotion_id, product_id),
+    CONSTRAINT fk_promotion_products_promotion FOREIGN KEY (promotion_id) REFERENCES promotions (id)
+) ENGINE = InnoDB DEFAULT CHARSET = utf8mb4;
+
+ALTER TABLE orders ADD COLUMN promotion_price DECIMAL(10, 2) NOT NULL DEFAULT 0 AFTER items_price;
@@ -0,0 +1,70 @@
+ALTER TABLE orders DROP COLUMN currency;
+
+ALTER TABLE coupons ADD COLUMN value DECIMAL(10, 2) NOT NULL DEFAULT 0 AFTER type;
+
+UPDATE coupons SET value = CASE type WHEN 'fixed' THEN amount / 100 ELSE percent END;
+
+ALTER TABLE coupons
+    MODIFY value DECIMAL(10, 2) NOT NULL,
+    DROP COLUMN amount,
+    DROP COLUMN percent;
+
+ALTER TABLE products
+    MODIFY price DECIMAL(20, 2) NOT NULL DEFAULT 0;
+
+UPDATE products SET price = price / 100;
+
+ALTER TABLE products
+    MODIFY price DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE product_variants
+    MODIFY price DECIMAL(20, 2) NULL;
+
+UPDATE product_variants SET price = price / 100;
+
+ALTER TABLE product_variants
+    MODIFY price DECIMAL(10, 2) NULL;
+
+ALTER TABLE order_items
+    MODIFY price DECIMAL(20, 2) NOT NULL DEFAULT 0,
+    MODIFY tax_price DECIMAL(20, 2) NOT NULL DEFAULT 0;
+
+UPDATE order_items SET price = price / 100, tax_price = tax_price / 100;
+
+ALTER TABLE order_items
+    MODIFY price DECIMAL(10, 2) NOT NULL,
+    MODIFY tax_price DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE orders
+    MODIFY items_price DECIMAL(20, 2) NOT NULL DEFAULT 0,
+    MODIFY promotion_price DECIMAL(20, 2) NOT NULL DEFAULT 0,
+    MODIFY discount_price DECIMAL(20, 2) NOT NULL DEFAULT 0,
+    MODIFY tax_price DECIMAL(20, 2) NOT NULL DEFAULT 0,
+    MODIFY shipping_price DECIMAL(20, 2) NOT NULL DEFAULT 0,
+    MODIFY total_price DECIMAL(20, 2) NOT NULL DEFAULT 0;
+
+UPDATE orders SET items_price = items_price / 100, promotion_price = promotion_price / 100, discount_price = discount_price / 100, tax_price = tax_price / 100, shipping_price = shipping_price / 100, total_price = total_price / 100;
+
+ALTER TABLE orders
+    MODIFY items_price DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    MODIFY promotion_price DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    MODIFY discount_price DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    MODIFY tax_price DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    MODIFY shipping_price DECIMAL(10, 2) NOT NULL DEFAULT 0,
+    MODIFY total_price DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE coupons
+    MODIFY min_order_value DECIMAL(20, 2) NOT NULL DEFAULT 0;
+
+UPDATE coupons SET min_order_value = min_order_value / 100;
+
+ALTER TABLE coupons
+    MODIFY min_order_value DECIMAL(10, 2) NOT NULL DEFAULT 0;
+
+ALTER TABLE coupon_redemptions
+    MODIFY discount DECIMAL(20, 2) NOT NULL DEFAULT 0;
+
+UPDATE coupon_redemptions SET discount = discount / 100;
+
+ALTER TABLE coupon_redemptions
+    MODIFY discount DECIMAL(10, 2) NOT NULL;
@@ -0,0 +1,78 @@
+-- Money is kept as integer minor units instead of decimals, and orders
+-- record the currency they were placed in. Existing amounts are taken to be
+-- US dollars. A store that sold in another currency has to set
+-- orders.currency afterwards, and rescale if that currency has no cents.
+-- The columns are widened first, so scaling them up cannot overflow.
+
+ALTER TABLE products
+    MODIFY price DECIMAL(20, 2) NOT NULL DEFAULT 0;
+
+UPDATE products SET price = ROUND(price * 100);
+
+ALTER TABLE products
+    MODIFY price BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE product_variants
+    MODIFY price DECIMAL(20, 2) NULL;
+
+UPDATE product_variants SET price = ROUND(price * 100);
+
+ALTER TABLE product_variants
+    MODIFY price BIGINT NULL;
+
+ALTER TABLE order_items
+    MODIFY price DECIMAL(20, 2) NOT NULL DEFAULT 0,
+    MODIFY tax_price DECIMAL(20, 2) NOT NULL DEFAULT 0;
+
+UPDATE order_items SET price = ROUND(price * 100), tax_price = ROUND(tax_price * 100);
+
+ALTER TABLE order_items
+    MODIFY price BIGINT NOT NULL,
+    MODIFY tax_price BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE orders
+    MODIFY items_price DECIMAL(20, 2) NOT NULL DEFAULT 0,
+    MODIFY promotion_price DECIMAL(20, 2) NOT NULL DEFAULT 0,
+    MODIFY discount_price DECIMAL(20, 2) NOT NULL DEFAULT 0,
+    MODIFY tax_price DECIMAL(20, 2) NOT NULL DEFAULT 0,
+    MODIFY shipping_price DECIMAL(20, 2) NOT NULL DEFAULT 0,
+    MODIFY total_price DECIMAL(20, 2) NOT NULL DEFAULT 0;
+
+UPDATE orders SET items_price = ROUND(items_price * 100), promotion_price = ROUND(promotion_price * 100), discount_price = ROUND(discount_price * 100), tax_price = ROUND(tax_price * 100), shipping_price = ROUND(shipping_price * 100), total_price = ROUND(total_price * 100);
+
+ALTER TABLE orders
+    MODIFY items_price BIGINT NOT NULL DEFAULT 0,
+    MODIFY promotion_price BIGINT NOT NULL DEFAULT 0,
+    MODIFY discount_price BIGINT NOT NULL DEFAULT 0,
+    MODIFY tax_price BIGINT NOT NULL DEFAULT 0,
+    MODIFY shipping_price BIGINT NOT NULL DEFAULT 0,
+    MODIFY total_price BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE coupons
+    MODIFY min_order_value DECIMAL(20, 2) NOT NULL DEFAULT 0;
+
+UPDATE coupons SET min_order_value = ROUND(min_order_value * 100);
+
+ALTER TABLE coupons
+    MODIFY min_order_value BIGINT NOT NULL DEFAULT 0;
+
+ALTER TABLE coupon_redemptions
+    MODIFY discount DECIMAL(20, 2) NOT NULL DEFAULT 0;
+
+UPDATE coupon_redemptions SET discount = ROUND(discount * 100);
+
+ALTER TABLE coupon_redemptions
+    MODIFY discount BIGINT NOT NULL;
+
+-- Percentage coupons keep their percent; fixed ones get an amount.
+ALTER TABLE coupons
+    ADD COLUMN percent DECIMAL(5, 2) NOT NULL DEFAULT 0 AFTER type,
+    ADD COLUMN amount BIGINT NOT NULL DEFAULT 0 AFTER percent;
+
+UPDATE coupons SET percent = value WHERE type = 'percentage';
+
+UPDATE coupons SET amount = ROUND(value * 100) WHERE type = 'fixed';
+
+ALTER TABLE coupons DROP COLUMN value;
+
+ALTER TABLE orders ADD COLUMN currency CHAR(3) NOT NULL DEFAULT 'USD' AFTER coupon_code;
@@ -0,0 +1 @@
+ALTER TABLE orders ADD COLUMN items_price REAL NOT NULL DEFAULT 0;
@@ -0,0 +1,8 @@
+ALTER TABLE order_items DROP COLUMN tax_inclusive;
+ALTER TABLE order_items DROP COLUMN tax_price;
+ALTER TABLE order_items DROP COLUMN tax_rate;
+
+ALTER TABLE orders DROP COLUMN shipping_region;
+ALTER TABLE orders DROP COLUMN shipping_country;
+
+DROP TABLE IF EXISTS tax_rates;
@@ -0,0 +1,20 @@
+CREATE TABLE tax_rates (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    country TEXT NOT NULL DEFAULT '',
+    region TEXT NOT NULL DEFAULT '',
+    category TEXT NOT NULL DEFAULT '',
+    rate REAL NOT NULL,
+    inclusive BOOLEAN NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME
+);
+
+INSERT INTO tax_rates (name, rate) VALUES ('Default', 0.15);
+
+ALTER TABLE orders ADD COLUMN shipping_country TEXT NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN shipping_region TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE order_items ADD COLUMN tax_rate REAL NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN tax_price REAL NOT NULL DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN tax_inclusive BOOLEAN NOT NULL DEFAULT 0;
@@ -0,0 +1,3 @@
+ALTER TABLE orders DROP COLUMN shipping_method;
+
+ALTER TABLE products DROP COLUMN weight;
@@ -0,0 +1,3 @@
+ALTER TABLE products ADD COLUMN weight REAL NOT NULL DEFAULT 0;
+
+ALTER TABLE orders ADD COLUMN shipping_method TEXT NOT NULL DEFAULT '';
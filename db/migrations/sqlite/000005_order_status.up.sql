@@ -0,0 +1,13 @@
+ALTER TABLE orders ADD COLUMN status TEXT NOT NULL DEFAULT 'pending';
+
+CREATE TABLE order_status_history (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    order_id INTEGER NOT NULL REFERENCES orders (id),
+    from_status TEXT NOT NULL DEFAULT '',
+    to_status TEXT NOT NULL,
+    changed_by INTEGER NOT NULL,
+    reason TEXT NOT NULL DEFAULT '',
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_order_status_history_order_id ON order_status_history (order_id);
@@ -0,0 +1 @@
+DROP INDEX IF EXISTS idx_order_items_order_id;
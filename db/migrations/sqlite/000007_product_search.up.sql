@@ -0,0 +1 @@
+CREATE INDEX idx_products_category ON products (category);
@@ -0,0 +1,8 @@
+DROP INDEX IF EXISTS idx_products_category_id;
+ALTER TABLE products ADD COLUMN category TEXT NOT NULL DEFAULT '';
+
+UPDATE products SET category = COALESCE((SELECT name FROM categories WHERE categories.id = products.category_id), '');
+
+ALTER TABLE products DROP COLUMN category_id;
+CREATE INDEX idx_products_category ON products (category);
+DROP TABLE IF EXISTS categories;
@@ -0,0 +1,34 @@
+CREATE TABLE categories (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    parent_id INTEGER REFERENCES categories (id),
+    name TEXT NOT NULL,
+    slug TEXT NOT NULL,
+    sort_order INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME
+);
+
+CREATE UNIQUE INDEX idx_categories_slug ON categories (slug);
+CREATE INDEX idx_categories_parent_id ON categories (parent_id);
+
+-- Every distinct free-text category becomes a top-level category.
+INSERT INTO categories (name, slug)
+SELECT MIN(TRIM(category)), LOWER(REPLACE(TRIM(category), ' ', '-'))
+FROM products
+WHERE TRIM(category) <> ''
+GROUP BY LOWER(REPLACE(TRIM(category), ' ', '-'));
+
+-- No REFERENCES clause: SQLite cannot drop a column that has one, which the
+-- down migration needs. The storer checks the category instead.
+ALTER TABLE products ADD COLUMN category_id INTEGER;
+
+UPDATE products SET category_id = (
+    SELECT id FROM categories WHERE categories.slug = LOWER(REPLACE(TRIM(products.category), ' ', '-'))
+);
+
+-- Tax rates now match categories by slug.
+UPDATE tax_rates SET category = LOWER(REPLACE(TRIM(category), ' ', '-')) WHERE category <> '';
+
+DROP INDEX IF EXISTS idx_products_category;
+ALTER TABLE products DROP COLUMN category;
+CREATE INDEX idx_products_category_id ON products (category_id);
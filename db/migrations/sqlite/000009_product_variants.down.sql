@@ -0,0 +1,5 @@
+ALTER TABLE order_items DROP COLUMN variant_id;
+ALTER TABLE order_items DROP COLUMN sku;
+
+DROP TABLE IF EXISTS product_variants;
+DROP TABLE IF EXISTS product_options;
@@ -0,0 +1,27 @@
+CREATE TABLE product_options (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    name TEXT NOT NULL,
+    choices TEXT NOT NULL,
+    position INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX idx_product_options_product_id ON product_options (product_id);
+
+CREATE TABLE product_variants (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    sku TEXT NOT NULL,
+    option_values TEXT NOT NULL,
+    price REAL,
+    image TEXT NOT NULL DEFAULT '',
+    count_in_stock INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME
+);
+
+CREATE UNIQUE INDEX idx_product_variants_sku ON product_variants (sku);
+CREATE INDEX idx_product_variants_product_id ON product_variants (product_id);
+
+ALTER TABLE order_items ADD COLUMN sku TEXT NOT NULL DEFAULT '';
+ALTER TABLE order_items ADD COLUMN variant_id INTEGER;
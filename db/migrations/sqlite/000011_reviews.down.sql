@@ -0,0 +1,3 @@
+DROP TABLE IF EXISTS reviews;
+
+UPDATE products SET rating = CAST(ROUND(rating) AS INTEGER);
@@ -0,0 +1,18 @@
+-- Ratings are now averages of the product reviews. The old values were set
+-- by hand and have no reviews behind them. The INTEGER column keeps
+-- fractional averages as REAL values.
+UPDATE products SET rating = 0, num_reviews = 0;
+
+CREATE TABLE reviews (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    user_id INTEGER NOT NULL REFERENCES users (id),
+    rating INTEGER NOT NULL,
+    title TEXT NOT NULL,
+    body TEXT NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME,
+    UNIQUE (product_id, user_id)
+);
+
+CREATE INDEX idx_reviews_product_rating ON reviews (product_id, rating);
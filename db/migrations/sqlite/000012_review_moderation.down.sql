@@ -0,0 +1,7 @@
+DROP INDEX IF EXISTS idx_reviews_status;
+
+ALTER TABLE reviews DROP COLUMN moderated_at;
+
+ALTER TABLE reviews DROP COLUMN moderation_reason;
+
+ALTER TABLE reviews DROP COLUMN status;
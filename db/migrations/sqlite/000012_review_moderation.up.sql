@@ -0,0 +1,8 @@
+-- Reviews written before moderation existed stay published.
+ALTER TABLE reviews ADD COLUMN status TEXT NOT NULL DEFAULT 'approved';
+
+ALTER TABLE reviews ADD COLUMN moderation_reason TEXT NOT NULL DEFAULT '';
+
+ALTER TABLE reviews ADD COLUMN moderated_at DATETIME;
+
+CREATE INDEX idx_reviews_status ON reviews (status, id);
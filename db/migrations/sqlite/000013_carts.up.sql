@@ -0,0 +1,20 @@
+CREATE TABLE carts (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL UNIQUE REFERENCES users (id),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME
+);
+
+CREATE TABLE cart_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    cart_id INTEGER NOT NULL REFERENCES carts (id),
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    variant_id INTEGER,
+    quantity INTEGER NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME
+);
+
+CREATE INDEX idx_cart_items_cart_id ON cart_items (cart_id);
+
+CREATE INDEX idx_cart_items_product_id ON cart_items (product_id);
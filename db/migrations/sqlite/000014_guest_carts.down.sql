@@ -0,0 +1,37 @@
+DELETE FROM cart_items WHERE cart_id IN (SELECT id FROM carts WHERE user_id IS NULL);
+
+DELETE FROM carts WHERE user_id IS NULL;
+
+CREATE TABLE carts_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL UNIQUE REFERENCES users (id),
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME
+);
+
+INSERT INTO carts_old (id, user_id, created_at, updated_at)
+SELECT id, user_id, created_at, updated_at FROM carts;
+
+CREATE TABLE cart_items_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    cart_id INTEGER NOT NULL REFERENCES carts_old (id),
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    variant_id INTEGER,
+    quantity INTEGER NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME
+);
+
+INSERT INTO cart_items_old SELECT * FROM cart_items;
+
+DROP TABLE cart_items;
+
+DROP TABLE carts;
+
+ALTER TABLE carts_old RENAME TO carts;
+
+ALTER TABLE cart_items_old RENAME TO cart_items;
+
+CREATE INDEX idx_cart_items_cart_id ON cart_items (cart_id);
+
+CREATE INDEX idx_cart_items_product_id ON cart_items (product_id);
@@ -0,0 +1,39 @@
+-- Guest carts have no user and are found by their token instead. SQLite
+-- cannot make a column nullable, so both cart tables are rebuilt; renaming
+-- the new tables also updates the foreign key of cart_items.
+CREATE TABLE carts_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER UNIQUE REFERENCES users (id),
+    token TEXT UNIQUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME
+);
+
+INSERT INTO carts_new (id, user_id, created_at, updated_at)
+SELECT id, user_id, created_at, updated_at FROM carts;
+
+CREATE TABLE cart_items_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    cart_id INTEGER NOT NULL REFERENCES carts_new (id),
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    variant_id INTEGER,
+    quantity INTEGER NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME
+);
+
+INSERT INTO cart_items_new SELECT * FROM cart_items;
+
+DROP TABLE cart_items;
+
+DROP TABLE carts;
+
+ALTER TABLE carts_new RENAME TO carts;
+
+ALTER TABLE cart_items_new RENAME TO cart_items;
+
+CREATE INDEX idx_carts_updated_at ON carts (updated_at);
+
+CREATE INDEX idx_cart_items_cart_id ON cart_items (cart_id);
+
+CREATE INDEX idx_cart_items_product_id ON cart_items (product_id);
@@ -0,0 +1,22 @@
+CREATE TABLE wishlists (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    user_id INTEGER NOT NULL REFERENCES users (id),
+    name TEXT NOT NULL,
+    share_token TEXT UNIQUE,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME
+);
+
+CREATE INDEX idx_wishlists_user_id ON wishlists (user_id);
+
+CREATE TABLE wishlist_items (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    wishlist_id INTEGER NOT NULL REFERENCES wishlists (id),
+    product_id INTEGER NOT NULL REFERENCES products (id),
+    variant_id INTEGER,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_wishlist_items_wishlist_id ON wishlist_items (wishlist_id);
+
+CREATE INDEX idx_wishlist_items_product_id ON wishlist_items (product_id);
@@ -0,0 +1,11 @@
+ALTER TABLE orders DROP COLUMN discount_price;
+ALTER TABLE orders DROP COLUMN coupon_code;
+ALTER TABLE orders DROP COLUMN coupon_id;
+
+DROP TABLE coupon_redemptions;
+
+DROP TABLE coupon_products;
+
+DROP TABLE coupon_categories;
+
+DROP TABLE coupons;
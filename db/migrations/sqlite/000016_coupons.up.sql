@@ -0,0 +1,43 @@
+CREATE TABLE coupons (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    code TEXT NOT NULL UNIQUE,
+    type TEXT NOT NULL,
+    value REAL NOT NULL,
+    min_order_value REAL NOT NULL DEFAULT 0,
+    starts_at DATETIME,
+    ends_at DATETIME,
+    usage_limit INTEGER NOT NULL DEFAULT 0,
+    per_customer_limit INTEGER NOT NULL DEFAULT 0,
+    times_used INTEGER NOT NULL DEFAULT 0,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME
+);
+
+-- The restrictions keep no foreign key on the category or product, so
+-- deleting one narrows the coupon instead of failing or widening it.
+CREATE TABLE coupon_categories (
+    coupon_id INTEGER NOT NULL REFERENCES coupons (id),
+    category_id INTEGER NOT NULL,
+    PRIMARY KEY (coupon_id, category_id)
+);
+
+CREATE TABLE coupon_products (
+    coupon_id INTEGER NOT NULL REFERENCES coupons (id),
+    product_id INTEGER NOT NULL,
+    PRIMARY KEY (coupon_id, product_id)
+);
+
+CREATE TABLE coupon_redemptions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    coupon_id INTEGER NOT NULL REFERENCES coupons (id),
+    user_id INTEGER NOT NULL,
+    order_id INTEGER NOT NULL UNIQUE REFERENCES orders (id),
+    discount REAL NOT NULL,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX idx_coupon_redemptions_coupon_user ON coupon_redemptions (coupon_id, user_id);
+
+ALTER TABLE orders ADD COLUMN coupon_id INTEGER;
+ALTER TABLE orders ADD COLUMN coupon_code TEXT NOT NULL DEFAULT '';
+ALTER TABLE orders ADD COLUMN discount_price REAL NOT NULL DEFAULT 0;
@@ -0,0 +1,28 @@
+CREATE TABLE promotions (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    type TEXT NOT NULL,
+    percent REAL NOT NULL DEFAULT 0,
+    buy_quantity INTEGER NOT NULL DEFAULT 0,
+    get_quantity INTEGER NOT NULL DEFAULT 0,
+    starts_at DATETIME,
+    ends_at DATETIME,
+    created_at DATETIME NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at DATETIME
+);
+
+-- Like the coupon restrictions, these keep no foreign key on the category
+-- or product.
+CREATE TABLE promotion_categories (
+    promotion_id INTEGER NOT NULL REFERENCES promotions (id),
+    category_id INTEGER NOT NULL,
+    PRIMARY KEY (promotion_id, category_id)
+);
+
+CREATE TABLE promotion_products (
+    promotion_id INTEGER NOT NULL REFERENCES promotions (id),
+    product_id INTEGER NOT NULL,
+    PRIMARY KEY (promotion_id, product_id)
+);
+
+ALTER TABLE orders ADD COLUMN promotion_price REAL NOT NULL DEFAULT 0;
@@ -0,0 +1,66 @@
+ALTER TABLE orders DROP COLUMN currency;
+
+ALTER TABLE coupons ADD COLUMN value REAL NOT NULL DEFAULT 0;
+UPDATE coupons SET value = CASE type WHEN 'fixed' THEN amount / 100.0 ELSE percent END;
+ALTER TABLE coupons DROP COLUMN amount;
+ALTER TABLE coupons DROP COLUMN percent;
+
+ALTER TABLE products ADD COLUMN price_new REAL NOT NULL DEFAULT 0;
+UPDATE products SET price_new = price / 100.0;
+ALTER TABLE products DROP COLUMN price;
+ALTER TABLE products RENAME COLUMN price_new TO price;
+
+ALTER TABLE product_variants ADD COLUMN price_new REAL;
+UPDATE product_variants SET price_new = price / 100.0;
+ALTER TABLE product_variants DROP COLUMN price;
+ALTER TABLE product_variants RENAME COLUMN price_new TO price;
+
+ALTER TABLE order_items ADD COLUMN price_new REAL NOT NULL DEFAULT 0;
+UPDATE order_items SET price_new = price / 100.0;
+ALTER TABLE order_items DROP COLUMN price;
+ALTER TABLE order_items RENAME COLUMN price_new TO price;
+
+ALTER TABLE order_items ADD COLUMN tax_price_new REAL NOT NULL DEFAULT 0;
+UPDATE order_items SET tax_price_new = tax_price / 100.0;
+ALTER TABLE order_items DROP COLUMN tax_price;
+ALTER TABLE order_items RENAME COLUMN tax_price_new TO tax_price;
+
+ALTER TABLE orders ADD COLUMN items_price_new REAL NOT NULL DEFAULT 0;
+UPDATE orders SET items_price_new = items_price / 100.0;
+ALTER TABLE orders DROP COLUMN items_price;
+ALTER TABLE orders RENAME COLUMN items_price_new TO items_price;
+
+ALTER TABLE orders ADD COLUMN promotion_price_new REAL NOT NULL DEFAULT 0;
+UPDATE orders SET promotion_price_new = promotion_price / 100.0;
+ALTER TABLE orders DROP COLUMN promotion_price;
+ALTER TABLE orders RENAME COLUMN promotion_price_new TO promotion_price;
+
+ALTER TABLE orders ADD COLUMN discount_price_new REAL NOT NULL DEFAULT 0;
+UPDATE orders SET discount_price_new = discount_price / 100.0;
+ALTER TABLE orders DROP COLUMN discount_price;
+ALTER TABLE orders RENAME COLUMN discount_price_new TO discount_price;
+
+ALTER TABLE orders ADD COLUMN tax_price_new REAL NOT NULL DEFAULT 0;
+UPDATE orders SET tax_price_new = tax_price / 100.0;
+ALTER TABLE orders DROP COLUMN tax_price;
+ALTER TABLE orders RENAME COLUMN tax_price_new TO tax_price;
+
+ALTER TABLE orders ADD COLUMN shipping_price_new REAL NOT NULL DEFAULT 0;
+UPDATE orders SET shipping_price_new = shipping_price / 100.0;
+ALTER TABLE orders DROP COLUMN shipping_price;
+ALTER TABLE orders RENAME COLUMN shipping_price_new TO shipping_price;
+
+ALTER TABLE orders ADD COLUMN total_price_new REAL NOT NULL DEFAULT 0;
+UPDATE orders SET total_price_new = total_price / 100.0;
+ALTER TABLE orders DROP COLUMN total_price;
+ALTER TABLE orders RENAME COLUMN total_price_new TO total_price;
+
+ALTER TABLE coupons ADD COLUMN min_order_value_new REAL NOT NULL DEFAULT 0;
+UPDATE coupons SET min_order_value_new = min_order_value / 100.0;
+ALTER TABLE coupons DROP COLUMN min_order_value;
+ALTER TABLE coupons RENAME COLUMN min_order_value_new TO min_order_value;
+
+ALTER TABLE coupon_redemptions ADD COLUMN discount_new REAL NOT NULL DEFAULT 0;
+UPDATE coupon_redemptions SET discount_new = discount / 100.0;
+ALTER TABLE coupon_redemptions DROP COLUMN discount;
+ALTER TABLE coupon_redemptions RENAME COLUMN discount_new TO discount;
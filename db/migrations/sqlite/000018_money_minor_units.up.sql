@@ -0,0 +1,75 @@
+-- Money is kept as integer minor units instead of decimals, and orders
+-- record the currency they were placed in. Existing amounts are taken to be
+-- US dollars. A store that sold in another currency has to set
+-- orders.currency afterwards, and rescale if that currency has no cents.
+-- SQLite cannot change the type of a column, so every column is copied
+-- into a new one that takes its name.
+
+ALTER TABLE products ADD COLUMN price_new INTEGER NOT NULL DEFAULT 0;
+UPDATE products SET price_new = CAST(ROUND(price * 100) AS INTEGER);
+ALTER TABLE products DROP COLUMN price;
+ALTER TABLE products RENAME COLUMN price_new TO price;
+
+ALTER TABLE product_variants ADD COLUMN price_new INTEGER;
+UPDATE product_variants SET price_new = CAST(ROUND(price * 100) AS INTEGER);
+ALTER TABLE product_variants DROP COLUMN price;
+ALTER TABLE product_variants RENAME COLUMN price_new TO price;
+
+ALTER TABLE order_items ADD COLUMN price_new INTEGER NOT NULL DEFAULT 0;
+UPDATE order_items SET price_new = CAST(ROUND(price * 100) AS INTEGER);
+ALTER TABLE order_items DROP COLUMN price;
+ALTER TABLE order_items RENAME COLUMN price_new TO price;
+
+ALTER TABLE order_items ADD COLUMN tax_price_new INTEGER NOT NULL DEFAULT 0;
+UPDATE order_items SET tax_price_new = CAST(ROUND(tax_price * 100) AS INTEGER);
+ALTER TABLE order_items DROP COLUMN tax_price;
+ALTER TABLE order_items RENAME COLUMN tax_price_new TO tax_price;
+
+ALTER TABLE orders ADD COLUMN items_price_new INTEGER NOT NULL DEFAULT 0;
+UPDATE orders SET items_price_new = CAST(ROUND(items_price * 100) AS INTEGER);
+ALTER TABLE orders DROP COLUMN items_price;
+ALTER TABLE orders RENAME COLUMN items_price_new TO items_price;
+
+ALTER TABLE orders ADD COLUMN promotion_price_new INTEGER NOT NULL DEFAULT 0;
+UPDATE orders SET promotion_price_new = CAST(ROUND(promotion_price * 100) AS INTEGER);
+ALTER TABLE orders DROP COLUMN promotion_price;
+ALTER TABLE orders RENAME COLUMN promotion_price_new TO promotion_price;
+
+ALTER TABLE orders ADD COLUMN discount_price_new INTEGER NOT NULL DEFAULT 0;
+UPDATE orders SET discount_price_new = CAST(ROUND(discount_price * 100) AS INTEGER);
+ALTER TABLE orders DROP COLUMN discount_price;
+ALTER TABLE orders RENAME COLUMN discount_price_new TO discount_price;
+
+ALTER TABLE orders ADD COLUMN tax_price_new INTEGER NOT NULL DEFAULT 0;
+UPDATE orders SET tax_price_new = CAST(ROUND(tax_price * 100) AS INTEGER);
+ALTER TABLE orders DROP COLUMN tax_price;
+ALTER TABLE orders RENAME COLUMN tax_price_new TO tax_price;
+
+ALTER TABLE orders ADD COLUMN shipping_price_new INTEGER NOT NULL DEFAULT 0;
+UPDATE orders SET shipping_price_new = CAST(ROUND(shipping_price * 100) AS INTEGER);
+ALTER TABLE orders DROP COLUMN shipping_price;
+ALTER TABLE orders RENAME COLUMN shipping_price_new TO shipping_price;
+
+ALTER TABLE orders ADD COLUMN total_price_new INTEGER NOT NULL DEFAULT 0;
+UPDATE orders SET total_price_new = CAST(ROUND(total_price * 100) AS INTEGER);
+ALTER TABLE orders DROP COLUMN total_price;
+ALTER TABLE orders RENAME COLUMN total_price_new TO total_price;
+
+ALTER TABLE coupons ADD COLUMN min_order_value_new INTEGER NOT NULL DEFAULT 0;
+UPDATE coupons SET min_order_value_new = CAST(ROUND(min_order_value * 100) AS INTEGER);
+ALTER TABLE coupons DROP COLUMN min_order_value;
+ALTER TABLE coupons RENAME COLUMN min_order_value_new TO min_order_value;
+
+ALTER TABLE coupon_redemptions ADD COLUMN discount_new INTEGER NOT NULL DEFAULT 0;
+UPDATE coupon_redemptions SET discount_new = CAST(ROUND(discount * 100) AS INTEGER);
+ALTER TABLE coupon_redemptions DROP COLUMN discount;
+ALTER TABLE coupon_redemptions RENAME COLUMN discount_new TO discount;
+
+-- Percentage coupons keep their percent; fixed ones get an amount.
+ALTER TABLE coupons ADD COLUMN percent REAL NOT NULL DEFAULT 0;
+ALTER TABLE coupons ADD COLUMN amount INTEGER NOT NULL DEFAULT 0;
+UPDATE coupons SET percent = value WHERE type = 'percentage';
+UPDATE coupons SET amount = CAST(ROUND(value * 100) AS INTEGER) WHERE type = 'fixed';
+ALTER TABLE coupons DROP COLUMN value;
+
+ALTER TABLE orders ADD COLUMN currency TEXT NOT NULL DEFAULT 'USD';
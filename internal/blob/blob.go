@@ -0,0 +1,21 @@
+// Package blob stores binary objects, such as uploaded images, under
+// slash-separated keys.
+package blob
+
+import (
+	"context"
+	"errors"
+	"io"
+)
+
+// ErrNotFound is returned by Get for a key that has no object.
+var ErrNotFound = errors.New("blob not found")
+
+// Store is a flat object store. Keys are slash-separated paths such as
+// "products/12/3f9a/original.jpg" and must not contain "." or ".."
+// elements.
+type Store interface {
+	Put(ctx context.Context, key string, r io.Reader) error
+	Get(ctx context.Context, key string) (io.ReadCloser, error)
+	Delete(ctx context.Context, key string) error
+}
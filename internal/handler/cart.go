@@ -0,0 +1,203 @@
+package handler
+
+import (
+	"database/sql"
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/server"
+	"ecom_apiv1/internal/storer"
+	"ecom_apiv1/token"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// cartTokenHeader carries the token of a guest cart. The token is returned
+// in the cart response when the server creates the guest cart.
+const cartTokenHeader = "X-Cart-Token"
+
+func (h *handler) getCart(w http.ResponseWriter, r *http.Request) {
+	c, err := h.server.GetCart(h.Ctx, cartOwner(r))
+	if err != nil {
+		writeCartError(w, err, "error getting cart")
+		return
+	}
+	writeCart(w, c)
+}
+
+// addCartItem adds a product to the cart, or raises the quantity of the line
+// that already holds it.
+func (h *handler) addCartItem(w http.ResponseWriter, r *http.Request) {
+	var req CartItemReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	item := &storer.CartItem{
+		ProductID: req.ProductID,
+		VariantID: req.VariantID,
+		Quantity:  req.Quantity,
+	}
+	c, err := h.server.AddCartItem(h.Ctx, cartOwner(r), item)
+	if err != nil {
+		writeCartError(w, err, "error adding cart item")
+		return
+	}
+	writeCart(w, c)
+}
+
+func (h *handler) updateCartItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req UpdateCartItemReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.server.UpdateCartItem(h.Ctx, cartOwner(r), id, req.Quantity)
+	if err != nil {
+		writeCartError(w, err, "error updating cart item")
+		return
+	}
+	writeCart(w, c)
+}
+
+func (h *handler) deleteCartItem(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.server.RemoveCartItem(h.Ctx, cartOwner(r), id)
+	if err != nil {
+		writeCartError(w, err, "error deleting cart item")
+		return
+	}
+	writeCart(w, c)
+}
+
+func (h *handler) clearCart(w http.ResponseWriter, r *http.Request) {
+	c, err := h.server.ClearCart(h.Ctx, cartOwner(r))
+	if err != nil {
+		writeCartError(w, err, "error clearing cart")
+		return
+	}
+	writeCart(w, c)
+}
+
+// checkout places an order for everything in the cart and empties it.
+func (h *handler) checkout(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	var req CheckoutReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	o := &storer.Order{
+		PaymentMethod:   req.PaymentMethod,
+		ShippingCountry: req.ShippingCountry,
+		ShippingRegion:  req.ShippingRegion,
+		ShippingMethod:  req.ShippingMethod,
+		CouponCode:      req.CouponCode,
+	}
+	err = parseAmounts(h.server.Currency(),
+		amountField{"items_price", req.ItemsPrice, &o.ItemsPrice},
+		amountField{"promotion_price", req.PromotionPrice, &o.PromotionPrice},
+		amountField{"discount_price", req.DiscountPrice, &o.DiscountPrice},
+		amountField{"tax_price", req.TaxPrice, &o.TaxPrice},
+		amountField{"shipping_price", req.ShippingPrice, &o.ShippingPrice},
+		amountField{"total_price", req.TotalPrice, &o.TotalPrice},
+	)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	created, err := h.server.Checkout(h.Ctx, claims.ID, o)
+	if err != nil {
+		if errors.Is(err, server.ErrInvalidCart) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeOrderError(w, err, claims.ID)
+		return
+	}
+	res := toOrderRes(created)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+}
+
+// cartOwner returns the owner of the cart a request is for: the signed-in
+// user, or else the guest whose cart token is in the header.
+func cartOwner(r *http.Request) server.CartOwner {
+	if claims, ok := r.Context().Value(authKey{}).(*token.UserClaims); ok {
+		return server.CartOwner{UserID: claims.ID}
+	}
+	return server.CartOwner{Token: r.Header.Get(cartTokenHeader)}
+}
+
+func writeCart(w http.ResponseWriter, c *server.PricedCart) {
+	res := toCartRes(c)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func writeCartError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, server.ErrInvalidCart):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, server.ErrCartNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "cart item not found", http.StatusNotFound)
+	default:
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
+
+func toCartRes(c *server.PricedCart) CartRes {
+	res := CartRes{
+		ID:             c.ID,
+		Items:          []CartItemRes{},
+		ItemCount:      c.Quantity,
+		Currency:       string(c.Currency),
+		ItemsPrice:     money.New(c.ItemsPrice, c.Currency),
+		PromotionPrice: money.New(c.PromotionPrice, c.Currency),
+		UpdatedAt:      c.UpdatedAt,
+	}
+	if c.UserID == nil && c.Token != nil {
+		res.Token = *c.Token
+	}
+	for _, l := range c.Lines {
+		res.Items = append(res.Items, CartItemRes{
+			ID:           l.ID,
+			ProductID:    l.ProductID,
+			VariantID:    l.VariantID,
+			SKU:          l.SKU,
+			Name:         l.Name,
+			Image:        l.Image,
+			Price:        money.New(l.Price, c.Currency),
+			Quantity:     l.Quantity,
+			LineTotal:    money.New(l.Total, c.Currency),
+			Discount:     money.New(l.Discount, c.Currency),
+			CountInStock: l.InStock,
+			Available:    l.Quantity <= l.InStock,
+		})
+	}
+	return res
+}
@@ -0,0 +1,243 @@
+package handler
+
+import (
+	"ecom_apiv1/internal/server"
+	"ecom_apiv1/internal/storer"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+var slugPattern = regexp.MustCompile(`^[a-z0-9]+(-[a-z0-9]+)*$`)
+
+func (h *handler) createCategory(w http.ResponseWriter, r *http.Request) {
+	var req CategoryReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	c := &storer.Category{}
+	patchCategoryReq(c, req)
+	if c.Slug == "" {
+		c.Slug = slugify(c.Name)
+	}
+	if err := validateCategory(c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.UpdatedAt = nil
+
+	created, err := h.server.CreateCategory(h.Ctx, c)
+	if err != nil {
+		if errors.Is(err, server.ErrInvalidCategory) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "error creating category", http.StatusInternalServerError)
+		return
+	}
+	h.writeCategory(w, created.ID)
+}
+
+func (h *handler) getCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	h.writeCategory(w, id)
+}
+
+// listCategories returns the whole category tree. Top-level categories and
+// the children of every category are in sort order.
+func (h *handler) listCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.server.ListCategories(h.Ctx)
+	if err != nil {
+		http.Error(w, "error listing categories", http.StatusInternalServerError)
+		return
+	}
+	res := categoryTree(categories, nil)
+	if res == nil {
+		res = []CategoryRes{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) updateCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req CategoryReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.server.GetCategory(h.Ctx, id)
+	if err != nil {
+		http.Error(w, "error getting category", http.StatusNotFound)
+		return
+	}
+	patchCategoryReq(c, req)
+	if err := validateCategory(c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	_, err = h.server.UpdateCategory(h.Ctx, c)
+	if err != nil {
+		if errors.Is(err, server.ErrInvalidCategory) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "error updating category", http.StatusInternalServerError)
+		return
+	}
+	h.writeCategory(w, c.ID)
+}
+
+func (h *handler) deleteCategory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	err = h.server.DeleteCategory(h.Ctx, id)
+	if err != nil {
+		if errors.Is(err, storer.ErrCategoryInUse) {
+			http.Error(w, storer.ErrCategoryInUse.Error(), http.StatusConflict)
+			return
+		}
+		http.Error(w, "error deleting category", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeCategory responds with the category and its subtree, which needs the
+// whole tree for the path and the children.
+func (h *handler) writeCategory(w http.ResponseWriter, id int) {
+	categories, err := h.server.ListCategories(h.Ctx)
+	if err != nil {
+		http.Error(w, "error getting category", http.StatusInternalServerError)
+		return
+	}
+	var res *CategoryRes
+	for i := range categories {
+		if categories[i].ID == id {
+			c := toCategoryRes(&categories[i], categories)
+			c.Children = categoryTree(categories, &id)
+			res = &c
+			break
+		}
+	}
+	if res == nil {
+		http.Error(w, "error getting category", http.StatusNotFound)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+// categoryTree returns the children of parentID, or the top-level categories
+// when it is nil, each with their own children. categories must already be
+// in sort order.
+func categoryTree(categories []storer.Category, parentID *int) []CategoryRes {
+	var res []CategoryRes
+	for i := range categories {
+		c := &categories[i]
+		if !sameCategoryID(c.ParentID, parentID) {
+			continue
+		}
+		node := toCategoryRes(c, categories)
+		node.Children = categoryTree(categories, &c.ID)
+		res = append(res, node)
+	}
+	return res
+}
+
+func sameCategoryID(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
+
+func patchCategoryReq(c *storer.Category, req CategoryReq) {
+	if req.Name != "" {
+		c.Name = req.Name
+	}
+	if req.Slug != "" {
+		c.Slug = req.Slug
+	}
+	if req.ParentID != nil {
+		if *req.ParentID == 0 {
+			c.ParentID = nil
+		} else {
+			c.ParentID = req.ParentID
+		}
+	}
+	if req.SortOrder != nil {
+		c.SortOrder = *req.SortOrder
+	}
+	c.UpdatedAt = toTimePtr(time.Now())
+}
+
+func validateCategory(c *storer.Category) error {
+	if c.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if !slugPattern.MatchString(c.Slug) {
+		return fmt.Errorf("slug must be lower-case letters and digits separated by single hyphens")
+	}
+	return nil
+}
+
+// slugify derives a slug from a category name: lower-case letters and digits,
+// with every other run of characters turned into a single hyphen.
+func slugify(name string) string {
+	var b strings.Builder
+	hyphen := false
+	for _, r := range strings.ToLower(name) {
+		if (r >= 'a' && r <= 'z') || (r >= '0' && r <= '9') {
+			if hyphen && b.Len() > 0 {
+				b.WriteByte('-')
+			}
+			b.WriteRune(r)
+			hyphen = false
+		} else {
+			hyphen = true
+		}
+	}
+	return b.String()
+}
+
+func toCategoryRes(c *storer.Category, categories []storer.Category) CategoryRes {
+	return CategoryRes{
+		ID:        c.ID,
+		ParentID:  c.ParentID,
+		Name:      c.Name,
+		Slug:      c.Slug,
+		Path:      storer.CategoryPath(categories, c.ID),
+		SortOrder: c.SortOrder,
+		CreatedAt: c.CreatedAt,
+		UpdatedAt: c.UpdatedAt,
+	}
+}
@@ -0,0 +1,268 @@
+package handler
+
+import (
+	"database/sql"
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/server"
+	"ecom_apiv1/internal/storer"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+var couponCodePattern = regexp.MustCompile(`^[A-Z0-9][A-Z0-9_-]{2,63}$`)
+
+func (h *handler) createCoupon(w http.ResponseWriter, r *http.Request) {
+	var req CouponReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	if req.Value == nil {
+		http.Error(w, "value is required", http.StatusBadRequest)
+		return
+	}
+
+	c := &storer.Coupon{}
+	if err := patchCouponReq(c, req, h.server.Currency()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateCoupon(c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	c.UpdatedAt = nil
+
+	created, err := h.server.CreateCoupon(h.Ctx, c)
+	if err != nil {
+		writeCouponError(w, err, "error creating coupon")
+		return
+	}
+	res := toCouponRes(created, h.server.Currency())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) getCoupon(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	c, err := h.server.GetCoupon(h.Ctx, id)
+	if err != nil {
+		writeCouponError(w, err, "error getting coupon")
+		return
+	}
+	res := toCouponRes(c, h.server.Currency())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) listCoupons(w http.ResponseWriter, r *http.Request) {
+	coupons, err := h.server.ListCoupons(h.Ctx)
+	if err != nil {
+		http.Error(w, "error listing coupons", http.StatusInternalServerError)
+		return
+	}
+	res := []CouponRes{}
+	for _, c := range coupons {
+		res = append(res, toCouponRes(&c, h.server.Currency()))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) updateCoupon(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req CouponReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	c, err := h.server.GetCoupon(h.Ctx, id)
+	if err != nil {
+		writeCouponError(w, err, "error getting coupon")
+		return
+	}
+	if err := patchCouponReq(c, req, h.server.Currency()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateCoupon(c); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.server.UpdateCoupon(h.Ctx, c)
+	if err != nil {
+		writeCouponError(w, err, "error updating coupon")
+		return
+	}
+	res := toCouponRes(updated, h.server.Currency())
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) deleteCoupon(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	err = h.server.DeleteCoupon(h.Ctx, id)
+	if err != nil {
+		writeCouponError(w, err, "error deleting coupon")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writeCouponError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, server.ErrInvalidCoupon):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, storer.ErrDuplicateCouponCode):
+		http.Error(w, storer.ErrDuplicateCouponCode.Error(), http.StatusConflict)
+	case errors.Is(err, storer.ErrCouponRedeemed):
+		http.Error(w, storer.ErrCouponRedeemed.Error()+"; set ends_at to retire it", http.StatusConflict)
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "coupon not found", http.StatusNotFound)
+	default:
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
+
+// patchCouponReq applies req to c. Value is a percentage or an amount in
+// currency cur, depending on the coupon's type after the patch, and is
+// required whenever the type changes.
+func patchCouponReq(c *storer.Coupon, req CouponReq, cur money.Currency) error {
+	if req.Code != "" {
+		c.Code = server.NormalizeCouponCode(req.Code)
+	}
+	if req.Type != "" && storer.CouponType(req.Type) != c.Type {
+		if req.Value == nil {
+			return fmt.Errorf("value is required when setting type")
+		}
+		c.Type = storer.CouponType(req.Type)
+	}
+	if req.Value != nil {
+		c.Percent, c.Amount = 0, 0
+		var err error
+		switch c.Type {
+		case storer.CouponFixed:
+			c.Amount, err = req.Value.Amount(cur)
+		default:
+			c.Percent, err = req.Value.Float()
+		}
+		if err != nil {
+			return fmt.Errorf("value: %w", err)
+		}
+	}
+	if req.MinOrderValue != nil {
+		if err := parseAmounts(cur, amountField{"min_order_value", *req.MinOrderValue, &c.MinOrderValue}); err != nil {
+			return err
+		}
+	}
+	if req.StartsAt != nil {
+		c.StartsAt = req.StartsAt
+	}
+	if req.EndsAt != nil {
+		c.EndsAt = req.EndsAt
+	}
+	if req.UsageLimit != nil {
+		c.UsageLimit = *req.UsageLimit
+	}
+	if req.PerCustomerLimit != nil {
+		c.PerCustomerLimit = *req.PerCustomerLimit
+	}
+	if req.CategoryIDs != nil {
+		c.CategoryIDs = *req.CategoryIDs
+	}
+	if req.ProductIDs != nil {
+		c.ProductIDs = *req.ProductIDs
+	}
+	c.UpdatedAt = toTimePtr(time.Now())
+	return nil
+}
+
+func validateCoupon(c *storer.Coupon) error {
+	if !couponCodePattern.MatchString(c.Code) {
+		return fmt.Errorf("code must be 3 to 64 letters, digits, dashes or underscores")
+	}
+	if !c.Type.Valid() {
+		return fmt.Errorf("type must be %q or %q", storer.CouponPercentage, storer.CouponFixed)
+	}
+	switch c.Type {
+	case storer.CouponFixed:
+		if c.Amount <= 0 || c.Percent != 0 {
+			return fmt.Errorf("value of a fixed coupon must be a positive amount")
+		}
+	case storer.CouponPercentage:
+		if c.Percent <= 0 || c.Percent > 100 || c.Amount != 0 {
+			return fmt.Errorf("value of a percentage coupon must be above 0 and at most 100")
+		}
+	}
+	if c.MinOrderValue < 0 {
+		return fmt.Errorf("min_order_value must not be negative")
+	}
+	if c.StartsAt != nil && c.EndsAt != nil && !c.EndsAt.After(*c.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	if c.UsageLimit < 0 || c.PerCustomerLimit < 0 {
+		return fmt.Errorf("usage limits must not be negative")
+	}
+	return nil
+}
+
+func toCouponRes(c *storer.Coupon, cur money.Currency) CouponRes {
+	res := CouponRes{
+		ID:               c.ID,
+		Code:             c.Code,
+		Type:             string(c.Type),
+		Value:            strconv.FormatFloat(c.Percent, 'f', -1, 64),
+		Currency:         string(cur),
+		MinOrderValue:    money.New(c.MinOrderValue, cur),
+		StartsAt:         c.StartsAt,
+		EndsAt:           c.EndsAt,
+		UsageLimit:       c.UsageLimit,
+		PerCustomerLimit: c.PerCustomerLimit,
+		TimesUsed:        c.TimesUsed,
+		CategoryIDs:      c.CategoryIDs,
+		ProductIDs:       c.ProductIDs,
+		CreatedAt:        c.CreatedAt,
+		UpdatedAt:        c.UpdatedAt,
+	}
+	if c.Type == storer.CouponFixed {
+		res.Value = cur.Format(c.Amount)
+	}
+	if res.CategoryIDs == nil {
+		res.CategoryIDs = []int{}
+	}
+	if res.ProductIDs == nil {
+		res.ProductIDs = []int{}
+	}
+	return res
+}
@@ -0,0 +1,72 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCreateCouponValidatesValue(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		want int
+	}{
+		{"percentage", `{"code":"TEN","type":"percentage","value":"10"}`, http.StatusCreated},
+		{"fixed", `{"code":"FIVE","type":"fixed","value":"5.00"}`, http.StatusCreated},
+		{"missing value", `{"code":"NONE","type":"fixed"}`, http.StatusBadRequest},
+		{"zero amount", `{"code":"ZERO","type":"fixed","value":"0"}`, http.StatusBadRequest},
+		{"negative amount", `{"code":"NEG","type":"fixed","value":"-5"}`, http.StatusBadRequest},
+		{"percent above 100", `{"code":"MORE","type":"percentage","value":"150"}`, http.StatusBadRequest},
+	}
+	a := newTestAPI(t)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if w := a.do("POST", "/coupons", a.adminToken, tt.body); w.Code != tt.want {
+				t.Errorf("status = %d (%s), want %d", w.Code, w.Body, tt.want)
+			}
+		})
+	}
+}
+
+func TestUpdateCouponType(t *testing.T) {
+	tests := []struct {
+		name      string
+		create    string
+		patch     string
+		want      int
+		wantValue string
+	}{
+		{"to fixed without value", `{"type":"percentage","value":"10"}`, `{"type":"fixed"}`, http.StatusBadRequest, ""},
+		{"to percentage without value", `{"type":"fixed","value":"5"}`, `{"type":"percentage"}`, http.StatusBadRequest, ""},
+		{"to fixed with value", `{"type":"percentage","value":"10"}`, `{"type":"fixed","value":"2.50"}`, http.StatusOK, "2.50"},
+		{"to percentage with value", `{"type":"fixed","value":"5"}`, `{"type":"percentage","value":"15"}`, http.StatusOK, "15"},
+		{"same type without value", `{"type":"fixed","value":"5"}`, `{"type":"fixed","usage_limit":3}`, http.StatusOK, "5.00"},
+	}
+	a := newTestAPI(t)
+	for i, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			create := fmt.Sprintf(`{"code":"CODE%d",%s`, i, tt.create[1:])
+			w := a.do("POST", "/coupons", a.adminToken, create)
+			if w.Code != http.StatusCreated {
+				t.Fatalf("create coupon: %d %s", w.Code, w.Body)
+			}
+			id := decode[struct {
+				ID int `json:"id"`
+			}](t, w).ID
+
+			w = a.do("PATCH", fmt.Sprintf("/coupons/%d", id), a.adminToken, tt.patch)
+			if w.Code != tt.want {
+				t.Fatalf("status = %d (%s), want %d", w.Code, w.Body, tt.want)
+			}
+			if tt.want != http.StatusOK {
+				return
+			}
+			if got := decode[struct {
+				Value string `json:"value"`
+			}](t, w).Value; got != tt.wantValue {
+				t.Errorf("value = %q, want %q", got, tt.wantValue)
+			}
+		})
+	}
+}
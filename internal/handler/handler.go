@@ -2,11 +2,16 @@ package handler
 
 import (
 	"context"
+	"database/sql"
+	"ecom_apiv1/internal/money"
 	"ecom_apiv1/internal/server"
 	"ecom_apiv1/internal/storer"
 	"ecom_apiv1/token"
 	"ecom_apiv1/util"
 	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"strconv"
@@ -36,12 +41,29 @@ func (h *handler) createProduct(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Error decoding request body", http.StatusInternalServerError)
 		return
 	}
-	p, err := h.server.CreateProduct(h.Ctx, toStorerProduct(productReq))
+	sp, err := toStorerProduct(productReq, h.server.Currency())
+	if err == nil {
+		err = validateProduct(sp)
+	}
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p, err := h.server.CreateProduct(h.Ctx, sp)
 	if err != nil {
+		if errors.Is(err, server.ErrInvalidProduct) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "error creating product", http.StatusInternalServerError)
 		return
 	}
-	res := toProductRes(p)
+	price, err := h.server.PriceProduct(h.Ctx, p)
+	if err != nil {
+		http.Error(w, "error pricing product", http.StatusInternalServerError)
+		return
+	}
+	res := toProductRes(p, price)
 
 	w.Header().Set("Content-Type", "application-json")
 	w.WriteHeader(http.StatusOK)
@@ -61,7 +83,12 @@ func (h *handler) getProduct(w http.ResponseWriter, r *http.Request) {
 		http.Error(w, "Error get product", http.StatusInternalServerError)
 		return
 	}
-	res := toProductRes(p)
+	price, err := h.server.PriceProduct(h.Ctx, p)
+	if err != nil {
+		http.Error(w, "error pricing product", http.StatusInternalServerError)
+		return
+	}
+	res := toProductRes(p, price)
 
 	w.Header().Set("Content-Type", "application-json")
 	w.WriteHeader(http.StatusOK)
@@ -69,14 +96,42 @@ func (h *handler) getProduct(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *handler) Listproducts(w http.ResponseWriter, r *http.Request) {
-	products, err := h.server.ListProducts(h.Ctx)
+	page, err := parsePage(r)
 	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	filter, err := parseProductFilter(r, h.server.Currency())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	products, next, err := h.server.ListProducts(h.Ctx, filter, page)
+	if err != nil {
+		if errors.Is(err, storer.ErrInvalidCursor) {
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "category not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "error get list product", http.StatusInternalServerError)
 		return
 	}
-	var res []ProductRes
-	for _, product := range products {
-		res = append(res, toProductRes(&product))
+	prices, err := h.server.PriceProducts(h.Ctx, products)
+	if err != nil {
+		http.Error(w, "error pricing products", http.StatusInternalServerError)
+		return
+	}
+	res := ListProductRes{
+		Products:   []ProductRes{},
+		Filters:    toProductFilterRes(filter, h.server.Currency()),
+		NextCursor: encodeCursor(next),
+		HasMore:    next != nil,
+	}
+	for i, product := range products {
+		res.Products = append(res.Products, toProductRes(&product, prices[i]))
 	}
 
 	w.Header().Set("Content-Type", "application-json")
@@ -105,14 +160,30 @@ func (h *handler) updateProducts(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	patchProductReq(p, productReq)
+	if err := patchProductReq(p, productReq, h.server.Currency()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateProduct(p); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 
 	updatedProduct, err := h.server.UpdateProduct(h.Ctx, p)
 	if err != nil {
+		if errors.Is(err, server.ErrInvalidProduct) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
 		http.Error(w, "error update product", http.StatusInternalServerError)
 		return
 	}
-	res := toProductRes(updatedProduct)
+	price, err := h.server.PriceProduct(h.Ctx, updatedProduct)
+	if err != nil {
+		http.Error(w, "error pricing product", http.StatusInternalServerError)
+		return
+	}
+	res := toProductRes(updatedProduct, price)
 
 	w.Header().Set("Content-Type", "application-json")
 	w.WriteHeader(http.StatusOK)
@@ -129,6 +200,10 @@ func (h *handler) DeleteProduct(w http.ResponseWriter, r *http.Request) {
 	}
 	err = h.server.DeleteProduct(h.Ctx, id)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "product not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "error deleting product", http.StatusInternalServerError)
 		return
 	}
@@ -144,59 +219,263 @@ func (h *handler) createOrder(w http.ResponseWriter, r *http.Request) {
 		return
 	}
 
-	so := toStorerOrder(orderReq)
+	so, err := toStorerOrder(orderReq, h.server.Currency())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
 	so.UserId = claims.ID
 
 	created, err := h.server.CreateOrder(h.Ctx, so)
 	if err != nil {
-		http.Error(w, "error creating order", http.StatusInternalServerError)
+		writeOrderError(w, err, claims.ID)
 		return
 	}
 	res := toOrderRes(created)
 
 	w.Header().Set("Content-Type", "application-json")
-	w.WriteHeader(http.StatusOK)
+	w.WriteHeader(http.StatusCreated)
 	json.NewEncoder(w).Encode(res)
 }
 
+// writeOrderError reports why an order for userID could not be placed.
+func writeOrderError(w http.ResponseWriter, err error, userID int) {
+	var stockErr *storer.InsufficientStockError
+	if errors.As(err, &stockErr) {
+		http.Error(w, stockErr.Error(), http.StatusConflict)
+		return
+	}
+	var priceErr *server.PriceMismatchError
+	if errors.As(err, &priceErr) {
+		log.Printf("Rejected order from user %d: %v", userID, priceErr)
+		http.Error(w, priceErr.Error(), http.StatusUnprocessableEntity)
+		return
+	}
+	if errors.Is(err, storer.ErrCouponLimitReached) {
+		http.Error(w, storer.ErrCouponLimitReached.Error(), http.StatusConflict)
+		return
+	}
+	if errors.Is(err, server.ErrInvalidOrder) || errors.Is(err, server.ErrInvalidCoupon) {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	http.Error(w, "error creating order", http.StatusInternalServerError)
+}
+
 func (h *handler) getOrder(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
 
-	o, err := h.server.GetOrder(h.Ctx, claims.ID)
+	o, err := h.server.GetOrder(h.Ctx, id)
 	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "order not found", http.StatusNotFound)
+			return
+		}
 		http.Error(w, "error getting order", http.StatusInternalServerError)
 		return
 	}
+	// other customers' orders are reported as missing rather than forbidden,
+	// so order IDs cannot be probed
+	if o.UserId != claims.ID && !claims.IsAdmin {
+		http.Error(w, "order not found", http.StatusNotFound)
+		return
+	}
 	res := toOrderRes(o)
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(res)
 }
 
+// getMyOrder serves the old GET /myorder, which returned a single order of
+// the customer. It now returns their most recent one; new clients should use
+// GET /myorders.
+func (h *handler) getMyOrder(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	orders, _, err := h.server.ListUserOrders(h.Ctx, claims.ID, storer.Page{Limit: 1})
+	if err != nil {
+		http.Error(w, "error getting order", http.StatusInternalServerError)
+		return
+	}
+	if len(orders) == 0 {
+		http.Error(w, "order not found", http.StatusNotFound)
+		return
+	}
+	res := toOrderRes(&orders[0])
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) listMyOrders(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	page, err := parsePage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	orders, next, err := h.server.ListUserOrders(h.Ctx, claims.ID, page)
+	if err != nil {
+		http.Error(w, "error listing orders", http.StatusInternalServerError)
+		return
+	}
+
+	res := ListOrderRes{
+		Orders:     []OrderRes{},
+		NextCursor: encodeCursor(next),
+		HasMore:    next != nil,
+	}
+	for _, o := range orders {
+		res.Orders = append(res.Orders, toOrderRes(&o))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
 func (h *handler) listOrders(w http.ResponseWriter, r *http.Request) {
-	orders, err := h.server.ListOrders(h.Ctx)
+	page, err := parsePage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	orders, next, err := h.server.ListOrders(h.Ctx, page)
 	if err != nil {
 		http.Error(w, "internal server error", http.StatusInternalServerError)
 		return
 	}
 
-	var res []OrderRes
+	res := ListOrderRes{
+		Orders:     []OrderRes{},
+		NextCursor: encodeCursor(next),
+		HasMore:    next != nil,
+	}
 	for _, o := range orders {
-		res = append(res, toOrderRes(&o))
+		res.Orders = append(res.Orders, toOrderRes(&o))
 	}
 
 	w.Header().Set("Content-Type", "application/json")
 	json.NewEncoder(w).Encode(res)
 }
 
-func (h *handler) deleteOrder(w http.ResponseWriter, r *http.Request) {
+func (h *handler) cancelOrder(w http.ResponseWriter, r *http.Request) {
 	claims := r.Context().Value(authKey{}).(*token.UserClaims)
-	err := h.server.DeleteOrder(h.Ctx, claims.ID)
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
 	if err != nil {
-		http.Error(w, "error deleting order", http.StatusInternalServerError)
+		http.Error(w, "error parsing id", http.StatusBadRequest)
 		return
 	}
-	w.WriteHeader(http.StatusNoContent)
+	// the body with a reason is optional
+	var req CancelOrderReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil && !errors.Is(err, io.EOF) {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	o, err := h.server.GetOrder(h.Ctx, id)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "order not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "error getting order", http.StatusInternalServerError)
+		return
+	}
+	if o.UserId != claims.ID && !claims.IsAdmin {
+		http.Error(w, "order not found", http.StatusNotFound)
+		return
+	}
+
+	cancelled, err := h.server.CancelOrder(h.Ctx, id, claims.ID, claims.IsAdmin, req.Reason)
+	if err != nil {
+		var transitionErr *storer.InvalidStatusTransitionError
+		if errors.As(err, &transitionErr) {
+			http.Error(w, fmt.Sprintf("order cannot be cancelled in status %s", transitionErr.From), http.StatusConflict)
+			return
+		}
+		http.Error(w, "error cancelling order", http.StatusInternalServerError)
+		return
+	}
+	res := toOrderRes(cancelled)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) updateOrderStatus(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req OrderStatusReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	status := storer.OrderStatus(req.Status)
+	if !status.Valid() {
+		http.Error(w, fmt.Sprintf("unknown order status %q", req.Status), http.StatusBadRequest)
+		return
+	}
+
+	o, err := h.server.UpdateOrderStatus(h.Ctx, id, status, claims.ID, req.Reason)
+	if err != nil {
+		var transitionErr *storer.InvalidStatusTransitionError
+		if errors.As(err, &transitionErr) {
+			http.Error(w, transitionErr.Error(), http.StatusConflict)
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "order not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "error updating order status", http.StatusInternalServerError)
+		return
+	}
+	res := toOrderRes(o)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) listOrderStatusHistory(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	history, err := h.server.ListOrderStatusHistory(h.Ctx, id)
+	if err != nil {
+		http.Error(w, "error listing order status history", http.StatusInternalServerError)
+		return
+	}
+
+	res := []OrderStatusChangeRes{}
+	for _, c := range history {
+		res = append(res, OrderStatusChangeRes{
+			FromStatus: string(c.FromStatus),
+			ToStatus:   string(c.ToStatus),
+			ChangedBy:  c.ChangedBy,
+			Reason:     c.Reason,
+			CreatedAt:  c.CreatedAt,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
 }
 
 func (h *handler) createUser(w http.ResponseWriter, r *http.Request) {
@@ -227,7 +506,12 @@ func (h *handler) createUser(w http.ResponseWriter, r *http.Request) {
 }
 
 func (h *handler) listUsers(w http.ResponseWriter, r *http.Request) {
-	users, err := h.server.ListUsers(h.Ctx)
+	page, err := parsePage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	users, next, err := h.server.ListUsers(h.Ctx, page)
 	if err != nil {
 		http.Error(w, "error getting list users", http.StatusInternalServerError)
 		return
@@ -238,7 +522,11 @@ func (h *handler) listUsers(w http.ResponseWriter, r *http.Request) {
 	// 	res = append(res, toUserRes(&user))
 	// }
 
-	var res ListUserRes
+	res := ListUserRes{
+		Users:      []UserRes{},
+		NextCursor: encodeCursor(next),
+		HasMore:    next != nil,
+	}
 	for _, u := range users {
 		res.Users = append(res.Users, toUserRes(&u))
 	}
@@ -382,6 +670,22 @@ func (h *handler) loginUser(w http.ResponseWriter, r *http.Request) {
 		User:                  toUserRes(u),
 	}
 
+	cartToken := loginReq.CartToken
+	if cartToken == "" {
+		cartToken = r.Header.Get(cartTokenHeader)
+	}
+	if cartToken != "" {
+		// The user is signed in either way; a guest cart that cannot be
+		// merged, for example because it expired, must not fail the login.
+		c, err := h.server.MergeGuestCart(h.Ctx, u.ID, cartToken)
+		if err != nil {
+			log.Printf("error merging guest cart into cart of user %d: %v", u.ID, err)
+		} else {
+			cartRes := toCartRes(c)
+			res.Cart = &cartRes
+		}
+	}
+
 	w.Header().Set("Content-Type", "application/json")
 	w.WriteHeader(http.StatusOK)
 	json.NewEncoder(w).Encode(res)
@@ -451,114 +755,197 @@ func (h *handler) revokeSession(w http.ResponseWriter, r *http.Request) {
 	w.WriteHeader(http.StatusNoContent)
 }
 
-func toStorerOrder(o OrderReq) *storer.Order {
-	return &storer.Order{
-		TaxPrice:      o.TaxPrice,
-		TotalPrice:    o.TotalPrice,
-		PaymentMethod: o.PaymentMethod,
-		ShippingPrice: o.ShippingPrice,
-		Items:         toStorerOrderItem(o.Items),
+func toStorerOrder(o OrderReq, c money.Currency) (*storer.Order, error) {
+	so := &storer.Order{
+		PaymentMethod:   o.PaymentMethod,
+		ShippingCountry: o.ShippingCountry,
+		ShippingRegion:  o.ShippingRegion,
+		ShippingMethod:  o.ShippingMethod,
+		CouponCode:      o.CouponCode,
+	}
+	err := parseAmounts(c,
+		amountField{"items_price", o.ItemsPrice, &so.ItemsPrice},
+		amountField{"promotion_price", o.PromotionPrice, &so.PromotionPrice},
+		amountField{"discount_price", o.DiscountPrice, &so.DiscountPrice},
+		amountField{"tax_price", o.TaxPrice, &so.TaxPrice},
+		amountField{"shipping_price", o.ShippingPrice, &so.ShippingPrice},
+		amountField{"total_price", o.TotalPrice, &so.TotalPrice},
+	)
+	if err != nil {
+		return nil, err
 	}
+	so.Items, err = toStorerOrderItem(o.Items, c)
+	if err != nil {
+		return nil, err
+	}
+	return so, nil
 }
 
-func toStorerOrderItem(items []OrderItem) []storer.OrderItem {
+func toStorerOrderItem(items []OrderItemReq, c money.Currency) ([]storer.OrderItem, error) {
 	var res []storer.OrderItem
 	for _, item := range items {
-		res = append(res, storer.OrderItem{
+		oi := storer.OrderItem{
 			Image:     item.Image,
 			Name:      item.Name,
-			Price:     item.Price,
 			Quantity:  item.Quantity,
 			ProductID: item.ProductID,
-		})
+			VariantID: item.VariantID,
+		}
+		if err := parseAmounts(c, amountField{"price", item.Price, &oi.Price}); err != nil {
+			return nil, err
+		}
+		res = append(res, oi)
 	}
-	return res
+	return res, nil
 }
 
 func toOrderRes(o *storer.Order) OrderRes {
 	return OrderRes{
-		ID:            o.ID,
-		ShippingPrice: o.ShippingPrice,
-		PaymentMethod: o.PaymentMethod,
-		TotalPrice:    o.TotalPrice,
-		TaxPrice:      o.TaxPrice,
-		CreatedAt:     o.CreatedAt,
-		UpdatedAt:     o.UpdatedAt,
-		Items:         toOrderItem(o.Items),
+		ID:              o.ID,
+		Status:          string(o.Status),
+		Currency:        string(o.Currency),
+		ShippingPrice:   money.New(o.ShippingPrice, o.Currency),
+		PaymentMethod:   o.PaymentMethod,
+		ShippingCountry: o.ShippingCountry,
+		ShippingRegion:  o.ShippingRegion,
+		ShippingMethod:  o.ShippingMethod,
+		CouponCode:      o.CouponCode,
+		ItemsPrice:      money.New(o.ItemsPrice, o.Currency),
+		PromotionPrice:  money.New(o.PromotionPrice, o.Currency),
+		DiscountPrice:   money.New(o.DiscountPrice, o.Currency),
+		TotalPrice:      money.New(o.TotalPrice, o.Currency),
+		TaxPrice:        money.New(o.TaxPrice, o.Currency),
+		CreatedAt:       o.CreatedAt,
+		UpdatedAt:       o.UpdatedAt,
+		Items:           toOrderItem(o.Items, o.Currency),
 	}
 }
 
-func toOrderItem(items []storer.OrderItem) []OrderItem {
+func toOrderItem(items []storer.OrderItem, c money.Currency) []OrderItem {
 	var res []OrderItem
 	for _, item := range items {
 		res = append(res, OrderItem{
-			Name:      item.Name,
-			Quantity:  item.Quantity,
-			Image:     item.Image,
-			Price:     item.Price,
-			ProductID: item.ProductID,
+			ID:           item.ID,
+			OrderID:      item.OrderID,
+			Name:         item.Name,
+			SKU:          item.SKU,
+			Quantity:     item.Quantity,
+			Image:        item.Image,
+			Price:        money.New(item.Price, c),
+			TaxRate:      item.TaxRate,
+			TaxPrice:     money.New(item.TaxPrice, c),
+			TaxInclusive: item.TaxInclusive,
+			ProductID:    item.ProductID,
+			VariantID:    item.VariantID,
 		})
 	}
 	return res
 }
 
-func patchProductReq(product *storer.Product, p ProductReq) {
+func patchProductReq(product *storer.Product, p ProductReq, c money.Currency) error {
 	if p.Name != "" {
 		product.Name = p.Name
 	}
 	if p.Image != "" {
 		product.Image = p.Image
 	}
-	if p.Category != "" {
-		product.Category = p.Category
+	if p.CategoryID != nil {
+		// A category_id of 0 removes the product from its category.
+		if *p.CategoryID == 0 {
+			product.CategoryID = nil
+		} else {
+			product.CategoryID = p.CategoryID
+		}
 	}
 	if p.Description != "" {
 		product.Description = p.Description
 	}
-	if p.Rating != 0 {
-		product.Rating = p.Rating
-	}
-	if p.NumReviews != 0 {
-		product.NumReviews = p.NumReviews
+	if p.Price != "" {
+		if err := parseAmounts(c, amountField{"price", p.Price, &product.Price}); err != nil {
+			return err
+		}
 	}
-	if p.Price != 0 {
-		product.Price = p.Price
+	if p.Weight != 0 {
+		product.Weight = p.Weight
 	}
 	if p.CountInStock != 0 {
 		product.CountInStock = p.CountInStock
 	}
 	product.UpdatedAt = toTimePtr(time.Now())
+	return nil
+}
+
+func validateProduct(p *storer.Product) error {
+	if p.Price < 0 {
+		return fmt.Errorf("price must not be negative")
+	}
+	if p.Weight < 0 {
+		return fmt.Errorf("weight must not be negative")
+	}
+	return nil
 }
 
 func toTimePtr(t time.Time) *time.Time {
 	return &t
 }
 
-func toStorerProduct(p ProductReq) *storer.Product {
-	return &storer.Product{
+func toStorerProduct(p ProductReq, c money.Currency) (*storer.Product, error) {
+	sp := &storer.Product{
 		Name:         p.Name,
 		CountInStock: p.CountInStock,
 		Image:        p.Image,
-		Category:     p.Category,
+		CategoryID:   p.CategoryID,
 		Description:  p.Description,
-		Rating:       p.Rating,
-		NumReviews:   p.NumReviews,
-		Price:        p.Price,
+		Weight:       p.Weight,
+	}
+	if err := parseAmounts(c, amountField{"price", p.Price, &sp.Price}); err != nil {
+		return nil, err
 	}
+	return sp, nil
 }
 
-func toProductRes(p *storer.Product) ProductRes {
-	return ProductRes{
+func toProductRes(p *storer.Product, price server.ProductPrice) ProductRes {
+	res := ProductRes{
 		ID:           p.ID,
 		Name:         p.Name,
 		Image:        p.Image,
-		Category:     p.Category,
+		CategoryID:   p.CategoryID,
 		Description:  p.Description,
 		Rating:       p.Rating,
 		NumReviews:   p.NumReviews,
-		Price:        p.Price,
+		Currency:     string(price.Currency),
+		Price:        money.New(p.Price, price.Currency),
+		SalePrice:    money.New(price.SalePrice, price.Currency),
+		Weight:       p.Weight,
 		CountInStock: p.CountInStock,
 		CreatedAt:    p.CreatedAt,
 		UpdatedAt:    p.UpdatedAt,
+		Options:      []ProductOptionRes{},
+		Variants:     []ProductVariantRes{},
+		Images:       []ProductImageRes{},
+		Promotions:   []ProductPromotionRes{},
+	}
+	for _, o := range p.Options {
+		res.Options = append(res.Options, ProductOptionRes{Name: o.Name, Choices: o.Choices})
+	}
+	for i := range p.Variants {
+		v := &p.Variants[i]
+		res.Variants = append(res.Variants, toProductVariantRes(p, v, price.VariantSalePrices[v.ID], price.Currency))
+	}
+	for i := range p.Images {
+		res.Images = append(res.Images, toProductImageRes(&p.Images[i]))
+	}
+	for _, promo := range price.Promotions {
+		res.Promotions = append(res.Promotions, ProductPromotionRes{
+			ID:          promo.ID,
+			Name:        promo.Name,
+			Type:        string(promo.Type),
+			Percent:     promo.Percent,
+			BuyQuantity: promo.BuyQuantity,
+			GetQuantity: promo.GetQuantity,
+			EndsAt:      promo.EndsAt,
+		})
 	}
+	res.Image = server.ProductImageURL(p)
+	return res
 }
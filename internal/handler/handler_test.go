@@ -0,0 +1,89 @@
+package handler
+
+import (
+	"bytes"
+	"context"
+	"ecom_apiv1/internal/blob"
+	"ecom_apiv1/internal/moderation"
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/server"
+	"ecom_apiv1/internal/shipping"
+	"ecom_apiv1/internal/storer"
+	"ecom_apiv1/internal/tax"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// testAPI serves the routes over a memory store, with a signed-in customer
+// and admin.
+type testAPI struct {
+	t          *testing.T
+	router     http.Handler
+	st         *storer.MemoryStorage
+	userID     int
+	userToken  string
+	adminToken string
+}
+
+func newTestAPI(t *testing.T) *testAPI {
+	t.Helper()
+	st := storer.NewMemoryStorage()
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	srv := server.NewServer(st, tax.NewRuleCalculator(st), shipping.NewTableCalculator(shipping.DefaultZones), blobs, moderation.NewWordFilter(nil), money.DefaultCurrency, time.Hour)
+	h := NewHandler(srv, "test-secret-key-that-is-long-enough")
+	a := &testAPI{t: t, router: RegisterRoutes(h), st: st}
+
+	ctx := context.Background()
+	u, err := st.CreateUser(ctx, &storer.User{Name: "customer", Email: "customer@example.com", Password: "x"})
+	if err != nil {
+		t.Fatal(err)
+	}
+	admin, err := st.CreateUser(ctx, &storer.User{Name: "admin", Email: "admin@example.com", Password: "x", IsAdmin: true})
+	if err != nil {
+		t.Fatal(err)
+	}
+	a.userID = u.ID
+	if a.userToken, _, err = h.TokenMaker.CreateToken(u.ID, u.Email, false, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	if a.adminToken, _, err = h.TokenMaker.CreateToken(admin.ID, admin.Email, true, time.Hour); err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// do sends body to path as the holder of token, which may be empty.
+func (a *testAPI) do(method, path, token, body string) *httptest.ResponseRecorder {
+	req := httptest.NewRequest(method, path, bytes.NewBufferString(body))
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	w := httptest.NewRecorder()
+	a.router.ServeHTTP(w, req)
+	return w
+}
+
+// product stores a product in stock for the test.
+func (a *testAPI) product(name string, price money.Amount) *storer.Product {
+	a.t.Helper()
+	p, err := a.st.CreateProduct(context.Background(), &storer.Product{Name: name, Price: price, CountInStock: 100})
+	if err != nil {
+		a.t.Fatal(err)
+	}
+	return p
+}
+
+func decode[T any](t *testing.T, w *httptest.ResponseRecorder) T {
+	t.Helper()
+	var v T
+	if err := json.Unmarshal(w.Body.Bytes(), &v); err != nil {
+		t.Fatalf("decoding %q: %v", w.Body.String(), err)
+	}
+	return v
+}
@@ -0,0 +1,178 @@
+package handler
+
+import (
+	"database/sql"
+	"ecom_apiv1/internal/blob"
+	"ecom_apiv1/internal/server"
+	"ecom_apiv1/internal/storer"
+	"encoding/json"
+	"errors"
+	"io"
+	"log"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+// maxImageSize is the largest image upload accepted, in bytes.
+const maxImageSize = 10 << 20
+
+// uploadProductImage accepts a multipart form with the image in the "image"
+// field and appends it to the product's images.
+func (h *handler) uploadProductImage(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+
+	// Leave some room for the multipart headers around the file.
+	r.Body = http.MaxBytesReader(w, r.Body, maxImageSize+1<<20)
+	file, _, err := r.FormFile("image")
+	if err != nil {
+		var maxErr *http.MaxBytesError
+		if errors.As(err, &maxErr) {
+			http.Error(w, "image is too large", http.StatusRequestEntityTooLarge)
+			return
+		}
+		http.Error(w, "expected a multipart form with an image field", http.StatusBadRequest)
+		return
+	}
+	defer file.Close()
+	data, err := io.ReadAll(io.LimitReader(file, maxImageSize+1))
+	if err != nil {
+		http.Error(w, "error reading image", http.StatusBadRequest)
+		return
+	}
+	if len(data) > maxImageSize {
+		http.Error(w, "image is too large", http.StatusRequestEntityTooLarge)
+		return
+	}
+
+	img, err := h.server.AddProductImage(h.Ctx, id, data)
+	if err != nil {
+		switch {
+		case errors.Is(err, server.ErrInvalidImage):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "product not found", http.StatusNotFound)
+		default:
+			log.Printf("error adding product image: %v", err)
+			http.Error(w, "error adding product image", http.StatusInternalServerError)
+		}
+		return
+	}
+	res := toProductImageRes(img)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+}
+
+// reorderProductImages sets the order of a product's images. The body must
+// list the IDs of all of its images.
+func (h *handler) reorderProductImages(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req ReorderImagesReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	p, err := h.server.ReorderProductImages(h.Ctx, id, req.ImageIDs)
+	if err != nil {
+		switch {
+		case errors.Is(err, server.ErrInvalidImage):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "product not found", http.StatusNotFound)
+		default:
+			http.Error(w, "error reordering product images", http.StatusInternalServerError)
+		}
+		return
+	}
+	price, err := h.server.PriceProduct(h.Ctx, p)
+	if err != nil {
+		http.Error(w, "error pricing product", http.StatusInternalServerError)
+		return
+	}
+	res := toProductRes(p, price)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) deleteProductImage(w http.ResponseWriter, r *http.Request) {
+	vars := mux.Vars(r)
+	productID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	imageID, err := strconv.Atoi(vars["imageID"])
+	if err != nil {
+		http.Error(w, "error parsing image id", http.StatusBadRequest)
+		return
+	}
+	img, err := h.server.GetProductImage(h.Ctx, imageID)
+	if err != nil || img.ProductID != productID {
+		http.Error(w, "image not found", http.StatusNotFound)
+		return
+	}
+	err = h.server.DeleteProductImage(h.Ctx, img)
+	if err != nil {
+		http.Error(w, "error deleting product image", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// serveImage serves an image blob. Every upload gets new keys, so the
+// content under a URL never changes and can be cached for good.
+func (h *handler) serveImage(w http.ResponseWriter, r *http.Request) {
+	key := mux.Vars(r)["key"]
+	rc, err := h.server.OpenImage(h.Ctx, key)
+	if err != nil {
+		if errors.Is(err, blob.ErrNotFound) {
+			http.Error(w, "image not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "error reading image", http.StatusInternalServerError)
+		return
+	}
+	defer rc.Close()
+
+	contentType := mime.TypeByExtension(path.Ext(key))
+	if contentType == "" {
+		contentType = "application/octet-stream"
+	}
+	w.Header().Set("Content-Type", contentType)
+	w.Header().Set("Cache-Control", "public, max-age=31536000, immutable")
+	w.Header().Set("X-Content-Type-Options", "nosniff")
+	w.WriteHeader(http.StatusOK)
+	io.Copy(w, rc)
+}
+
+func toProductImageRes(img *storer.ProductImage) ProductImageRes {
+	res := ProductImageRes{
+		ID:         img.ID,
+		Position:   img.Position,
+		URL:        server.ImageURL(img.Key),
+		Width:      img.Width,
+		Height:     img.Height,
+		Thumbnails: map[string]string{},
+	}
+	for name, key := range img.Thumbnails {
+		res.Thumbnails[name] = server.ImageURL(key)
+	}
+	return res
+}
@@ -27,6 +27,27 @@ func GetAuthMiddlewareFunc(tokenMaker *token.JWTMaker) func(next http.Handler) h
 	}
 }
 
+// GetOptionalAuthMiddlewareFunc passes the claims down the context like
+// GetAuthMiddlewareFunc when the request carries a token, and lets requests
+// without one through as guests. An invalid token is still rejected.
+func GetOptionalAuthMiddlewareFunc(tokenMaker *token.JWTMaker) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if r.Header.Get("Authorization") == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			claims, err := verifyClaimsFromHeader(r, tokenMaker)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("Error verifying token: %v", err), http.StatusUnauthorized)
+				return
+			}
+			ctx := context.WithValue(r.Context(), authKey{}, claims)
+			next.ServeHTTP(w, r.WithContext(ctx))
+		})
+	}
+}
+
 func GetAdminMiddlewareFunc(tokenMaker *token.JWTMaker) func(next http.Handler) http.Handler {
 	return func(next http.Handler) http.Handler {
 		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
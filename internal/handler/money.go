@@ -0,0 +1,35 @@
+package handler
+
+import (
+	"ecom_apiv1/internal/money"
+	"fmt"
+)
+
+// amountField is a decimal a client sent in field name, to be parsed into
+// dst.
+type amountField struct {
+	name string
+	d    money.Decimal
+	dst  *money.Amount
+}
+
+// parseAmounts parses decimals sent by a client in the store currency c. An
+// empty decimal, one the client did not send, is zero. Amounts sent by
+// clients are never negative.
+func parseAmounts(c money.Currency, fields ...amountField) error {
+	for _, f := range fields {
+		if f.d == "" {
+			*f.dst = 0
+			continue
+		}
+		a, err := f.d.Amount(c)
+		if err != nil {
+			return fmt.Errorf("%s: %w", f.name, err)
+		}
+		if a < 0 {
+			return fmt.Errorf("%s must not be negative", f.name)
+		}
+		*f.dst = a
+	}
+	return nil
+}
@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestCreateOrderReturnsItemIDs(t *testing.T) {
+	a := newTestAPI(t)
+	p := a.product("mug", 1250)
+
+	w := a.do("POST", "/orders", a.userToken, fmt.Sprintf(`{"payment_method":"card","shipping_country":"US","items":[{"product_id":%d,"quantity":2}]}`, p.ID))
+	if w.Code != http.StatusCreated {
+		t.Fatalf("create order: %d %s", w.Code, w.Body)
+	}
+	o := decode[struct {
+		ID    int `json:"id"`
+		Items []struct {
+			ID      int `json:"id"`
+			OrderID int `json:"order_id"`
+		} `json:"items"`
+	}](t, w)
+	if len(o.Items) != 1 || o.Items[0].ID == 0 || o.Items[0].OrderID != o.ID {
+		t.Errorf("items = %+v, want one item with an id and order_id %d", o.Items, o.ID)
+	}
+}
+
+func TestOldOrderRoutes(t *testing.T) {
+	a := newTestAPI(t)
+	p := a.product("mug", 1250)
+
+	if w := a.do("GET", "/myorder", a.userToken, ""); w.Code != http.StatusNotFound {
+		t.Errorf("GET /myorder without orders = %d, want %d", w.Code, http.StatusNotFound)
+	}
+	var ids []int
+	for range 2 {
+		w := a.do("POST", "/orders", a.userToken, fmt.Sprintf(`{"payment_method":"card","shipping_country":"US","items":[{"product_id":%d,"quantity":1}]}`, p.ID))
+		if w.Code != http.StatusCreated {
+			t.Fatalf("create order: %d %s", w.Code, w.Body)
+		}
+		ids = append(ids, decode[struct {
+			ID int `json:"id"`
+		}](t, w).ID)
+	}
+
+	w := a.do("GET", "/myorder", a.userToken, "")
+	if got := decode[struct {
+		ID int `json:"id"`
+	}](t, w).ID; w.Code != http.StatusOK || got != ids[1] {
+		t.Errorf("GET /myorder = %d with order %d, want %d with order %d", w.Code, got, http.StatusOK, ids[1])
+	}
+
+	w = a.do("DELETE", fmt.Sprintf("/orders/%d", ids[0]), a.userToken, "")
+	if got := decode[struct {
+		Status string `json:"status"`
+	}](t, w).Status; w.Code != http.StatusOK || got != "cancelled" {
+		t.Errorf("DELETE /orders/{id} = %d with status %q, want %d with status cancelled", w.Code, got, http.StatusOK)
+	}
+}
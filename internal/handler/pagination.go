@@ -0,0 +1,60 @@
+package handler
+
+import (
+	"ecom_apiv1/internal/storer"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+)
+
+const (
+	defaultPageLimit = 20
+	maxPageLimit     = 100
+)
+
+// parsePage reads the limit and cursor query parameters. The cursor is the
+// opaque next_cursor value of a previous page.
+func parsePage(r *http.Request) (storer.Page, error) {
+	page := storer.Page{Limit: defaultPageLimit}
+	q := r.URL.Query()
+	if v := q.Get("limit"); v != "" {
+		limit, err := strconv.Atoi(v)
+		if err != nil || limit < 1 || limit > maxPageLimit {
+			return page, fmt.Errorf("limit must be between 1 and %d", maxPageLimit)
+		}
+		page.Limit = limit
+	}
+	if v := q.Get("cursor"); v != "" {
+		cursor, err := decodeCursor(v)
+		if err != nil {
+			return page, fmt.Errorf("invalid cursor")
+		}
+		page.After = cursor
+	}
+	return page, nil
+}
+
+func encodeCursor(c *storer.Cursor) string {
+	if c == nil {
+		return ""
+	}
+	b, err := json.Marshal(c)
+	if err != nil {
+		return ""
+	}
+	return base64.RawURLEncoding.EncodeToString(b)
+}
+
+func decodeCursor(s string) (*storer.Cursor, error) {
+	b, err := base64.RawURLEncoding.DecodeString(s)
+	if err != nil {
+		return nil, err
+	}
+	var c storer.Cursor
+	if err := json.Unmarshal(b, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
@@ -0,0 +1,142 @@
+package handler
+
+import (
+	"database/sql"
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/storer"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+const maxSearchQueryLength = 200
+
+// parseProductFilter reads the search, filter and sort query parameters of
+// the product listing. Prices are decimals in the store currency c.
+func parseProductFilter(r *http.Request, c money.Currency) (storer.ProductFilter, error) {
+	var filter storer.ProductFilter
+	q := r.URL.Query()
+
+	filter.Query = strings.TrimSpace(q.Get("q"))
+	if utf8.RuneCountInString(filter.Query) > maxSearchQueryLength {
+		return filter, fmt.Errorf("q must be at most %d characters", maxSearchQueryLength)
+	}
+	filter.Category = strings.Trim(strings.TrimSpace(q.Get("category")), "/")
+
+	var err error
+	if filter.MinPrice, err = parsePrice(q.Get("min_price"), "min_price", c); err != nil {
+		return filter, err
+	}
+	if filter.MaxPrice, err = parsePrice(q.Get("max_price"), "max_price", c); err != nil {
+		return filter, err
+	}
+	if filter.MinPrice != nil && filter.MaxPrice != nil && *filter.MinPrice > *filter.MaxPrice {
+		return filter, fmt.Errorf("min_price must not be greater than max_price")
+	}
+
+	if v := q.Get("min_rating"); v != "" {
+		rating, err := strconv.Atoi(v)
+		if err != nil || rating < 1 || rating > 5 {
+			return filter, fmt.Errorf("min_rating must be an integer between 1 and 5")
+		}
+		filter.MinRating = rating
+	}
+	if v := q.Get("in_stock"); v != "" {
+		inStock, err := strconv.ParseBool(v)
+		if err != nil {
+			return filter, fmt.Errorf("in_stock must be true or false")
+		}
+		filter.InStock = inStock
+	}
+
+	filter.Sort = storer.ProductSort(q.Get("sort"))
+	if !filter.Sort.Valid() {
+		return filter, fmt.Errorf("sort must be one of price_asc, price_desc, rating, newest or relevance")
+	}
+	if filter.Sort == storer.ProductSortRelevance && filter.Query == "" {
+		return filter, fmt.Errorf("sort=relevance requires q")
+	}
+	return filter, nil
+}
+
+func parsePrice(v, name string, c money.Currency) (*money.Amount, error) {
+	if v == "" {
+		return nil, nil
+	}
+	price, err := c.Parse(v)
+	if err != nil || price < 0 {
+		return nil, fmt.Errorf("%s must be a non-negative amount in %s", name, c)
+	}
+	return &price, nil
+}
+
+func toProductFilterRes(filter storer.ProductFilter, c money.Currency) ProductFilterRes {
+	return ProductFilterRes{
+		Query:     filter.Query,
+		Category:  filter.Category,
+		MinPrice:  toMoneyPtr(filter.MinPrice, c),
+		MaxPrice:  toMoneyPtr(filter.MaxPrice, c),
+		MinRating: filter.MinRating,
+		InStock:   filter.InStock,
+		Sort:      string(filter.EffectiveSort()),
+	}
+}
+
+func toMoneyPtr(a *money.Amount, c money.Currency) *money.Money {
+	if a == nil {
+		return nil
+	}
+	m := money.New(*a, c)
+	return &m
+}
+
+func (h *handler) productFacets(w http.ResponseWriter, r *http.Request) {
+	filter, err := parseProductFilter(r, h.server.Currency())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	facets, err := h.server.ProductFacets(h.Ctx, filter)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "category not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "error counting products", http.StatusInternalServerError)
+		return
+	}
+	res := ProductFacetsRes{
+		Filters:    toProductFilterRes(filter, h.server.Currency()),
+		Categories: []CategoryCountRes{},
+		Stock: StockCountRes{
+			InStock:    facets.InStock,
+			OutOfStock: facets.OutOfStock,
+		},
+	}
+	for _, c := range facets.Categories {
+		res.Categories = append(res.Categories, CategoryCountRes{
+			CategoryID: c.CategoryID,
+			Name:       c.Name,
+			Path:       c.Path,
+			Count:      c.Count,
+		})
+	}
+	for _, b := range facets.Prices {
+		res.Prices = append(res.Prices, PriceBucketRes{
+			Min:   money.New(b.Min, h.server.Currency()),
+			Max:   toMoneyPtr(b.Max, h.server.Currency()),
+			Count: b.Count,
+		})
+	}
+	for _, b := range facets.Ratings {
+		res.Ratings = append(res.Ratings, RatingBucketRes{MinRating: b.MinRating, Count: b.Count})
+	}
+
+	w.Header().Set("Content-Type", "application-json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
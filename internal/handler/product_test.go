@@ -0,0 +1,49 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"testing"
+)
+
+func TestProductRejectsNegativeAmounts(t *testing.T) {
+	a := newTestAPI(t)
+	p := a.product("mug", 1250)
+
+	tests := []struct {
+		name   string
+		method string
+		path   string
+		body   string
+		want   int
+	}{
+		{"create", "POST", "/products", `{"name":"cup","price":"4.50","weight":0.3}`, http.StatusOK},
+		{"create with negative price", "POST", "/products", `{"name":"cup","price":"-5.00"}`, http.StatusBadRequest},
+		{"create with negative number price", "POST", "/products", `{"name":"cup","price":-5}`, http.StatusBadRequest},
+		{"create with negative weight", "POST", "/products", `{"name":"cup","price":"5.00","weight":-1}`, http.StatusBadRequest},
+		{"update with negative price", "PATCH", fmt.Sprintf("/products/%d", p.ID), `{"price":"-1"}`, http.StatusBadRequest},
+		{"update with negative weight", "PATCH", fmt.Sprintf("/products/%d", p.ID), `{"weight":-0.5}`, http.StatusBadRequest},
+		{"variant with negative price", "POST", fmt.Sprintf("/products/%d/variants", p.ID), `{"sku":"MUG-1","price":"-3.00"}`, http.StatusBadRequest},
+		{"order with negative total", "POST", "/orders", fmt.Sprintf(`{"payment_method":"card","shipping_country":"US","total_price":"-1","items":[{"product_id":%d,"quantity":1}]}`, p.ID), http.StatusBadRequest},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			token := a.adminToken
+			if tt.path == "/orders" {
+				token = a.userToken
+			}
+			if w := a.do(tt.method, tt.path, token, tt.body); w.Code != tt.want {
+				t.Errorf("status = %d (%s), want %d", w.Code, w.Body, tt.want)
+			}
+		})
+	}
+
+	got, err := a.st.GetProduct(context.Background(), p.ID)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if got.Price != 1250 || got.Weight != 0 {
+		t.Errorf("product saved with price %d and weight %v", got.Price, got.Weight)
+	}
+}
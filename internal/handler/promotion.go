@@ -0,0 +1,225 @@
+package handler
+
+import (
+	"database/sql"
+	"ecom_apiv1/internal/server"
+	"ecom_apiv1/internal/storer"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *handler) createPromotion(w http.ResponseWriter, r *http.Request) {
+	var req PromotionReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	p := &storer.Promotion{}
+	patchPromotionReq(p, req)
+	if err := validatePromotion(p); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	p.UpdatedAt = nil
+
+	created, err := h.server.CreatePromotion(h.Ctx, p)
+	if err != nil {
+		writePromotionError(w, err, "error creating promotion")
+		return
+	}
+	res := toPromotionRes(created)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) getPromotion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	p, err := h.server.GetPromotion(h.Ctx, id)
+	if err != nil {
+		writePromotionError(w, err, "error getting promotion")
+		return
+	}
+	res := toPromotionRes(p)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) listPromotions(w http.ResponseWriter, r *http.Request) {
+	promotions, err := h.server.ListPromotions(h.Ctx)
+	if err != nil {
+		http.Error(w, "error listing promotions", http.StatusInternalServerError)
+		return
+	}
+	res := []PromotionRes{}
+	for _, p := range promotions {
+		res = append(res, toPromotionRes(&p))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) updatePromotion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req PromotionReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	p, err := h.server.GetPromotion(h.Ctx, id)
+	if err != nil {
+		writePromotionError(w, err, "error getting promotion")
+		return
+	}
+	patchPromotionReq(p, req)
+	if err := validatePromotion(p); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.server.UpdatePromotion(h.Ctx, p)
+	if err != nil {
+		writePromotionError(w, err, "error updating promotion")
+		return
+	}
+	res := toPromotionRes(updated)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) deletePromotion(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	err = h.server.DeletePromotion(h.Ctx, id)
+	if err != nil {
+		writePromotionError(w, err, "error deleting promotion")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func writePromotionError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, server.ErrInvalidPromotion):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "promotion not found", http.StatusNotFound)
+	default:
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
+
+// patchPromotionReq applies req to p. Fields that do not belong to the
+// promotion's type are cleared, so changing the type leaves nothing stale.
+func patchPromotionReq(p *storer.Promotion, req PromotionReq) {
+	if req.Name != "" {
+		p.Name = strings.TrimSpace(req.Name)
+	}
+	if req.Type != "" {
+		p.Type = storer.PromotionType(req.Type)
+	}
+	if req.Percent != nil {
+		p.Percent = *req.Percent
+	}
+	if req.BuyQuantity != nil {
+		p.BuyQuantity = *req.BuyQuantity
+	}
+	if req.GetQuantity != nil {
+		p.GetQuantity = *req.GetQuantity
+	}
+	if req.StartsAt != nil {
+		p.StartsAt = req.StartsAt
+	}
+	if req.EndsAt != nil {
+		p.EndsAt = req.EndsAt
+	}
+	if req.CategoryIDs != nil {
+		p.CategoryIDs = *req.CategoryIDs
+	}
+	if req.ProductIDs != nil {
+		p.ProductIDs = *req.ProductIDs
+	}
+	switch p.Type {
+	case storer.PromotionSale:
+		p.BuyQuantity, p.GetQuantity = 0, 0
+	case storer.PromotionBuyXGetY:
+		p.Percent = 0
+	}
+	p.UpdatedAt = toTimePtr(time.Now())
+}
+
+func validatePromotion(p *storer.Promotion) error {
+	if p.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	switch p.Type {
+	case storer.PromotionSale:
+		if p.Percent <= 0 || p.Percent > 100 {
+			return fmt.Errorf("percent of a sale must be more than 0 and at most 100")
+		}
+	case storer.PromotionBuyXGetY:
+		if p.BuyQuantity < 1 || p.GetQuantity < 1 {
+			return fmt.Errorf("buy_quantity and get_quantity must be at least 1")
+		}
+	default:
+		return fmt.Errorf("type must be %q or %q", storer.PromotionSale, storer.PromotionBuyXGetY)
+	}
+	if p.StartsAt != nil && p.EndsAt != nil && !p.EndsAt.After(*p.StartsAt) {
+		return fmt.Errorf("ends_at must be after starts_at")
+	}
+	return nil
+}
+
+func toPromotionRes(p *storer.Promotion) PromotionRes {
+	res := PromotionRes{
+		ID:          p.ID,
+		Name:        p.Name,
+		Type:        string(p.Type),
+		Percent:     p.Percent,
+		BuyQuantity: p.BuyQuantity,
+		GetQuantity: p.GetQuantity,
+		StartsAt:    p.StartsAt,
+		EndsAt:      p.EndsAt,
+		Running:     p.Running(time.Now()),
+		CategoryIDs: p.CategoryIDs,
+		ProductIDs:  p.ProductIDs,
+		CreatedAt:   p.CreatedAt,
+		UpdatedAt:   p.UpdatedAt,
+	}
+	if res.CategoryIDs == nil {
+		res.CategoryIDs = []int{}
+	}
+	if res.ProductIDs == nil {
+		res.ProductIDs = []int{}
+	}
+	return res
+}
@@ -0,0 +1,337 @@
+package handler
+
+import (
+	"database/sql"
+	"ecom_apiv1/internal/server"
+	"ecom_apiv1/internal/storer"
+	"ecom_apiv1/token"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// listProductReviews lists the reviews of a product, newest first unless
+// another sort is requested.
+func (h *handler) listProductReviews(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	page, err := parsePage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	sort := storer.ReviewSort(r.URL.Query().Get("sort"))
+	if !sort.Valid() {
+		http.Error(w, "sort must be one of newest, oldest, rating_desc, rating_asc", http.StatusBadRequest)
+		return
+	}
+
+	reviews, next, err := h.server.ListProductReviews(h.Ctx, id, sort, page)
+	if err != nil {
+		switch {
+		case errors.Is(err, storer.ErrInvalidCursor):
+			http.Error(w, "invalid cursor", http.StatusBadRequest)
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "product not found", http.StatusNotFound)
+		default:
+			http.Error(w, "error listing reviews", http.StatusInternalServerError)
+		}
+		return
+	}
+
+	if sort == "" {
+		sort = storer.ReviewSortNewest
+	}
+	res := ListReviewRes{
+		Reviews:    []ReviewRes{},
+		Sort:       string(sort),
+		NextCursor: encodeCursor(next),
+		HasMore:    next != nil,
+	}
+	for _, rv := range reviews {
+		res.Reviews = append(res.Reviews, toReviewRes(&rv))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) createReview(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req ReviewReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	rv := &storer.Review{ProductID: id, UserID: claims.ID}
+	patchReviewReq(rv, req)
+	rv.UpdatedAt = nil
+	if err := validateReview(rv); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.server.CreateReview(h.Ctx, rv)
+	if err != nil {
+		switch {
+		case errors.Is(err, server.ErrNotVerifiedBuyer):
+			http.Error(w, err.Error(), http.StatusForbidden)
+		case errors.Is(err, storer.ErrDuplicateReview):
+			http.Error(w, storer.ErrDuplicateReview.Error(), http.StatusConflict)
+		case errors.Is(err, sql.ErrNoRows):
+			http.Error(w, "product not found", http.StatusNotFound)
+		default:
+			http.Error(w, "error creating review", http.StatusInternalServerError)
+		}
+		return
+	}
+	res := toReviewRes(created)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(res)
+}
+
+// updateReview lets customers edit their own reviews.
+func (h *handler) updateReview(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	rv, ok := h.productReview(w, r)
+	if !ok {
+		return
+	}
+	if rv.UserID != claims.ID {
+		http.Error(w, "can only edit your own reviews", http.StatusForbidden)
+		return
+	}
+	var req ReviewReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	patchReviewReq(rv, req)
+	if err := validateReview(rv); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.server.UpdateReview(h.Ctx, rv)
+	if err != nil {
+		http.Error(w, "error updating review", http.StatusInternalServerError)
+		return
+	}
+	res := toReviewRes(updated)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+// deleteReview lets customers delete their own reviews and admins delete
+// any review.
+func (h *handler) deleteReview(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	rv, ok := h.productReview(w, r)
+	if !ok {
+		return
+	}
+	if rv.UserID != claims.ID && !claims.IsAdmin {
+		http.Error(w, "can only delete your own reviews", http.StatusForbidden)
+		return
+	}
+	err := h.server.DeleteReview(h.Ctx, rv.ID)
+	if err != nil {
+		http.Error(w, "error deleting review", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// listReviewsForModeration lists the reviews with the status in the query,
+// pending by default, oldest first.
+func (h *handler) listReviewsForModeration(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	status := storer.ReviewStatus(r.URL.Query().Get("status"))
+	if status == "" {
+		status = storer.ReviewStatusPending
+	}
+	if !status.Valid() {
+		http.Error(w, "status must be one of pending, approved, rejected", http.StatusBadRequest)
+		return
+	}
+
+	reviews, next, err := h.server.ListReviewsByStatus(h.Ctx, status, page)
+	if err != nil {
+		http.Error(w, "error listing reviews", http.StatusInternalServerError)
+		return
+	}
+	res := ListReviewRes{
+		Reviews:    []ReviewRes{},
+		NextCursor: encodeCursor(next),
+		HasMore:    next != nil,
+	}
+	for _, rv := range reviews {
+		res.Reviews = append(res.Reviews, toReviewRes(&rv))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) moderateReview(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req ModerateReviewReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	reviews, err := h.server.ModerateReviews(h.Ctx, []int{id}, storer.ReviewStatus(req.Status), strings.TrimSpace(req.Reason))
+	if err != nil {
+		writeModerationError(w, err, "review not found")
+		return
+	}
+	res := toReviewRes(&reviews[0])
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+// moderateReviews approves or rejects all reviews in the body. Nothing is
+// changed if one of them does not exist.
+func (h *handler) moderateReviews(w http.ResponseWriter, r *http.Request) {
+	var req ModerateReviewsReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	if len(req.ReviewIDs) > maxPageLimit {
+		http.Error(w, fmt.Sprintf("at most %d reviews can be moderated at once", maxPageLimit), http.StatusBadRequest)
+		return
+	}
+
+	reviews, err := h.server.ModerateReviews(h.Ctx, req.ReviewIDs, storer.ReviewStatus(req.Status), strings.TrimSpace(req.Reason))
+	if err != nil {
+		writeModerationError(w, err, "one or more reviews were not found, no review was changed")
+		return
+	}
+	res := ListReviewRes{Reviews: []ReviewRes{}}
+	for _, rv := range reviews {
+		res.Reviews = append(res.Reviews, toReviewRes(&rv))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func writeModerationError(w http.ResponseWriter, err error, notFound string) {
+	switch {
+	case errors.Is(err, server.ErrInvalidModeration):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, notFound, http.StatusNotFound)
+	default:
+		http.Error(w, "error moderating reviews", http.StatusInternalServerError)
+	}
+}
+
+// productReview loads the review named in the URL and makes sure it belongs
+// to the product in the URL. It writes the error response itself.
+func (h *handler) productReview(w http.ResponseWriter, r *http.Request) (*storer.Review, bool) {
+	vars := mux.Vars(r)
+	productID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return nil, false
+	}
+	reviewID, err := strconv.Atoi(vars["reviewID"])
+	if err != nil {
+		http.Error(w, "error parsing review id", http.StatusBadRequest)
+		return nil, false
+	}
+	rv, err := h.server.GetReview(h.Ctx, reviewID)
+	if err != nil || rv.ProductID != productID {
+		http.Error(w, "review not found", http.StatusNotFound)
+		return nil, false
+	}
+	return rv, true
+}
+
+func patchReviewReq(rv *storer.Review, req ReviewReq) {
+	if req.Rating != 0 {
+		rv.Rating = req.Rating
+	}
+	if req.Title != "" {
+		rv.Title = strings.TrimSpace(req.Title)
+	}
+	if req.Body != "" {
+		rv.Body = strings.TrimSpace(req.Body)
+	}
+	rv.UpdatedAt = toTimePtr(time.Now())
+}
+
+func validateReview(rv *storer.Review) error {
+	if rv.Rating < 1 || rv.Rating > 5 {
+		return fmt.Errorf("rating must be between 1 and 5")
+	}
+	if rv.Title == "" {
+		return fmt.Errorf("title is required")
+	}
+	if len(rv.Title) > 120 {
+		return fmt.Errorf("title must be at most 120 characters")
+	}
+	if rv.Body == "" {
+		return fmt.Errorf("body is required")
+	}
+	if len(rv.Body) > 5000 {
+		return fmt.Errorf("body must be at most 5000 characters")
+	}
+	return nil
+}
+
+func toReviewRes(rv *storer.Review) ReviewRes {
+	return ReviewRes{
+		ID:               rv.ID,
+		ProductID:        rv.ProductID,
+		UserID:           rv.UserID,
+		UserName:         rv.UserName,
+		Rating:           rv.Rating,
+		Title:            rv.Title,
+		Body:             rv.Body,
+		Status:           string(rv.Status),
+		ModerationReason: rv.ModerationReason,
+		ModeratedAt:      rv.ModeratedAt,
+		CreatedAt:        rv.CreatedAt,
+		UpdatedAt:        rv.UpdatedAt,
+	}
+}
@@ -14,7 +14,9 @@ func RegisterRoutes(h *handler) *mux.Router {
 
 	// Products
 	r.HandleFunc("/products", h.Listproducts).Methods("GET")
+	r.HandleFunc("/products/facets", h.productFacets).Methods("GET")
 	r.HandleFunc("/products/{id}", h.getProduct).Methods("GET")
+	r.HandleFunc("/products/{id}/reviews", h.listProductReviews).Methods("GET")
 
 	// Admin Product routes
 	adminProductRouter := r.PathPrefix("/products").Subrouter()
@@ -22,20 +24,91 @@ func RegisterRoutes(h *handler) *mux.Router {
 	adminProductRouter.HandleFunc("", h.createProduct).Methods("POST")
 	adminProductRouter.HandleFunc("/{id}", h.updateProducts).Methods("PATCH")
 	adminProductRouter.HandleFunc("/{id}", h.DeleteProduct).Methods("DELETE")
+	adminProductRouter.HandleFunc("/{id}/options", h.setProductOptions).Methods("PUT")
+	adminProductRouter.HandleFunc("/{id}/variants", h.createVariant).Methods("POST")
+	adminProductRouter.HandleFunc("/{id}/variants/{variantID}", h.updateVariant).Methods("PATCH")
+	adminProductRouter.HandleFunc("/{id}/variants/{variantID}", h.deleteVariant).Methods("DELETE")
+	adminProductRouter.HandleFunc("/{id}/images", h.uploadProductImage).Methods("POST")
+	adminProductRouter.HandleFunc("/{id}/images/order", h.reorderProductImages).Methods("PUT")
+	adminProductRouter.HandleFunc("/{id}/images/{imageID}", h.deleteProductImage).Methods("DELETE")
+
+	// Images
+	r.HandleFunc("/images/{key:.+}", h.serveImage).Methods("GET")
+
+	// Categories
+	r.HandleFunc("/categories", h.listCategories).Methods("GET")
+	r.HandleFunc("/categories/{id}", h.getCategory).Methods("GET")
+
+	// Admin Category routes
+	adminCategoryRouter := r.PathPrefix("/categories").Subrouter()
+	adminCategoryRouter.Use(GetAdminMiddlewareFunc(tokenMaker))
+	adminCategoryRouter.HandleFunc("", h.createCategory).Methods("POST")
+	adminCategoryRouter.HandleFunc("/{id}", h.updateCategory).Methods("PATCH")
+	adminCategoryRouter.HandleFunc("/{id}", h.deleteCategory).Methods("DELETE")
+
+	// Admin Review routes
+	adminReviewRouter := r.PathPrefix("/reviews").Subrouter()
+	adminReviewRouter.Use(GetAdminMiddlewareFunc(tokenMaker))
+	adminReviewRouter.HandleFunc("/moderation", h.listReviewsForModeration).Methods("GET")
+	adminReviewRouter.HandleFunc("/moderation", h.moderateReviews).Methods("POST")
+	adminReviewRouter.HandleFunc("/{id}/moderation", h.moderateReview).Methods("PATCH")
+
+	// Admin Wishlist routes
+	adminWishlistRouter := r.PathPrefix("/wishlists").Subrouter()
+	adminWishlistRouter.Use(GetAdminMiddlewareFunc(tokenMaker))
+	adminWishlistRouter.HandleFunc("/products", h.listWishlistedProducts).Methods("GET")
+
+	// Shared wishlists
+	r.HandleFunc("/shared/wishlists/{token}", h.getSharedWishlist).Methods("GET")
+
+	// Cart routes, for signed-in users and guests
+	cartRouter := r.PathPrefix("/cart").Subrouter()
+	cartRouter.Use(GetOptionalAuthMiddlewareFunc(tokenMaker))
+	cartRouter.HandleFunc("", h.getCart).Methods("GET")
+	cartRouter.HandleFunc("", h.clearCart).Methods("DELETE")
+	cartRouter.HandleFunc("/items", h.addCartItem).Methods("POST")
+	cartRouter.HandleFunc("/items/{id}", h.updateCartItem).Methods("PATCH")
+	cartRouter.HandleFunc("/items/{id}", h.deleteCartItem).Methods("DELETE")
 
 	// Auth required routes
 	authRouter := r.PathPrefix("").Subrouter()
 	authRouter.Use(GetAuthMiddlewareFunc(tokenMaker))
 
+	// Reviews
+	authRouter.HandleFunc("/products/{id}/reviews", h.createReview).Methods("POST")
+	authRouter.HandleFunc("/products/{id}/reviews/{reviewID}", h.updateReview).Methods("PATCH")
+	authRouter.HandleFunc("/products/{id}/reviews/{reviewID}", h.deleteReview).Methods("DELETE")
+
+	// Checkout
+	authRouter.HandleFunc("/cart/checkout", h.checkout).Methods("POST")
+
+	// Wishlists
+	authRouter.HandleFunc("/wishlists", h.listWishlists).Methods("GET")
+	authRouter.HandleFunc("/wishlists", h.createWishlist).Methods("POST")
+	authRouter.HandleFunc("/wishlists/{id}", h.getWishlist).Methods("GET")
+	authRouter.HandleFunc("/wishlists/{id}", h.renameWishlist).Methods("PATCH")
+	authRouter.HandleFunc("/wishlists/{id}", h.deleteWishlist).Methods("DELETE")
+	authRouter.HandleFunc("/wishlists/{id}/items", h.addWishlistItem).Methods("POST")
+	authRouter.HandleFunc("/wishlists/{id}/items/{itemID}", h.deleteWishlistItem).Methods("DELETE")
+	authRouter.HandleFunc("/wishlists/{id}/items/{itemID}/move-to-cart", h.moveWishlistItemToCart).Methods("POST")
+	authRouter.HandleFunc("/wishlists/{id}/share", h.shareWishlist).Methods("POST")
+	authRouter.HandleFunc("/wishlists/{id}/share", h.unshareWishlist).Methods("DELETE")
+
 	// Orders
-	authRouter.HandleFunc("/myorder", h.getOrder).Methods("GET")
+	authRouter.HandleFunc("/myorders", h.listMyOrders).Methods("GET")
 	authRouter.HandleFunc("/orders", h.createOrder).Methods("POST")
-	authRouter.HandleFunc("/orders/{id}", h.deleteOrder).Methods("DELETE")
+	authRouter.HandleFunc("/orders/{id}", h.getOrder).Methods("GET")
+	authRouter.HandleFunc("/orders/{id}/cancel", h.cancelOrder).Methods("POST")
+	// GET /myorder and DELETE /orders/{id} are kept for older clients.
+	authRouter.HandleFunc("/myorder", h.getMyOrder).Methods("GET")
+	authRouter.HandleFunc("/orders/{id}", h.cancelOrder).Methods("DELETE")
 
 	// Admin Order routes
 	adminOrderRouter := authRouter.PathPrefix("/orders").Subrouter()
 	adminOrderRouter.Use(GetAdminMiddlewareFunc(tokenMaker))
 	adminOrderRouter.HandleFunc("", h.listOrders).Methods("GET")
+	adminOrderRouter.HandleFunc("/{id}/status", h.updateOrderStatus).Methods("PATCH")
+	adminOrderRouter.HandleFunc("/{id}/history", h.listOrderStatusHistory).Methods("GET")
 
 	// Users
 	r.HandleFunc("/users", h.createUser).Methods("POST")
@@ -50,6 +123,36 @@ func RegisterRoutes(h *handler) *mux.Router {
 	adminUserRouter.HandleFunc("", h.listUsers).Methods("GET")
 	adminUserRouter.HandleFunc("/{id}", h.deleteUser).Methods("DELETE")
 
+	// Shipping
+	r.HandleFunc("/shipping/quote", h.quoteShipping).Methods("POST")
+
+	// Admin Tax rate routes
+	adminTaxRouter := r.PathPrefix("/tax-rates").Subrouter()
+	adminTaxRouter.Use(GetAdminMiddlewareFunc(tokenMaker))
+	adminTaxRouter.HandleFunc("", h.listTaxRates).Methods("GET")
+	adminTaxRouter.HandleFunc("", h.createTaxRate).Methods("POST")
+	adminTaxRouter.HandleFunc("/{id}", h.getTaxRate).Methods("GET")
+	adminTaxRouter.HandleFunc("/{id}", h.updateTaxRate).Methods("PATCH")
+	adminTaxRouter.HandleFunc("/{id}", h.deleteTaxRate).Methods("DELETE")
+
+	// Admin Coupon routes
+	adminCouponRouter := r.PathPrefix("/coupons").Subrouter()
+	adminCouponRouter.Use(GetAdminMiddlewareFunc(tokenMaker))
+	adminCouponRouter.HandleFunc("", h.listCoupons).Methods("GET")
+	adminCouponRouter.HandleFunc("", h.createCoupon).Methods("POST")
+	adminCouponRouter.HandleFunc("/{id}", h.getCoupon).Methods("GET")
+	adminCouponRouter.HandleFunc("/{id}", h.updateCoupon).Methods("PATCH")
+	adminCouponRouter.HandleFunc("/{id}", h.deleteCoupon).Methods("DELETE")
+
+	// Admin Promotion routes
+	adminPromotionRouter := r.PathPrefix("/promotions").Subrouter()
+	adminPromotionRouter.Use(GetAdminMiddlewareFunc(tokenMaker))
+	adminPromotionRouter.HandleFunc("", h.listPromotions).Methods("GET")
+	adminPromotionRouter.HandleFunc("", h.createPromotion).Methods("POST")
+	adminPromotionRouter.HandleFunc("/{id}", h.getPromotion).Methods("GET")
+	adminPromotionRouter.HandleFunc("/{id}", h.updatePromotion).Methods("PATCH")
+	adminPromotionRouter.HandleFunc("/{id}", h.deletePromotion).Methods("DELETE")
+
 	// Tokens
 	authRouter.HandleFunc("/tokens/renew", h.renewAccessToken).Methods("POST")
 	authRouter.HandleFunc("/tokens/revoke", h.revokeSession).Methods("POST")
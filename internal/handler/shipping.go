@@ -0,0 +1,55 @@
+package handler
+
+import (
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/server"
+	"ecom_apiv1/internal/storer"
+	"encoding/json"
+	"errors"
+	"net/http"
+)
+
+func (h *handler) quoteShipping(w http.ResponseWriter, r *http.Request) {
+	var req ShippingQuoteReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	items, err := toStorerOrderItem(req.Items, h.server.Currency())
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	options, err := h.server.QuoteShipping(h.Ctx, &storer.Order{
+		ShippingCountry: req.ShippingCountry,
+		ShippingRegion:  req.ShippingRegion,
+		Items:           items,
+	})
+	if err != nil {
+		var priceErr *server.PriceMismatchError
+		if errors.Is(err, server.ErrInvalidOrder) || errors.As(err, &priceErr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "error quoting shipping", http.StatusInternalServerError)
+		return
+	}
+
+	c := h.server.Currency()
+	res := ShippingQuoteRes{Currency: string(c), Options: []ShippingOptionRes{}}
+	for _, o := range options {
+		res.Options = append(res.Options, ShippingOptionRes{
+			Method: o.Method,
+			Name:   o.Name,
+			Zone:   o.Zone,
+			Price:  money.New(o.Price, c),
+			Days:   o.Days,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
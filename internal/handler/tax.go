@@ -0,0 +1,181 @@
+package handler
+
+import (
+	"ecom_apiv1/internal/storer"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *handler) createTaxRate(w http.ResponseWriter, r *http.Request) {
+	var req TaxRateReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	if req.Rate == nil {
+		http.Error(w, "rate is required", http.StatusBadRequest)
+		return
+	}
+
+	tr := &storer.TaxRate{}
+	patchTaxRateReq(tr, req)
+	if err := validateTaxRate(tr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	tr.UpdatedAt = nil
+
+	created, err := h.server.CreateTaxRate(h.Ctx, tr)
+	if err != nil {
+		http.Error(w, "error creating tax rate", http.StatusInternalServerError)
+		return
+	}
+	res := toTaxRateRes(created)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) getTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	tr, err := h.server.GetTaxRate(h.Ctx, id)
+	if err != nil {
+		http.Error(w, "error getting tax rate", http.StatusNotFound)
+		return
+	}
+	res := toTaxRateRes(tr)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) listTaxRates(w http.ResponseWriter, r *http.Request) {
+	rates, err := h.server.ListTaxRates(h.Ctx)
+	if err != nil {
+		http.Error(w, "error listing tax rates", http.StatusInternalServerError)
+		return
+	}
+	res := []TaxRateRes{}
+	for _, tr := range rates {
+		res = append(res, toTaxRateRes(&tr))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) updateTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req TaxRateReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	tr, err := h.server.GetTaxRate(h.Ctx, id)
+	if err != nil {
+		http.Error(w, "error getting tax rate", http.StatusNotFound)
+		return
+	}
+	patchTaxRateReq(tr, req)
+	if err := validateTaxRate(tr); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.server.UpdateTaxRate(h.Ctx, tr)
+	if err != nil {
+		http.Error(w, "error updating tax rate", http.StatusInternalServerError)
+		return
+	}
+	res := toTaxRateRes(updated)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) deleteTaxRate(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	err = h.server.DeleteTaxRate(h.Ctx, id)
+	if err != nil {
+		http.Error(w, "error deleting tax rate", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func patchTaxRateReq(tr *storer.TaxRate, req TaxRateReq) {
+	if req.Name != "" {
+		tr.Name = req.Name
+	}
+	if req.Country != "" {
+		tr.Country = strings.ToUpper(req.Country)
+	}
+	if req.Region != "" {
+		tr.Region = req.Region
+	}
+	if req.Category != "" {
+		tr.Category = req.Category
+	}
+	if req.Rate != nil {
+		tr.Rate = *req.Rate
+	}
+	if req.Inclusive != nil {
+		tr.Inclusive = *req.Inclusive
+	}
+	tr.UpdatedAt = toTimePtr(time.Now())
+}
+
+func validateTaxRate(tr *storer.TaxRate) error {
+	if tr.Name == "" {
+		return fmt.Errorf("name is required")
+	}
+	if tr.Rate < 0 || tr.Rate > 1 {
+		return fmt.Errorf("rate must be between 0 and 1")
+	}
+	if tr.Country != "" && len(tr.Country) != 2 {
+		return fmt.Errorf("country must be an ISO 3166 alpha-2 code")
+	}
+	if tr.Region != "" && tr.Country == "" {
+		return fmt.Errorf("region requires a country")
+	}
+	return nil
+}
+
+func toTaxRateRes(tr *storer.TaxRate) TaxRateRes {
+	return TaxRateRes{
+		ID:        tr.ID,
+		Name:      tr.Name,
+		Country:   tr.Country,
+		Region:    tr.Region,
+		Category:  tr.Category,
+		Rate:      tr.Rate,
+		Inclusive: tr.Inclusive,
+		CreatedAt: tr.CreatedAt,
+		UpdatedAt: tr.UpdatedAt,
+	}
+}
@@ -1,57 +1,251 @@
 package handler
 
-import "time"
+import (
+	"ecom_apiv1/internal/money"
+	"time"
+)
 
 type ProductReq struct {
-	Name         string  `json:"name"`
-	Image        string  `json:"image"`
-	Category     string  `json:"category"`
-	Description  string  `json:"description"`
-	Rating       int     `json:"rating"`
-	NumReviews   int     `json:"num_reviews"`
-	Price        float64 `json:"price"`
-	CountInStock int     `json:"count_in_stock"`
+	Name         string        `json:"name"`
+	Image        string        `json:"image"`
+	CategoryID   *int          `json:"category_id"`
+	Description  string        `json:"description"`
+	Price        money.Decimal `json:"price"`
+	Weight       float64       `json:"weight"`
+	CountInStock int           `json:"count_in_stock"`
 }
 
 type ProductRes struct {
-	ID           int        `json:"id"`
-	Name         string     `json:"name"`
-	Image        string     `json:"image"`
-	Category     string     `json:"category"`
-	Description  string     `json:"description"`
-	Rating       int        `json:"rating"`
-	NumReviews   int        `json:"num_reviews"`
-	Price        float64    `json:"price"`
-	CountInStock int        `json:"count_in_stock"`
-	CreatedAt    time.Time  `json:"created_at"`
-	UpdatedAt    *time.Time `json:"updated_at"`
+	ID           int         `json:"id"`
+	Name         string      `json:"name"`
+	Image        string      `json:"image"`
+	CategoryID   *int        `json:"category_id"`
+	Description  string      `json:"description"`
+	Rating       float64     `json:"rating"`
+	NumReviews   int         `json:"num_reviews"`
+	Currency     string      `json:"currency"`
+	Price        money.Money `json:"price"`
+	SalePrice    money.Money `json:"sale_price"`
+	Weight       float64     `json:"weight"`
+	CountInStock int         `json:"count_in_stock"`
+	CreatedAt    time.Time   `json:"created_at"`
+	UpdatedAt    *time.Time  `json:"updated_at"`
+
+	Options    []ProductOptionRes    `json:"options"`
+	Variants   []ProductVariantRes   `json:"variants"`
+	Images     []ProductImageRes     `json:"images"`
+	Promotions []ProductPromotionRes `json:"promotions"`
+}
+
+// ProductPromotionRes names a promotion running on a product. Sales are
+// already taken off sale_price; buy X get Y offers are taken off in the cart
+// and the order.
+type ProductPromotionRes struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Type        string     `json:"type"`
+	Percent     float64    `json:"percent,omitempty"`
+	BuyQuantity int        `json:"buy_quantity,omitempty"`
+	GetQuantity int        `json:"get_quantity,omitempty"`
+	EndsAt      *time.Time `json:"ends_at"`
+}
+
+// ProductImageRes links to an uploaded image and its thumbnails, by size
+// name.
+type ProductImageRes struct {
+	ID         int               `json:"id"`
+	Position   int               `json:"position"`
+	URL        string            `json:"url"`
+	Width      int               `json:"width"`
+	Height     int               `json:"height"`
+	Thumbnails map[string]string `json:"thumbnails"`
+}
+
+type ReorderImagesReq struct {
+	ImageIDs []int `json:"image_ids"`
+}
+
+type ProductOptionReq struct {
+	Name    string   `json:"name"`
+	Choices []string `json:"choices"`
+}
+
+type ProductOptionRes struct {
+	Name    string   `json:"name"`
+	Choices []string `json:"choices"`
+}
+
+// VariantReq creates or patches a variant. A price of 0 removes the price
+// override, so the variant sells at the product's price.
+type VariantReq struct {
+	SKU          string            `json:"sku"`
+	Options      map[string]string `json:"options"`
+	Price        *money.Decimal    `json:"price"`
+	Image        string            `json:"image"`
+	CountInStock *int              `json:"count_in_stock"`
+}
+
+// ProductVariantRes shows the price and image a variant sells with, which
+// fall back to the product's when the variant does not set its own.
+type ProductVariantRes struct {
+	ID           int               `json:"id"`
+	SKU          string            `json:"sku"`
+	Options      map[string]string `json:"options"`
+	Price        money.Money       `json:"price"`
+	SalePrice    money.Money       `json:"sale_price"`
+	Image        string            `json:"image"`
+	CountInStock int               `json:"count_in_stock"`
+}
+
+type ListProductRes struct {
+	Products   []ProductRes     `json:"products"`
+	Filters    ProductFilterRes `json:"filters"`
+	NextCursor string           `json:"next_cursor,omitempty"`
+	HasMore    bool             `json:"has_more"`
+}
+
+type ProductFilterRes struct {
+	Query     string       `json:"q,omitempty"`
+	Category  string       `json:"category,omitempty"`
+	MinPrice  *money.Money `json:"min_price,omitempty"`
+	MaxPrice  *money.Money `json:"max_price,omitempty"`
+	MinRating int          `json:"min_rating,omitempty"`
+	InStock   bool         `json:"in_stock,omitempty"`
+	Sort      string       `json:"sort,omitempty"`
+}
+
+type ProductFacetsRes struct {
+	Filters    ProductFilterRes   `json:"filters"`
+	Categories []CategoryCountRes `json:"categories"`
+	Prices     []PriceBucketRes   `json:"prices"`
+	Ratings    []RatingBucketRes  `json:"ratings"`
+	Stock      StockCountRes      `json:"stock"`
+}
+
+type CategoryCountRes struct {
+	CategoryID *int   `json:"category_id"`
+	Name       string `json:"name,omitempty"`
+	Path       string `json:"path,omitempty"`
+	Count      int    `json:"count"`
+}
+
+type PriceBucketRes struct {
+	Min   money.Money  `json:"min"`
+	Max   *money.Money `json:"max,omitempty"`
+	Count int          `json:"count"`
+}
+
+type RatingBucketRes struct {
+	MinRating int `json:"min_rating"`
+	Count     int `json:"count"`
+}
+
+type StockCountRes struct {
+	InStock    int `json:"in_stock"`
+	OutOfStock int `json:"out_of_stock"`
 }
 
 type OrderReq struct {
-	Items         []OrderItem `json:"items"`
-	PaymentMethod string      `json:"payment_method"`
-	TaxPrice      float64     `json:"tax_price"`
-	ShippingPrice float64     `json:"shipping_price"`
-	TotalPrice    float64     `json:"total_price"`
+	Items           []OrderItemReq `json:"items"`
+	PaymentMethod   string         `json:"payment_method"`
+	ShippingCountry string         `json:"shipping_country"`
+	ShippingRegion  string         `json:"shipping_region"`
+	ShippingMethod  string         `json:"shipping_method"`
+	CouponCode      string         `json:"coupon_code"`
+	ItemsPrice      money.Decimal  `json:"items_price"`
+	PromotionPrice  money.Decimal  `json:"promotion_price"`
+	DiscountPrice   money.Decimal  `json:"discount_price"`
+	TaxPrice        money.Decimal  `json:"tax_price"`
+	ShippingPrice   money.Decimal  `json:"shipping_price"`
+	TotalPrice      money.Decimal  `json:"total_price"`
+}
+
+// OrderItemReq is an item of an order or a shipping quote. Name and image
+// come from the product; a price, when sent, must match the product's.
+type OrderItemReq struct {
+	Name      string        `json:"name"`
+	Quantity  int           `json:"quantity"`
+	Image     string        `json:"image"`
+	Price     money.Decimal `json:"price"`
+	ProductID int           `json:"product_id"`
+	VariantID *int          `json:"variant_id,omitempty"`
 }
 
 type OrderItem struct {
-	Name      string  `json:"name"`
-	Quantity  int     `json:"quantity"`
-	Image     string  `json:"image"`
-	Price     float64 `json:"price"`
-	ProductID int     `json:"product_id"`
+	ID           int         `json:"id"`
+	OrderID      int         `json:"order_id"`
+	Name         string      `json:"name"`
+	SKU          string      `json:"sku,omitempty"`
+	Quantity     int         `json:"quantity"`
+	Image        string      `json:"image"`
+	Price        money.Money `json:"price"`
+	TaxRate      float64     `json:"tax_rate"`
+	TaxPrice     money.Money `json:"tax_price"`
+	TaxInclusive bool        `json:"tax_inclusive"`
+	ProductID    int         `json:"product_id"`
+	VariantID    *int        `json:"variant_id,omitempty"`
 }
 
 type OrderRes struct {
-	ID            int         `json:"id"`
-	Items         []OrderItem `json:"items"`
-	PaymentMethod string      `json:"payment_method"`
-	TaxPrice      float64     `json:"tax_price"`
-	ShippingPrice float64     `json:"shipping_price"`
-	TotalPrice    float64     `json:"total_price"`
-	CreatedAt     time.Time   `json:"created_at"`
-	UpdatedAt     *time.Time  `json:"updated_at"`
+	ID              int         `json:"id"`
+	Status          string      `json:"status"`
+	Items           []OrderItem `json:"items"`
+	PaymentMethod   string      `json:"payment_method"`
+	ShippingCountry string      `json:"shipping_country"`
+	ShippingRegion  string      `json:"shipping_region"`
+	ShippingMethod  string      `json:"shipping_method"`
+	CouponCode      string      `json:"coupon_code,omitempty"`
+	Currency        string      `json:"currency"`
+	ItemsPrice      money.Money `json:"items_price"`
+	PromotionPrice  money.Money `json:"promotion_price"`
+	DiscountPrice   money.Money `json:"discount_price"`
+	TaxPrice        money.Money `json:"tax_price"`
+	ShippingPrice   money.Money `json:"shipping_price"`
+	TotalPrice      money.Money `json:"total_price"`
+	CreatedAt       time.Time   `json:"created_at"`
+	UpdatedAt       *time.Time  `json:"updated_at"`
+}
+
+type ListOrderRes struct {
+	Orders     []OrderRes `json:"orders"`
+	NextCursor string     `json:"next_cursor,omitempty"`
+	HasMore    bool       `json:"has_more"`
+}
+
+type CancelOrderReq struct {
+	Reason string `json:"reason"`
+}
+
+type OrderStatusReq struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+type OrderStatusChangeRes struct {
+	FromStatus string    `json:"from_status"`
+	ToStatus   string    `json:"to_status"`
+	ChangedBy  int       `json:"changed_by"`
+	Reason     string    `json:"reason"`
+	CreatedAt  time.Time `json:"created_at"`
+}
+
+type ShippingQuoteReq struct {
+	Items           []OrderItemReq `json:"items"`
+	ShippingCountry string         `json:"shipping_country"`
+	ShippingRegion  string         `json:"shipping_region"`
+}
+
+type ShippingOptionRes struct {
+	Method string      `json:"method"`
+	Name   string      `json:"name"`
+	Zone   string      `json:"zone"`
+	Price  money.Money `json:"price"`
+	Days   string      `json:"days"`
+}
+
+type ShippingQuoteRes struct {
+	Currency string              `json:"currency"`
+	Options  []ShippingOptionRes `json:"options"`
 }
 
 type UserReq struct {
@@ -68,12 +262,17 @@ type UserRes struct {
 }
 
 type ListUserRes struct {
-	Users []UserRes `json:"users"`
+	Users      []UserRes `json:"users"`
+	NextCursor string    `json:"next_cursor,omitempty"`
+	HasMore    bool      `json:"has_more"`
 }
 
+// LoginUserReq signs a user in. CartToken, or else the X-Cart-Token header,
+// names a guest cart to merge into the user's cart.
 type LoginUserReq struct {
-	Email    string `json:"email"`
-	Password string `json:"password"`
+	Email     string `json:"email"`
+	Password  string `json:"password"`
+	CartToken string `json:"cart_token"`
 }
 
 type LoginUserRes struct {
@@ -83,6 +282,7 @@ type LoginUserRes struct {
 	AccessTokenExpiresAt  time.Time `json:"access_token_expires_at"`
 	RefreshTokenExpiresAt time.Time `json:"refresh_token_expires_at"`
 	User                  UserRes   `json:"user"`
+	Cart                  *CartRes  `json:"cart,omitempty"`
 }
 
 type RenewAccessTokenReq struct {
@@ -93,3 +293,273 @@ type RenewAccessTokenRes struct {
 	AccessToken          string    `json:"access_token"`
 	AccessTokenExpiresAt time.Time `json:"access_token_expires_at"`
 }
+
+type TaxRateReq struct {
+	Name      string   `json:"name"`
+	Country   string   `json:"country"`
+	Region    string   `json:"region"`
+	Category  string   `json:"category"`
+	Rate      *float64 `json:"rate"`
+	Inclusive *bool    `json:"inclusive"`
+}
+
+// CouponReq creates or patches a coupon. Value is a percentage for
+// percentage coupons and an amount for fixed ones. A usage_limit or
+// per_customer_limit of 0 means unlimited; empty category_ids and
+// product_ids let the coupon apply to every product.
+type CouponReq struct {
+	Code             string         `json:"code"`
+	Type             string         `json:"type"`
+	Value            *money.Decimal `json:"value"`
+	MinOrderValue    *money.Decimal `json:"min_order_value"`
+	StartsAt         *time.Time     `json:"starts_at"`
+	EndsAt           *time.Time     `json:"ends_at"`
+	UsageLimit       *int           `json:"usage_limit"`
+	PerCustomerLimit *int           `json:"per_customer_limit"`
+	CategoryIDs      *[]int         `json:"category_ids"`
+	ProductIDs       *[]int         `json:"product_ids"`
+}
+
+type CouponRes struct {
+	ID               int         `json:"id"`
+	Code             string      `json:"code"`
+	Type             string      `json:"type"`
+	Value            string      `json:"value"`
+	Currency         string      `json:"currency"`
+	MinOrderValue    money.Money `json:"min_order_value"`
+	StartsAt         *time.Time  `json:"starts_at"`
+	EndsAt           *time.Time  `json:"ends_at"`
+	UsageLimit       int         `json:"usage_limit"`
+	PerCustomerLimit int         `json:"per_customer_limit"`
+	TimesUsed        int         `json:"times_used"`
+	CategoryIDs      []int       `json:"category_ids"`
+	ProductIDs       []int       `json:"product_ids"`
+	CreatedAt        time.Time   `json:"created_at"`
+	UpdatedAt        *time.Time  `json:"updated_at"`
+}
+
+// PromotionReq creates or patches a promotion. Sales take percent off the
+// price; buy_x_get_y promotions give get_quantity units free for every
+// buy_quantity bought. Empty category_ids and product_ids let the promotion
+// apply to every product.
+type PromotionReq struct {
+	Name        string     `json:"name"`
+	Type        string     `json:"type"`
+	Percent     *float64   `json:"percent"`
+	BuyQuantity *int       `json:"buy_quantity"`
+	GetQuantity *int       `json:"get_quantity"`
+	StartsAt    *time.Time `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at"`
+	CategoryIDs *[]int     `json:"category_ids"`
+	ProductIDs  *[]int     `json:"product_ids"`
+}
+
+type PromotionRes struct {
+	ID          int        `json:"id"`
+	Name        string     `json:"name"`
+	Type        string     `json:"type"`
+	Percent     float64    `json:"percent"`
+	BuyQuantity int        `json:"buy_quantity"`
+	GetQuantity int        `json:"get_quantity"`
+	StartsAt    *time.Time `json:"starts_at"`
+	EndsAt      *time.Time `json:"ends_at"`
+	Running     bool       `json:"running"`
+	CategoryIDs []int      `json:"category_ids"`
+	ProductIDs  []int      `json:"product_ids"`
+	CreatedAt   time.Time  `json:"created_at"`
+	UpdatedAt   *time.Time `json:"updated_at"`
+}
+
+type TaxRateRes struct {
+	ID        int        `json:"id"`
+	Name      string     `json:"name"`
+	Country   string     `json:"country"`
+	Region    string     `json:"region"`
+	Category  string     `json:"category"`
+	Rate      float64    `json:"rate"`
+	Inclusive bool       `json:"inclusive"`
+	CreatedAt time.Time  `json:"created_at"`
+	UpdatedAt *time.Time `json:"updated_at"`
+}
+
+// CategoryReq creates or patches a category. In a patch, a parent_id of 0
+// moves the category to the top level.
+type CategoryReq struct {
+	Name      string `json:"name"`
+	Slug      string `json:"slug"`
+	ParentID  *int   `json:"parent_id"`
+	SortOrder *int   `json:"sort_order"`
+}
+
+type CategoryRes struct {
+	ID        int           `json:"id"`
+	ParentID  *int          `json:"parent_id"`
+	Name      string        `json:"name"`
+	Slug      string        `json:"slug"`
+	Path      string        `json:"path"`
+	SortOrder int           `json:"sort_order"`
+	Children  []CategoryRes `json:"children,omitempty"`
+	CreatedAt time.Time     `json:"created_at"`
+	UpdatedAt *time.Time    `json:"updated_at"`
+}
+
+// ReviewReq creates or patches a review. Zero values leave a field unchanged
+// when patching.
+type ReviewReq struct {
+	Rating int    `json:"rating"`
+	Title  string `json:"title"`
+	Body   string `json:"body"`
+}
+
+type ReviewRes struct {
+	ID               int        `json:"id"`
+	ProductID        int        `json:"product_id"`
+	UserID           int        `json:"user_id"`
+	UserName         string     `json:"user_name"`
+	Rating           int        `json:"rating"`
+	Title            string     `json:"title"`
+	Body             string     `json:"body"`
+	Status           string     `json:"status"`
+	ModerationReason string     `json:"moderation_reason,omitempty"`
+	ModeratedAt      *time.Time `json:"moderated_at,omitempty"`
+	CreatedAt        time.Time  `json:"created_at"`
+	UpdatedAt        *time.Time `json:"updated_at"`
+}
+
+type ListReviewRes struct {
+	Reviews    []ReviewRes `json:"reviews"`
+	Sort       string      `json:"sort,omitempty"`
+	NextCursor string      `json:"next_cursor,omitempty"`
+	HasMore    bool        `json:"has_more"`
+}
+
+// ModerateReviewReq approves or rejects one review; ModerateReviewsReq does
+// the same for several reviews at once. Rejections need a reason.
+type ModerateReviewReq struct {
+	Status string `json:"status"`
+	Reason string `json:"reason"`
+}
+
+type ModerateReviewsReq struct {
+	ReviewIDs []int  `json:"review_ids"`
+	Status    string `json:"status"`
+	Reason    string `json:"reason"`
+}
+
+type CartItemReq struct {
+	ProductID int  `json:"product_id"`
+	VariantID *int `json:"variant_id"`
+	Quantity  int  `json:"quantity"`
+}
+
+type UpdateCartItemReq struct {
+	Quantity int `json:"quantity"`
+}
+
+// CartRes shows a cart at the current catalog prices, after any running
+// sale. promotion_price is what buy X get Y promotions take off items_price.
+// A line is not available when its quantity is more than is in stock. Token
+// is only set on guest carts; the client sends it back in the X-Cart-Token
+// header.
+type CartRes struct {
+	ID             int           `json:"id"`
+	Token          string        `json:"token,omitempty"`
+	Items          []CartItemRes `json:"items"`
+	ItemCount      int           `json:"item_count"`
+	Currency       string        `json:"currency"`
+	ItemsPrice     money.Money   `json:"items_price"`
+	PromotionPrice money.Money   `json:"promotion_price"`
+	UpdatedAt      *time.Time    `json:"updated_at"`
+}
+
+type CartItemRes struct {
+	ID           int         `json:"id"`
+	ProductID    int         `json:"product_id"`
+	VariantID    *int        `json:"variant_id,omitempty"`
+	SKU          string      `json:"sku,omitempty"`
+	Name         string      `json:"name"`
+	Image        string      `json:"image"`
+	Price        money.Money `json:"price"`
+	Quantity     int         `json:"quantity"`
+	LineTotal    money.Money `json:"line_total"`
+	Discount     money.Money `json:"discount"`
+	CountInStock int         `json:"count_in_stock"`
+	Available    bool        `json:"available"`
+}
+
+// CheckoutReq carries the payment and shipping details of an order placed
+// from the cart. The totals are optional; when sent they must match.
+type CheckoutReq struct {
+	PaymentMethod   string        `json:"payment_method"`
+	ShippingCountry string        `json:"shipping_country"`
+	ShippingRegion  string        `json:"shipping_region"`
+	ShippingMethod  string        `json:"shipping_method"`
+	CouponCode      string        `json:"coupon_code"`
+	ItemsPrice      money.Decimal `json:"items_price"`
+	PromotionPrice  money.Decimal `json:"promotion_price"`
+	DiscountPrice   money.Decimal `json:"discount_price"`
+	TaxPrice        money.Decimal `json:"tax_price"`
+	ShippingPrice   money.Decimal `json:"shipping_price"`
+	TotalPrice      money.Decimal `json:"total_price"`
+}
+
+type WishlistReq struct {
+	Name string `json:"name"`
+}
+
+type WishlistItemReq struct {
+	ProductID int  `json:"product_id"`
+	VariantID *int `json:"variant_id"`
+}
+
+// MoveWishlistItemReq moves a wishlist item to the cart. Quantity defaults to
+// one; VariantID picks the variant for an item saved without one.
+type MoveWishlistItemReq struct {
+	Quantity  int  `json:"quantity"`
+	VariantID *int `json:"variant_id"`
+}
+
+// WishlistRes shows a wishlist of the signed-in user. ShareURL is set while
+// the wishlist is shared.
+type WishlistRes struct {
+	ID        int               `json:"id"`
+	Name      string            `json:"name"`
+	Currency  string            `json:"currency"`
+	Items     []WishlistItemRes `json:"items"`
+	ShareURL  string            `json:"share_url,omitempty"`
+	CreatedAt time.Time         `json:"created_at"`
+	UpdatedAt *time.Time        `json:"updated_at"`
+}
+
+// SharedWishlistRes is the read-only view of a wishlist behind a share link.
+type SharedWishlistRes struct {
+	Name      string            `json:"name"`
+	Currency  string            `json:"currency"`
+	Items     []WishlistItemRes `json:"items"`
+	UpdatedAt *time.Time        `json:"updated_at"`
+}
+
+type WishlistItemRes struct {
+	ID           int         `json:"id"`
+	ProductID    int         `json:"product_id"`
+	VariantID    *int        `json:"variant_id,omitempty"`
+	SKU          string      `json:"sku,omitempty"`
+	Name         string      `json:"name"`
+	Image        string      `json:"image"`
+	Price        money.Money `json:"price"`
+	CountInStock int         `json:"count_in_stock"`
+	AddedAt      time.Time   `json:"added_at"`
+}
+
+type WishlistedProductRes struct {
+	ProductID int    `json:"product_id"`
+	Name      string `json:"name"`
+	Wishlists int    `json:"wishlists"`
+	Users     int    `json:"users"`
+}
+
+type ListWishlistedProductRes struct {
+	Products   []WishlistedProductRes `json:"products"`
+	NextCursor string                 `json:"next_cursor,omitempty"`
+	HasMore    bool                   `json:"has_more"`
+}
@@ -0,0 +1,252 @@
+package handler
+
+import (
+	"database/sql"
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/server"
+	"ecom_apiv1/internal/storer"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+)
+
+// setProductOptions replaces the options of a product with the ones in the
+// body, in that order.
+func (h *handler) setProductOptions(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req []ProductOptionReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	options := make([]storer.ProductOption, len(req))
+	for i, o := range req {
+		options[i] = storer.ProductOption{
+			Name:    strings.TrimSpace(o.Name),
+			Choices: o.Choices,
+		}
+	}
+	options, err = h.server.SetProductOptions(h.Ctx, id, options)
+	if err != nil {
+		if errors.Is(err, server.ErrInvalidVariant) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if errors.Is(err, sql.ErrNoRows) {
+			http.Error(w, "product not found", http.StatusNotFound)
+			return
+		}
+		http.Error(w, "error setting product options", http.StatusInternalServerError)
+		return
+	}
+	res := []ProductOptionRes{}
+	for _, o := range options {
+		res = append(res, ProductOptionRes{Name: o.Name, Choices: o.Choices})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) createVariant(w http.ResponseWriter, r *http.Request) {
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req VariantReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	v := &storer.ProductVariant{ProductID: id}
+	if err := patchVariantReq(v, req, h.server.Currency()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateVariant(v); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	v.UpdatedAt = nil
+
+	created, err := h.server.CreateVariant(h.Ctx, v)
+	if err != nil {
+		writeVariantError(w, err, "error creating variant")
+		return
+	}
+	h.writeVariant(w, created)
+}
+
+func (h *handler) updateVariant(w http.ResponseWriter, r *http.Request) {
+	v, ok := h.productVariant(w, r)
+	if !ok {
+		return
+	}
+	var req VariantReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+	if err := patchVariantReq(v, req, h.server.Currency()); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+	if err := validateVariant(v); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	updated, err := h.server.UpdateVariant(h.Ctx, v)
+	if err != nil {
+		writeVariantError(w, err, "error updating variant")
+		return
+	}
+	h.writeVariant(w, updated)
+}
+
+func (h *handler) deleteVariant(w http.ResponseWriter, r *http.Request) {
+	v, ok := h.productVariant(w, r)
+	if !ok {
+		return
+	}
+	err := h.server.DeleteVariant(h.Ctx, v.ID)
+	if err != nil {
+		http.Error(w, "error deleting variant", http.StatusInternalServerError)
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// productVariant loads the variant named in the URL and makes sure it belongs
+// to the product in the URL. It writes the error response itself.
+func (h *handler) productVariant(w http.ResponseWriter, r *http.Request) (*storer.ProductVariant, bool) {
+	vars := mux.Vars(r)
+	productID, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return nil, false
+	}
+	variantID, err := strconv.Atoi(vars["variantID"])
+	if err != nil {
+		http.Error(w, "error parsing variant id", http.StatusBadRequest)
+		return nil, false
+	}
+	v, err := h.server.GetVariant(h.Ctx, variantID)
+	if err != nil || v.ProductID != productID {
+		http.Error(w, "variant not found", http.StatusNotFound)
+		return nil, false
+	}
+	return v, true
+}
+
+func (h *handler) writeVariant(w http.ResponseWriter, v *storer.ProductVariant) {
+	p, err := h.server.GetProduct(h.Ctx, v.ProductID)
+	if err != nil {
+		http.Error(w, "error getting product", http.StatusInternalServerError)
+		return
+	}
+	price, err := h.server.PriceProduct(h.Ctx, p)
+	if err != nil {
+		http.Error(w, "error pricing product", http.StatusInternalServerError)
+		return
+	}
+	res := toProductVariantRes(p, v, price.VariantSalePrices[v.ID], price.Currency)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func writeVariantError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, server.ErrInvalidVariant):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, storer.ErrDuplicateSKU):
+		http.Error(w, storer.ErrDuplicateSKU.Error(), http.StatusConflict)
+	default:
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
+
+func patchVariantReq(v *storer.ProductVariant, req VariantReq, c money.Currency) error {
+	if req.SKU != "" {
+		v.SKU = strings.TrimSpace(req.SKU)
+	}
+	if req.Options != nil {
+		v.OptionValues = req.Options
+	}
+	if req.Price != nil {
+		var price money.Amount
+		if err := parseAmounts(c, amountField{"price", *req.Price, &price}); err != nil {
+			return err
+		}
+		if price == 0 {
+			v.Price = nil
+		} else {
+			v.Price = &price
+		}
+	}
+	if req.Image != "" {
+		v.Image = req.Image
+	}
+	if req.CountInStock != nil {
+		v.CountInStock = *req.CountInStock
+	}
+	v.UpdatedAt = toTimePtr(time.Now())
+	return nil
+}
+
+func validateVariant(v *storer.ProductVariant) error {
+	if v.SKU == "" {
+		return fmt.Errorf("sku is required")
+	}
+	if len(v.SKU) > 64 {
+		return fmt.Errorf("sku must be at most 64 characters")
+	}
+	if v.Price != nil && *v.Price < 0 {
+		return fmt.Errorf("price must not be negative")
+	}
+	if v.CountInStock < 0 {
+		return fmt.Errorf("count_in_stock must not be negative")
+	}
+	return nil
+}
+
+func toProductVariantRes(p *storer.Product, v *storer.ProductVariant, salePrice money.Amount, c money.Currency) ProductVariantRes {
+	res := ProductVariantRes{
+		ID:           v.ID,
+		SKU:          v.SKU,
+		Options:      v.OptionValues,
+		Price:        money.New(p.Price, c),
+		SalePrice:    money.New(salePrice, c),
+		Image:        server.ProductImageURL(p),
+		CountInStock: v.CountInStock,
+	}
+	if res.Options == nil {
+		res.Options = map[string]string{}
+	}
+	if v.Price != nil {
+		res.Price = money.New(*v.Price, c)
+	}
+	if v.Image != "" {
+		res.Image = v.Image
+	}
+	return res
+}
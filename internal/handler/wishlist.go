@@ -0,0 +1,334 @@
+package handler
+
+import (
+	"database/sql"
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/server"
+	"ecom_apiv1/internal/storer"
+	"ecom_apiv1/token"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+
+	"github.com/gorilla/mux"
+)
+
+func (h *handler) listWishlists(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	wishlists, err := h.server.ListWishlists(h.Ctx, claims.ID)
+	if err != nil {
+		http.Error(w, "error listing wishlists", http.StatusInternalServerError)
+		return
+	}
+	res := []WishlistRes{}
+	for _, wl := range wishlists {
+		res = append(res, toWishlistRes(&wl))
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+func (h *handler) createWishlist(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	var req WishlistReq
+	err := json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	created, err := h.server.CreateWishlist(h.Ctx, claims.ID, req.Name)
+	if err != nil {
+		writeWishlistError(w, err, "error creating wishlist")
+		return
+	}
+	writeWishlist(w, created, http.StatusCreated)
+}
+
+func (h *handler) getWishlist(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+
+	wl, err := h.server.GetWishlist(h.Ctx, claims.ID, id)
+	if err != nil {
+		writeWishlistError(w, err, "error getting wishlist")
+		return
+	}
+	writeWishlist(w, wl, http.StatusOK)
+}
+
+func (h *handler) renameWishlist(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req WishlistReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	wl, err := h.server.RenameWishlist(h.Ctx, claims.ID, id, req.Name)
+	if err != nil {
+		writeWishlistError(w, err, "error updating wishlist")
+		return
+	}
+	writeWishlist(w, wl, http.StatusOK)
+}
+
+func (h *handler) deleteWishlist(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+
+	if err := h.server.DeleteWishlist(h.Ctx, claims.ID, id); err != nil {
+		writeWishlistError(w, err, "error deleting wishlist")
+		return
+	}
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *handler) addWishlistItem(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+	var req WishlistItemReq
+	err = json.NewDecoder(r.Body).Decode(&req)
+	if err != nil {
+		http.Error(w, "error decoding request body", http.StatusBadRequest)
+		return
+	}
+
+	item := &storer.WishlistItem{
+		WishlistID: id,
+		ProductID:  req.ProductID,
+		VariantID:  req.VariantID,
+	}
+	wl, err := h.server.AddWishlistItem(h.Ctx, claims.ID, item)
+	if err != nil {
+		writeWishlistError(w, err, "error adding wishlist item")
+		return
+	}
+	writeWishlist(w, wl, http.StatusOK)
+}
+
+func (h *handler) deleteWishlistItem(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	id, itemID, ok := wishlistItemIDs(w, r)
+	if !ok {
+		return
+	}
+
+	wl, err := h.server.RemoveWishlistItem(h.Ctx, claims.ID, id, itemID)
+	if err != nil {
+		writeWishlistError(w, err, "error deleting wishlist item")
+		return
+	}
+	writeWishlist(w, wl, http.StatusOK)
+}
+
+// moveWishlistItemToCart adds a wishlist item to the cart and takes it off
+// the wishlist. It responds with the cart.
+func (h *handler) moveWishlistItemToCart(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	id, itemID, ok := wishlistItemIDs(w, r)
+	if !ok {
+		return
+	}
+	req := MoveWishlistItemReq{Quantity: 1}
+	if r.ContentLength != 0 {
+		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+			http.Error(w, "error decoding request body", http.StatusBadRequest)
+			return
+		}
+	}
+
+	c, err := h.server.MoveWishlistItemToCart(h.Ctx, claims.ID, id, itemID, req.Quantity, req.VariantID)
+	if err != nil {
+		if errors.Is(err, server.ErrInvalidCart) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		writeWishlistError(w, err, "error moving wishlist item to cart")
+		return
+	}
+	writeCart(w, c)
+}
+
+func (h *handler) shareWishlist(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+
+	wl, err := h.server.ShareWishlist(h.Ctx, claims.ID, id)
+	if err != nil {
+		writeWishlistError(w, err, "error sharing wishlist")
+		return
+	}
+	writeWishlist(w, wl, http.StatusOK)
+}
+
+func (h *handler) unshareWishlist(w http.ResponseWriter, r *http.Request) {
+	claims := r.Context().Value(authKey{}).(*token.UserClaims)
+	id, err := strconv.Atoi(mux.Vars(r)["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return
+	}
+
+	wl, err := h.server.UnshareWishlist(h.Ctx, claims.ID, id)
+	if err != nil {
+		writeWishlistError(w, err, "error unsharing wishlist")
+		return
+	}
+	writeWishlist(w, wl, http.StatusOK)
+}
+
+// getSharedWishlist shows a shared wishlist to anyone with its link.
+func (h *handler) getSharedWishlist(w http.ResponseWriter, r *http.Request) {
+	wl, err := h.server.GetSharedWishlist(h.Ctx, mux.Vars(r)["token"])
+	if err != nil {
+		writeWishlistError(w, err, "error getting wishlist")
+		return
+	}
+	res := SharedWishlistRes{
+		Name:      wl.Name,
+		Currency:  string(wl.Currency),
+		Items:     toWishlistItemsRes(wl),
+		UpdatedAt: wl.UpdatedAt,
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+// listWishlistedProducts shows how often products are wishlisted, most
+// wishlisted first.
+func (h *handler) listWishlistedProducts(w http.ResponseWriter, r *http.Request) {
+	page, err := parsePage(r)
+	if err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	products, next, err := h.server.ListWishlistedProducts(h.Ctx, page)
+	if err != nil {
+		if errors.Is(err, storer.ErrInvalidCursor) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		http.Error(w, "error listing wishlisted products", http.StatusInternalServerError)
+		return
+	}
+	res := ListWishlistedProductRes{
+		Products:   []WishlistedProductRes{},
+		NextCursor: encodeCursor(next),
+		HasMore:    next != nil,
+	}
+	for _, p := range products {
+		res.Products = append(res.Products, WishlistedProductRes{
+			ProductID: p.ProductID,
+			Name:      p.Name,
+			Wishlists: p.Wishlists,
+			Users:     p.Users,
+		})
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(res)
+}
+
+// wishlistItemIDs parses the wishlist and item IDs in the URL. It writes the
+// error response itself.
+func wishlistItemIDs(w http.ResponseWriter, r *http.Request) (int, int, bool) {
+	vars := mux.Vars(r)
+	id, err := strconv.Atoi(vars["id"])
+	if err != nil {
+		http.Error(w, "error parsing id", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	itemID, err := strconv.Atoi(vars["itemID"])
+	if err != nil {
+		http.Error(w, "error parsing item id", http.StatusBadRequest)
+		return 0, 0, false
+	}
+	return id, itemID, true
+}
+
+func writeWishlist(w http.ResponseWriter, wl *server.DetailedWishlist, status int) {
+	res := toWishlistRes(wl)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(res)
+}
+
+func writeWishlistError(w http.ResponseWriter, err error, msg string) {
+	switch {
+	case errors.Is(err, server.ErrInvalidWishlist):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, sql.ErrNoRows):
+		http.Error(w, "wishlist not found", http.StatusNotFound)
+	default:
+		http.Error(w, msg, http.StatusInternalServerError)
+	}
+}
+
+// sharedWishlistURL is the public path of a wishlist shared with token.
+func sharedWishlistURL(token string) string {
+	return "/shared/wishlists/" + token
+}
+
+func toWishlistRes(wl *server.DetailedWishlist) WishlistRes {
+	res := WishlistRes{
+		ID:        wl.ID,
+		Name:      wl.Name,
+		Currency:  string(wl.Currency),
+		Items:     toWishlistItemsRes(wl),
+		CreatedAt: wl.CreatedAt,
+		UpdatedAt: wl.UpdatedAt,
+	}
+	if wl.ShareToken != nil {
+		res.ShareURL = sharedWishlistURL(*wl.ShareToken)
+	}
+	return res
+}
+
+func toWishlistItemsRes(wl *server.DetailedWishlist) []WishlistItemRes {
+	res := []WishlistItemRes{}
+	for _, l := range wl.Lines {
+		res = append(res, WishlistItemRes{
+			ID:           l.ID,
+			ProductID:    l.ProductID,
+			VariantID:    l.VariantID,
+			SKU:          l.SKU,
+			Name:         l.Name,
+			Image:        l.Image,
+			Price:        money.New(l.Price, wl.Currency),
+			CountInStock: l.InStock,
+			AddedAt:      l.CreatedAt,
+		})
+	}
+	return res
+}
@@ -0,0 +1,129 @@
+// Package imaging decodes uploaded images and makes thumbnails using only
+// the standard library.
+package imaging
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	_ "image/gif"
+	"image/jpeg"
+	"image/png"
+	"io"
+	"net/http"
+)
+
+// MaxPixels bounds the decoded size of an upload, so a small compressed file
+// cannot expand into gigabytes of pixels.
+const MaxPixels = 40_000_000
+
+// ErrUnsupported is returned for data that is not a JPEG, PNG or GIF image.
+var ErrUnsupported = errors.New("unsupported image type")
+
+// Format is a supported image format.
+type Format struct {
+	ContentType string
+	Ext         string
+}
+
+var (
+	JPEG = Format{ContentType: "image/jpeg", Ext: "jpg"}
+	PNG  = Format{ContentType: "image/png", Ext: "png"}
+	GIF  = Format{ContentType: "image/gif", Ext: "gif"}
+)
+
+// DetectFormat sniffs the format from the first bytes of data.
+func DetectFormat(data []byte) (Format, error) {
+	switch http.DetectContentType(data) {
+	case JPEG.ContentType:
+		return JPEG, nil
+	case PNG.ContentType:
+		return PNG, nil
+	case GIF.ContentType:
+		return GIF, nil
+	}
+	return Format{}, ErrUnsupported
+}
+
+// Decode decodes data after checking that its dimensions are within
+// MaxPixels.
+func Decode(data []byte) (image.Image, error) {
+	cfg, _, err := image.DecodeConfig(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	if cfg.Width <= 0 || cfg.Height <= 0 || cfg.Width*cfg.Height > MaxPixels {
+		return nil, fmt.Errorf("image of %dx%d pixels is too large", cfg.Width, cfg.Height)
+	}
+	img, _, err := image.Decode(bytes.NewReader(data))
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrUnsupported, err)
+	}
+	return img, nil
+}
+
+// Thumbnail scales img down to fit within a size x size box, keeping its
+// aspect ratio. Every output pixel is the average of the source pixels it
+// covers. Images that already fit are returned unchanged.
+func Thumbnail(img image.Image, size int) image.Image {
+	b := img.Bounds()
+	sw, sh := b.Dx(), b.Dy()
+	if sw <= size && sh <= size {
+		return img
+	}
+	dw, dh := size, size
+	if sw > sh {
+		dh = max(1, sh*size/sw)
+	} else {
+		dw = max(1, sw*size/sh)
+	}
+
+	src := image.NewRGBA(image.Rect(0, 0, sw, sh))
+	draw.Draw(src, src.Bounds(), img, b.Min, draw.Src)
+	dst := image.NewRGBA(image.Rect(0, 0, dw, dh))
+	for dy := 0; dy < dh; dy++ {
+		y0, y1 := dy*sh/dh, (dy+1)*sh/dh
+		for dx := 0; dx < dw; dx++ {
+			x0, x1 := dx*sw/dw, (dx+1)*sw/dw
+			var r, g, bl, a, n uint32
+			for y := y0; y < y1; y++ {
+				row := src.Pix[y*src.Stride+x0*4 : y*src.Stride+x1*4]
+				for i := 0; i < len(row); i += 4 {
+					r += uint32(row[i])
+					g += uint32(row[i+1])
+					bl += uint32(row[i+2])
+					a += uint32(row[i+3])
+					n++
+				}
+			}
+			o := dy*dst.Stride + dx*4
+			dst.Pix[o] = uint8(r / n)
+			dst.Pix[o+1] = uint8(g / n)
+			dst.Pix[o+2] = uint8(bl / n)
+			dst.Pix[o+3] = uint8(a / n)
+		}
+	}
+	return dst
+}
+
+// ThumbnailFormat is the format thumbnails of an f image are stored in: JPEG
+// for photos, PNG for everything else so transparency survives.
+func ThumbnailFormat(f Format) Format {
+	if f == JPEG {
+		return JPEG
+	}
+	return PNG
+}
+
+// Encode writes img as a JPEG (quality 85) or PNG.
+func Encode(w io.Writer, img image.Image, f Format) error {
+	switch f {
+	case JPEG:
+		return jpeg.Encode(w, img, &jpeg.Options{Quality: 85})
+	case PNG:
+		return png.Encode(w, img)
+	}
+	return ErrUnsupported
+}
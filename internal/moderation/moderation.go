@@ -0,0 +1,89 @@
+// Package moderation screens user-written text, such as product reviews,
+// before it is published.
+package moderation
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"strings"
+	"unicode"
+)
+
+// Filter decides whether text has to be checked by a moderator before it is
+// published. Match returns the banned terms found in texts, or nil.
+type Filter interface {
+	Match(texts ...string) []string
+}
+
+// DefaultBannedWords holds terms that commonly show up in spam reviews.
+var DefaultBannedWords = []string{
+	"casino",
+	"viagra",
+	"crypto giveaway",
+	"click here",
+	"free money",
+	"whatsapp me",
+}
+
+// LoadWords reads banned terms from a text file with one term per line.
+// Empty lines and lines starting with "#" are skipped.
+func LoadWords(path string) ([]string, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading banned words: %w", err)
+	}
+	defer f.Close()
+
+	var words []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+		words = append(words, line)
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("error reading banned words: %w", err)
+	}
+	return words, nil
+}
+
+// WordFilter matches whole words and phrases, ignoring case and punctuation,
+// so "Casino!" matches "casino" but "occasional" does not.
+type WordFilter struct {
+	terms []string
+}
+
+func NewWordFilter(words []string) *WordFilter {
+	f := &WordFilter{}
+	for _, w := range words {
+		if t := normalize(w); t != "" {
+			f.terms = append(f.terms, t)
+		}
+	}
+	return f
+}
+
+func (f *WordFilter) Match(texts ...string) []string {
+	var found []string
+	for _, term := range f.terms {
+		for _, text := range texts {
+			if strings.Contains(" "+normalize(text)+" ", " "+term+" ") {
+				found = append(found, term)
+				break
+			}
+		}
+	}
+	return found
+}
+
+// normalize lower-cases s and reduces it to its words separated by single
+// spaces.
+func normalize(s string) string {
+	words := strings.FieldsFunc(strings.ToLower(s), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+	return strings.Join(words, " ")
+}
@@ -0,0 +1,263 @@
+// Package money holds sums of money exactly, as integer minor units of a
+// currency, such as cents of a US dollar.
+//
+// Arithmetic that cannot stay exact rounds to the nearest minor unit, halves
+// away from zero:
+//
+//   - Percentages, such as sales and percentage coupons, take
+//     Amount.Percent of the price.
+//   - Tax added on top of a price is Amount.Ratio(rate, 1); tax included in a
+//     price is Amount.Ratio(rate, 1+rate). Tax is rounded per line.
+//   - A discount on several lines is split with Amount.Allocate, so the
+//     parts always add up to the discount.
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidAmount is returned for decimals that are not a valid amount of
+// a currency.
+var ErrInvalidAmount = errors.New("invalid amount")
+
+// Currency is an ISO 4217 currency code.
+type Currency string
+
+// DefaultCurrency is the currency of stores that do not configure one. The
+// migration to minor units assumed it for existing prices.
+const DefaultCurrency Currency = "USD"
+
+var currencyPattern = regexp.MustCompile(`^[A-Z]{3}$`)
+
+// minorDigits lists the currencies whose minor unit is not a hundredth.
+var minorDigits = map[Currency]int{
+	"BIF": 0, "CLP": 0, "DJF": 0, "GNF": 0, "ISK": 0, "JPY": 0, "KMF": 0,
+	"KRW": 0, "PYG": 0, "RWF": 0, "UGX": 0, "VND": 0, "VUV": 0, "XAF": 0,
+	"XOF": 0, "XPF": 0,
+	"BHD": 3, "IQD": 3, "JOD": 3, "KWD": 3, "LYD": 3, "OMR": 3, "TND": 3,
+}
+
+// ParseCurrency parses a currency code, in any case.
+func ParseCurrency(s string) (Currency, error) {
+	c := Currency(strings.ToUpper(strings.TrimSpace(s)))
+	if !currencyPattern.MatchString(string(c)) {
+		return "", fmt.Errorf("invalid currency %q", s)
+	}
+	return c, nil
+}
+
+// Digits returns the number of decimal digits of the minor unit of c.
+func (c Currency) Digits() int {
+	if d, ok := minorDigits[c]; ok {
+		return d
+	}
+	return 2
+}
+
+// Format writes a as a decimal in units of c, such as "12.30".
+func (c Currency) Format(a Amount) string {
+	digits := c.Digits()
+	s := strconv.FormatInt(int64(a), 10)
+	sign := ""
+	if a < 0 {
+		sign, s = "-", s[1:]
+	}
+	if digits == 0 {
+		return sign + s
+	}
+	if len(s) <= digits {
+		s = strings.Repeat("0", digits-len(s)+1) + s
+	}
+	return sign + s[:len(s)-digits] + "." + s[len(s)-digits:]
+}
+
+// Parse reads a decimal in units of c, such as "12.3", into an Amount. It
+// fails rather than round when s has more decimals than c has minor digits,
+// unless the extra digits are zeros.
+func (c Currency) Parse(s string) (Amount, error) {
+	digits := c.Digits()
+	num := strings.TrimSpace(s)
+	neg := strings.HasPrefix(num, "-")
+	num = strings.TrimPrefix(num, "-")
+	whole, frac, _ := strings.Cut(num, ".")
+	if whole == "" || !allDigits(whole) || !allDigits(frac) || (strings.Contains(num, ".") && frac == "") {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if len(frac) > digits {
+		if strings.Trim(frac[digits:], "0") != "" {
+			return 0, fmt.Errorf("%w: %q has more than %d decimals for %s", ErrInvalidAmount, s, digits, c)
+		}
+		frac = frac[:digits]
+	}
+	frac += strings.Repeat("0", digits-len(frac))
+	v, err := strconv.ParseInt(whole+frac, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w: %q", ErrInvalidAmount, s)
+	}
+	if neg {
+		v = -v
+	}
+	return Amount(v), nil
+}
+
+func allDigits(s string) bool {
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// Amount is a sum of money in the minor unit of its currency. Which currency
+// that is lives next to the amount: on the order, the cart or the store.
+type Amount int64
+
+// Sum adds up amounts.
+func Sum(amounts []Amount) Amount {
+	var sum Amount
+	for _, a := range amounts {
+		sum += a
+	}
+	return sum
+}
+
+// Times returns a multiplied by a quantity.
+func (a Amount) Times(n int) Amount {
+	return a * Amount(n)
+}
+
+// Percent returns p percent of a, rounded to the nearest minor unit.
+func (a Amount) Percent(p float64) Amount {
+	return a.Ratio(p, 100)
+}
+
+// Ratio returns a times num divided by den, rounded to the nearest minor
+// unit, halves away from zero. num and den are taken to six decimal places,
+// which covers every percentage and tax rate the store keeps.
+func (a Amount) Ratio(num, den float64) Amount {
+	n := big.NewInt(int64(math.Round(num * 1e6)))
+	d := big.NewInt(int64(math.Round(den * 1e6)))
+	if d.Sign() == 0 {
+		return 0
+	}
+	n.Mul(n, big.NewInt(int64(a)))
+	return divRound(n, d)
+}
+
+// divRound divides n by d, rounding halves away from zero.
+func divRound(n, d *big.Int) Amount {
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	r.Abs(r).Lsh(r, 1)
+	if r.CmpAbs(d) >= 0 {
+		if n.Sign()*d.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return Amount(q.Int64())
+}
+
+// Allocate splits a over parts in proportion to weights. Every part is
+// rounded down, then the minor units left over go one each to the parts that
+// lost the most to rounding, earlier parts first on a tie. The parts add up
+// to a exactly, and parts with a zero weight get nothing. a and the weights
+// must not be negative.
+func (a Amount) Allocate(weights []Amount) []Amount {
+	parts := make([]Amount, len(weights))
+	total := Sum(weights)
+	if total <= 0 || a <= 0 {
+		return parts
+	}
+	remainders := make([]*big.Int, len(weights))
+	left := a
+	bigA, bigTotal := big.NewInt(int64(a)), big.NewInt(int64(total))
+	for i, w := range weights {
+		n := new(big.Int).Mul(bigA, big.NewInt(int64(w)))
+		q, r := n.QuoRem(n, bigTotal, new(big.Int))
+		parts[i] = Amount(q.Int64())
+		remainders[i] = r
+		left -= parts[i]
+	}
+	for ; left > 0; left-- {
+		best := -1
+		for i, r := range remainders {
+			if weights[i] > 0 && (best < 0 || r.Cmp(remainders[best]) > 0) {
+				best = i
+			}
+		}
+		parts[best]++
+		remainders[best] = big.NewInt(-1)
+	}
+	return parts
+}
+
+// Money is an Amount in a currency. It encodes to JSON as a decimal string
+// in units of the currency, such as "12.30", so clients never see floating
+// point prices.
+type Money struct {
+	Amount   Amount
+	Currency Currency
+}
+
+func New(a Amount, c Currency) Money {
+	return Money{Amount: a, Currency: c}
+}
+
+// String returns m as a decimal followed by the currency code, such as
+// "12.30 USD".
+func (m Money) String() string {
+	return m.Currency.Format(m.Amount) + " " + string(m.Currency)
+}
+
+func (m Money) MarshalJSON() ([]byte, error) {
+	return json.Marshal(m.Currency.Format(m.Amount))
+}
+
+// Decimal is a decimal number as a client sent it, either as a JSON string
+// such as "12.30" or as a JSON number such as 12.3. The text is kept as it
+// was, so no precision is lost before it is parsed in a currency.
+type Decimal string
+
+func (d *Decimal) UnmarshalJSON(b []byte) error {
+	if string(b) == "null" {
+		return nil
+	}
+	if len(b) > 0 && b[0] == '"' {
+		var s string
+		if err := json.Unmarshal(b, &s); err != nil {
+			return err
+		}
+		*d = Decimal(s)
+		return nil
+	}
+	var n json.Number
+	if err := json.Unmarshal(b, &n); err != nil {
+		return fmt.Errorf("%w: %s", ErrInvalidAmount, b)
+	}
+	*d = Decimal(n)
+	return nil
+}
+
+// Amount parses d in units of c.
+func (d Decimal) Amount(c Currency) (Amount, error) {
+	return c.Parse(string(d))
+}
+
+// Float parses d as a plain number, for values such as percentages that are
+// not money.
+func (d Decimal) Float() (float64, error) {
+	f, err := strconv.ParseFloat(strings.TrimSpace(string(d)), 64)
+	if err != nil {
+		return 0, fmt.Errorf("invalid number %q", string(d))
+	}
+	return f, nil
+}
@@ -0,0 +1,155 @@
+package money
+
+import (
+	"encoding/json"
+	"errors"
+	"slices"
+	"testing"
+)
+
+func TestFormat(t *testing.T) {
+	tests := []struct {
+		c    Currency
+		a    Amount
+		want string
+	}{
+		{"USD", 1230, "12.30"},
+		{"USD", 5, "0.05"},
+		{"USD", 0, "0.00"},
+		{"USD", -5, "-0.05"},
+		{"USD", -123456, "-1234.56"},
+		{"JPY", 1500, "1500"},
+		{"KWD", 1234, "1.234"},
+	}
+	for _, tt := range tests {
+		if got := tt.c.Format(tt.a); got != tt.want {
+			t.Errorf("%s.Format(%d) = %q, want %q", tt.c, tt.a, got, tt.want)
+		}
+	}
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		c       Currency
+		s       string
+		want    Amount
+		wantErr bool
+	}{
+		{"USD", "12.3", 1230, false},
+		{"USD", "12", 1200, false},
+		{"USD", "0.05", 5, false},
+		{"USD", " 7.10 ", 710, false},
+		{"USD", "-1.5", -150, false},
+		{"USD", "12.300", 1230, false},
+		{"USD", "12.345", 0, true},
+		{"USD", "", 0, true},
+		{"USD", "1.", 0, true},
+		{"USD", ".5", 0, true},
+		{"USD", "1,00", 0, true},
+		{"USD", "1e3", 0, true},
+		{"JPY", "12", 12, false},
+		{"JPY", "12.5", 0, true},
+		{"KWD", "1.234", 1234, false},
+	}
+	for _, tt := range tests {
+		got, err := tt.c.Parse(tt.s)
+		if tt.wantErr {
+			if !errors.Is(err, ErrInvalidAmount) {
+				t.Errorf("%s.Parse(%q) error = %v, want ErrInvalidAmount", tt.c, tt.s, err)
+			}
+			continue
+		}
+		if err != nil || got != tt.want {
+			t.Errorf("%s.Parse(%q) = %d, %v, want %d", tt.c, tt.s, got, err, tt.want)
+		}
+	}
+}
+
+func TestRatio(t *testing.T) {
+	tests := []struct {
+		name     string
+		a        Amount
+		num, den float64
+		want     Amount
+	}{
+		{"exact", 1000, 0.15, 1, 150},
+		{"rounds up", 999, 0.15, 1, 150},
+		{"half away from zero", 10, 0.05, 1, 1},
+		{"negative half away from zero", -10, 0.05, 1, -1},
+		{"included tax", 1150, 0.15, 1.15, 150},
+		{"thirds down", 100, 1, 3, 33},
+		{"thirds up", 200, 1, 3, 67},
+		{"zero denominator", 100, 1, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Ratio(tt.num, tt.den); got != tt.want {
+			t.Errorf("%s: %d.Ratio(%v, %v) = %d, want %d", tt.name, tt.a, tt.num, tt.den, got, tt.want)
+		}
+	}
+}
+
+func TestPercent(t *testing.T) {
+	tests := []struct {
+		a    Amount
+		p    float64
+		want Amount
+	}{
+		{1999, 10, 200},
+		{25, 10, 3},
+		{1000, 12.5, 125},
+		{1000, 100, 1000},
+		{1000, 0, 0},
+	}
+	for _, tt := range tests {
+		if got := tt.a.Percent(tt.p); got != tt.want {
+			t.Errorf("%d.Percent(%v) = %d, want %d", tt.a, tt.p, got, tt.want)
+		}
+	}
+}
+
+func TestAllocate(t *testing.T) {
+	tests := []struct {
+		name    string
+		a       Amount
+		weights []Amount
+		want    []Amount
+	}{
+		{"even split", 100, []Amount{1, 1, 1}, []Amount{34, 33, 33}},
+		{"largest remainder first", 10, []Amount{1, 2, 3}, []Amount{2, 3, 5}},
+		{"earlier part wins a tie", 5, []Amount{0, 1, 1}, []Amount{0, 3, 2}},
+		{"single unit", 1, []Amount{1, 1, 1}, []Amount{1, 0, 0}},
+		{"exact", 600, []Amount{100, 200, 300}, []Amount{100, 200, 300}},
+		{"nothing to split", 0, []Amount{1, 2}, []Amount{0, 0}},
+		{"zero weights", 7, []Amount{0, 0}, []Amount{0, 0}},
+		{"no parts", 7, nil, []Amount{}},
+	}
+	for _, tt := range tests {
+		got := tt.a.Allocate(tt.weights)
+		if !slices.Equal(got, tt.want) {
+			t.Errorf("%s: %d.Allocate(%v) = %v, want %v", tt.name, tt.a, tt.weights, got, tt.want)
+		}
+		if Sum(tt.weights) > 0 && Sum(got) != tt.a {
+			t.Errorf("%s: parts add up to %d, want %d", tt.name, Sum(got), tt.a)
+		}
+	}
+}
+
+func TestDecimalUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		json    string
+		want    Decimal
+		wantErr bool
+	}{
+		{`"12.30"`, "12.30", false},
+		{`12.3`, "12.3", false},
+		{`null`, "", false},
+		{`true`, "", true},
+	}
+	for _, tt := range tests {
+		var d Decimal
+		err := json.Unmarshal([]byte(tt.json), &d)
+		if (err != nil) != tt.wantErr || d != tt.want {
+			t.Errorf("unmarshal %s = %q, %v, want %q (error %v)", tt.json, d, err, tt.want, tt.wantErr)
+		}
+	}
+}
@@ -0,0 +1,340 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/storer"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"time"
+)
+
+// ErrInvalidCart is returned for cart lines that cannot be bought: unknown
+// products or variants, quantities below one, or more than is in stock.
+var ErrInvalidCart = errors.New("invalid cart")
+
+// ErrCartNotFound is returned for a guest cart token that is unknown or has
+// expired.
+var ErrCartNotFound = errors.New("cart not found")
+
+// PricedCart is a cart with every line priced at the current catalog price,
+// after any running sale. PromotionPrice is what buy X get Y promotions take
+// off ItemsPrice. Prices are in Currency.
+type PricedCart struct {
+	*storer.Cart
+	Currency       money.Currency
+	Lines          []CartLine
+	ItemsPrice     money.Amount
+	PromotionPrice money.Amount
+	Quantity       int
+}
+
+// CartLine is a cart item with the product details it sells with right now.
+// Total is Price times Quantity, and Discount the part of it that buy X get
+// Y promotions take off. InStock is the stock of the product or variant, so
+// a line with a Quantity above it cannot be checked out as it is.
+type CartLine struct {
+	storer.CartItem
+	Name     string
+	SKU      string
+	Image    string
+	Price    money.Amount
+	Total    money.Amount
+	Discount money.Amount
+	InStock  int
+}
+
+// CartOwner identifies a cart: the cart of the signed-in user UserID, or
+// else the guest cart with Token. The zero CartOwner is a guest without a
+// cart yet.
+type CartOwner struct {
+	UserID int
+	Token  string
+}
+
+// GetCart returns the cart of owner. A guest without a cart gets an empty
+// cart that is not stored. An unknown or expired guest token returns
+// ErrCartNotFound.
+func (s *Server) GetCart(ctx context.Context, owner CartOwner) (*PricedCart, error) {
+	if owner.UserID == 0 && owner.Token == "" {
+		return s.priceCart(ctx, &storer.Cart{})
+	}
+	c, err := s.ownerCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	return s.priceCart(ctx, c)
+}
+
+// AddCartItem adds a quantity of a product, or of one of its variants, to
+// the cart of owner. A guest without a cart gets a new guest cart; its token
+// is in the returned cart.
+func (s *Server) AddCartItem(ctx context.Context, owner CartOwner, item *storer.CartItem) (*PricedCart, error) {
+	c := &storer.Cart{}
+	if owner.UserID != 0 || owner.Token != "" {
+		var err error
+		if c, err = s.ownerCart(ctx, owner); err != nil {
+			return nil, err
+		}
+	}
+	quantity := item.Quantity
+	for i := range c.Items {
+		if c.Items[i].ProductID == item.ProductID && sameVariant(c.Items[i].VariantID, item.VariantID) {
+			quantity += c.Items[i].Quantity
+		}
+	}
+	if err := s.checkCartItem(ctx, item, quantity); err != nil {
+		return nil, err
+	}
+	if c.ID == 0 {
+		// Only create the guest cart once there is something to put in it.
+		created, err := s.createGuestCart(ctx)
+		if err != nil {
+			return nil, err
+		}
+		c, owner.Token = created, *created.Token
+	}
+	item.CartID = c.ID
+	if _, err := s.storer.AddCartItem(ctx, item); err != nil {
+		return nil, err
+	}
+	return s.GetCart(ctx, owner)
+}
+
+// UpdateCartItem sets the quantity of a line of the cart of owner. It
+// returns an error wrapping sql.ErrNoRows if the line is not in the cart.
+func (s *Server) UpdateCartItem(ctx context.Context, owner CartOwner, itemID, quantity int) (*PricedCart, error) {
+	c, err := s.ownerCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	item := findCartItem(c, itemID)
+	if item == nil {
+		return nil, fmt.Errorf("error getting cart item: %w", sql.ErrNoRows)
+	}
+	if err := s.checkCartItem(ctx, item, quantity); err != nil {
+		return nil, err
+	}
+	if err := s.storer.UpdateCartItem(ctx, c.ID, itemID, quantity); err != nil {
+		return nil, err
+	}
+	return s.GetCart(ctx, owner)
+}
+
+// RemoveCartItem removes a line from the cart of owner. It returns an error
+// wrapping sql.ErrNoRows if the line is not in the cart.
+func (s *Server) RemoveCartItem(ctx context.Context, owner CartOwner, itemID int) (*PricedCart, error) {
+	c, err := s.ownerCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storer.DeleteCartItem(ctx, c.ID, itemID); err != nil {
+		return nil, err
+	}
+	return s.GetCart(ctx, owner)
+}
+
+func (s *Server) ClearCart(ctx context.Context, owner CartOwner) (*PricedCart, error) {
+	c, err := s.ownerCart(ctx, owner)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storer.ClearCart(ctx, c.ID); err != nil {
+		return nil, err
+	}
+	return s.GetCart(ctx, owner)
+}
+
+// MergeGuestCart moves the lines of the guest cart with token into the cart
+// of the user and deletes the guest cart. A product that is in both carts
+// keeps the larger of the two quantities, so signing in never doubles what
+// the customer meant to buy. It returns ErrCartNotFound if the guest cart
+// does not exist or has expired.
+func (s *Server) MergeGuestCart(ctx context.Context, userID int, token string) (*PricedCart, error) {
+	guest, err := s.ownerCart(ctx, CartOwner{Token: token})
+	if err != nil {
+		return nil, err
+	}
+	c, err := s.storer.GetUserCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storer.MergeCarts(ctx, guest.ID, c.ID); err != nil {
+		return nil, err
+	}
+	return s.GetCart(ctx, CartOwner{UserID: userID})
+}
+
+// ExpireGuestCarts deletes the guest carts that have not changed for the
+// guest cart TTL. It returns the number of carts deleted.
+func (s *Server) ExpireGuestCarts(ctx context.Context) (int64, error) {
+	return s.storer.DeleteExpiredGuestCarts(ctx, time.Now().Add(-s.guestCartTTL))
+}
+
+// ownerCart returns the stored cart of owner. The cart of a user is created
+// on first use; a guest cart past the TTL counts as missing even before
+// ExpireGuestCarts deletes it.
+func (s *Server) ownerCart(ctx context.Context, owner CartOwner) (*storer.Cart, error) {
+	if owner.UserID != 0 {
+		return s.storer.GetUserCart(ctx, owner.UserID)
+	}
+	if owner.Token == "" {
+		return nil, ErrCartNotFound
+	}
+	c, err := s.storer.GetGuestCart(ctx, owner.Token)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, ErrCartNotFound
+		}
+		return nil, err
+	}
+	if time.Since(c.LastActive()) > s.guestCartTTL {
+		return nil, ErrCartNotFound
+	}
+	return c, nil
+}
+
+// createGuestCart creates a guest cart with a new unguessable token.
+func (s *Server) createGuestCart(ctx context.Context) (*storer.Cart, error) {
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("error generating cart token: %w", err)
+	}
+	return s.storer.CreateGuestCart(ctx, hex.EncodeToString(b))
+}
+
+// Checkout places an order for the contents of the user's cart and empties
+// the cart. o carries the payment and shipping details; its items are taken
+// from the cart. Totals the client sent along are checked like for any other
+// order, so a price change since the cart was viewed fails the checkout.
+func (s *Server) Checkout(ctx context.Context, userID int, o *storer.Order) (*storer.Order, error) {
+	c, err := s.storer.GetUserCart(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	if len(c.Items) == 0 {
+		return nil, fmt.Errorf("%w: cart is empty", ErrInvalidCart)
+	}
+	o.UserId = userID
+	o.Items = make([]storer.OrderItem, len(c.Items))
+	for i, item := range c.Items {
+		o.Items[i] = storer.OrderItem{
+			ProductID: item.ProductID,
+			VariantID: item.VariantID,
+			Quantity:  item.Quantity,
+		}
+	}
+	created, err := s.CreateOrder(ctx, o)
+	if err != nil {
+		return nil, err
+	}
+	// The order is placed at this point, so failing to empty the cart must
+	// not fail the checkout.
+	if err := s.storer.ClearCart(ctx, c.ID); err != nil {
+		log.Printf("error clearing cart %d after order %d: %v", c.ID, created.ID, err)
+	}
+	return created, nil
+}
+
+// checkCartItem checks that quantity of the product and variant of item can
+// be bought.
+func (s *Server) checkCartItem(ctx context.Context, item *storer.CartItem, quantity int) error {
+	if quantity < 1 {
+		return fmt.Errorf("%w: quantity must be at least 1", ErrInvalidCart)
+	}
+	p, err := s.storer.GetProduct(ctx, item.ProductID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: product %d not found", ErrInvalidCart, item.ProductID)
+		}
+		return err
+	}
+	stock := p.CountInStock
+	switch {
+	case item.VariantID != nil:
+		v := findVariant(p, *item.VariantID)
+		if v == nil {
+			return fmt.Errorf("%w: variant %d of product %d not found", ErrInvalidCart, *item.VariantID, p.ID)
+		}
+		stock = v.CountInStock
+	case len(p.Variants) > 0:
+		return fmt.Errorf("%w: product %d requires a variant", ErrInvalidCart, p.ID)
+	}
+	if quantity > stock {
+		return fmt.Errorf("%w: only %d of product %d in stock", ErrInvalidCart, stock, p.ID)
+	}
+	return nil
+}
+
+// priceCart looks up the current price and stock of every line of c.
+func (s *Server) priceCart(ctx context.Context, c *storer.Cart) (*PricedCart, error) {
+	ps, err := s.runningPromotions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	pc := &PricedCart{Cart: c, Currency: s.currency, Lines: make([]CartLine, 0, len(c.Items))}
+	products := make(map[int]*storer.Product)
+	lineProducts := make([]*storer.Product, 0, len(c.Items))
+	for _, item := range c.Items {
+		p, ok := products[item.ProductID]
+		if !ok {
+			var err error
+			p, err = s.storer.GetProduct(ctx, item.ProductID)
+			if err != nil {
+				return nil, err
+			}
+			products[p.ID] = p
+		}
+		var v *storer.ProductVariant
+		if item.VariantID != nil {
+			v = findVariant(p, *item.VariantID)
+		}
+		line := CartLine{CartItem: item, Name: p.Name, InStock: p.CountInStock}
+		line.Price, line.Image, line.SKU = sellingDetails(p, v)
+		line.Price = ps.salePrice(p, line.Price)
+		switch {
+		case v != nil:
+			line.InStock = v.CountInStock
+		case item.VariantID != nil || len(p.Variants) > 0:
+			// The variant was deleted, or variants were added to the
+			// product after the line was; the line has to be replaced.
+			line.InStock = 0
+		}
+		line.Total = line.Price.Times(item.Quantity)
+		pc.Lines = append(pc.Lines, line)
+		lineProducts = append(lineProducts, p)
+		pc.ItemsPrice += line.Total
+		pc.Quantity += item.Quantity
+	}
+
+	prices := make([]money.Amount, len(pc.Lines))
+	quantities := make([]int, len(pc.Lines))
+	for i, line := range pc.Lines {
+		prices[i], quantities[i] = line.Price, line.Quantity
+	}
+	for i, d := range ps.lineDiscounts(lineProducts, prices, quantities) {
+		pc.Lines[i].Discount = d
+		pc.PromotionPrice += d
+	}
+	return pc, nil
+}
+
+func findCartItem(c *storer.Cart, id int) *storer.CartItem {
+	for i := range c.Items {
+		if c.Items[i].ID == id {
+			return &c.Items[i]
+		}
+	}
+	return nil
+}
+
+func sameVariant(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,88 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"ecom_apiv1/internal/storer"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidCategory is returned when a category would break the tree: an
+// unknown parent, a parent that is the category itself or one of its
+// descendants, or a slug that is already taken.
+var ErrInvalidCategory = errors.New("invalid category")
+
+// ErrInvalidProduct is returned when a product references an unknown
+// category.
+var ErrInvalidProduct = errors.New("invalid product")
+
+func (s *Server) CreateCategory(ctx context.Context, c *storer.Category) (*storer.Category, error) {
+	if err := s.checkCategory(ctx, c); err != nil {
+		return nil, err
+	}
+	return s.storer.CreateCategory(ctx, c)
+}
+
+func (s *Server) GetCategory(ctx context.Context, id int) (*storer.Category, error) {
+	return s.storer.GetCategory(ctx, id)
+}
+
+func (s *Server) ListCategories(ctx context.Context) ([]storer.Category, error) {
+	return s.storer.ListCategories(ctx)
+}
+
+func (s *Server) UpdateCategory(ctx context.Context, c *storer.Category) (*storer.Category, error) {
+	if err := s.checkCategory(ctx, c); err != nil {
+		return nil, err
+	}
+	return s.storer.UpdateCategory(ctx, c)
+}
+
+func (s *Server) DeleteCategory(ctx context.Context, id int) error {
+	return s.storer.DeleteCategory(ctx, id)
+}
+
+// checkCategory validates c against the rest of the tree. A zero ID means c
+// is new.
+func (s *Server) checkCategory(ctx context.Context, c *storer.Category) error {
+	categories, err := s.storer.ListCategories(ctx)
+	if err != nil {
+		return err
+	}
+	byID := make(map[int]storer.Category, len(categories))
+	for _, other := range categories {
+		byID[other.ID] = other
+		if other.ID != c.ID && other.Slug == c.Slug {
+			return fmt.Errorf("%w: slug %q is already in use", ErrInvalidCategory, c.Slug)
+		}
+	}
+
+	// Walk up from the new parent; reaching c means it would become its own
+	// ancestor.
+	for id := c.ParentID; id != nil; {
+		parent, ok := byID[*id]
+		if !ok {
+			return fmt.Errorf("%w: parent category %d not found", ErrInvalidCategory, *id)
+		}
+		if c.ID != 0 && parent.ID == c.ID {
+			return fmt.Errorf("%w: a category cannot be moved below itself", ErrInvalidCategory)
+		}
+		id = parent.ParentID
+	}
+	return nil
+}
+
+// checkProductCategory makes sure the category of p exists.
+func (s *Server) checkProductCategory(ctx context.Context, p *storer.Product) error {
+	if p.CategoryID == nil {
+		return nil
+	}
+	if _, err := s.storer.GetCategory(ctx, *p.CategoryID); err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: category %d not found", ErrInvalidProduct, *p.CategoryID)
+		}
+		return err
+	}
+	return nil
+}
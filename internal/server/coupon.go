@@ -0,0 +1,133 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/storer"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidCoupon is returned for coupons that restrict themselves to
+// unknown categories or products, and for coupon codes an order cannot use:
+// unknown codes, codes outside their validity window, and orders that are
+// below the minimum or contain nothing the coupon applies to.
+var ErrInvalidCoupon = errors.New("invalid coupon")
+
+func (s *Server) CreateCoupon(ctx context.Context, c *storer.Coupon) (*storer.Coupon, error) {
+	if err := s.checkCoupon(ctx, c); err != nil {
+		return nil, err
+	}
+	return s.storer.CreateCoupon(ctx, c)
+}
+
+func (s *Server) GetCoupon(ctx context.Context, id int) (*storer.Coupon, error) {
+	return s.storer.GetCoupon(ctx, id)
+}
+
+func (s *Server) ListCoupons(ctx context.Context) ([]storer.Coupon, error) {
+	return s.storer.ListCoupons(ctx)
+}
+
+func (s *Server) UpdateCoupon(ctx context.Context, c *storer.Coupon) (*storer.Coupon, error) {
+	if err := s.checkCoupon(ctx, c); err != nil {
+		return nil, err
+	}
+	return s.storer.UpdateCoupon(ctx, c)
+}
+
+func (s *Server) DeleteCoupon(ctx context.Context, id int) error {
+	return s.storer.DeleteCoupon(ctx, id)
+}
+
+// NormalizeCouponCode returns the form codes are stored and looked up in, so
+// customers can type them in any case.
+func NormalizeCouponCode(code string) string {
+	return strings.ToUpper(strings.TrimSpace(code))
+}
+
+// checkCoupon makes sure the categories and products c is restricted to
+// exist, and drops duplicates among them.
+func (s *Server) checkCoupon(ctx context.Context, c *storer.Coupon) error {
+	c.CategoryIDs, c.ProductIDs = uniqueIDs(c.CategoryIDs), uniqueIDs(c.ProductIDs)
+	for _, id := range c.CategoryIDs {
+		if _, err := s.storer.GetCategory(ctx, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: category %d not found", ErrInvalidCoupon, id)
+			}
+			return err
+		}
+	}
+	for _, id := range c.ProductIDs {
+		if _, err := s.storer.GetProduct(ctx, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: product %d not found", ErrInvalidCoupon, id)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// applyCoupon validates the coupon code of o and returns the discount on
+// each of its items. amounts holds what each item costs before the coupon.
+// The usage limits are checked by the storer when the order is created, so
+// that the check and the redemption are atomic.
+func (s *Server) applyCoupon(ctx context.Context, o *storer.Order, products []*storer.Product, categories map[int]storer.Category, amounts []money.Amount) ([]money.Amount, error) {
+	code := NormalizeCouponCode(o.CouponCode)
+	c, err := s.storer.GetCouponByCode(ctx, code)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: coupon %q not found", ErrInvalidCoupon, code)
+		}
+		return nil, err
+	}
+	now := time.Now()
+	if c.StartsAt != nil && now.Before(*c.StartsAt) {
+		return nil, fmt.Errorf("%w: coupon %s is not valid yet", ErrInvalidCoupon, c.Code)
+	}
+	if c.EndsAt != nil && !now.Before(*c.EndsAt) {
+		return nil, fmt.Errorf("%w: coupon %s has expired", ErrInvalidCoupon, c.Code)
+	}
+	if money.Sum(amounts) < c.MinOrderValue {
+		return nil, fmt.Errorf("%w: coupon %s needs an order of at least %s", ErrInvalidCoupon, c.Code, money.New(c.MinOrderValue, s.currency))
+	}
+
+	eligibleAmounts := make([]money.Amount, len(amounts))
+	var eligible money.Amount
+	for i, p := range products {
+		if covers(c.ProductIDs, c.CategoryIDs, p, categories) {
+			eligibleAmounts[i] = amounts[i]
+			eligible += amounts[i]
+		}
+	}
+	if eligible == 0 {
+		return nil, fmt.Errorf("%w: coupon %s does not apply to any item of this order", ErrInvalidCoupon, c.Code)
+	}
+
+	var discount money.Amount
+	switch c.Type {
+	case storer.CouponPercentage:
+		discount = eligible.Percent(c.Percent)
+	case storer.CouponFixed:
+		discount = min(c.Amount, eligible)
+	}
+	o.CouponID = &c.ID
+	o.CouponCode = c.Code
+	return discount.Allocate(eligibleAmounts), nil
+}
+
+func uniqueIDs(ids []int) []int {
+	seen := make(map[int]bool, len(ids))
+	var res []int
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			res = append(res, id)
+		}
+	}
+	return res
+}
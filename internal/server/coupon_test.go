@@ -0,0 +1,151 @@
+package server
+
+import (
+	"context"
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/storer"
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestApplyCoupon(t *testing.T) {
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	type item struct {
+		product string
+		qty     int
+	}
+	tests := []struct {
+		name         string
+		coupon       storer.Coupon
+		restrictTo   string
+		code         string
+		items        []item
+		wantDiscount money.Amount
+		wantTax      money.Amount
+		wantErr      error
+	}{
+		{
+			name:         "percentage",
+			coupon:       storer.Coupon{Type: storer.CouponPercentage, Percent: 10},
+			items:        []item{{"mug", 2}},
+			wantDiscount: 200,
+			wantTax:      270,
+		},
+		{
+			name:         "fixed is capped at the order",
+			coupon:       storer.Coupon{Type: storer.CouponFixed, Amount: 5000},
+			items:        []item{{"mug", 1}},
+			wantDiscount: 1000,
+			wantTax:      0,
+		},
+		{
+			name:         "fixed is split over the items",
+			coupon:       storer.Coupon{Type: storer.CouponFixed, Amount: 300},
+			items:        []item{{"mug", 1}, {"book", 2}},
+			wantDiscount: 300,
+			wantTax:      225,
+		},
+		{
+			name:         "code in any case",
+			coupon:       storer.Coupon{Type: storer.CouponPercentage, Percent: 10},
+			code:         " save ",
+			items:        []item{{"mug", 2}},
+			wantDiscount: 200,
+			wantTax:      270,
+		},
+		{
+			name:         "restricted to a category",
+			coupon:       storer.Coupon{Type: storer.CouponPercentage, Percent: 10},
+			restrictTo:   "books",
+			items:        []item{{"mug", 1}, {"book", 1}},
+			wantDiscount: 100,
+			wantTax:      195,
+		},
+		{
+			name:       "restricted to a product not in the order",
+			coupon:     storer.Coupon{Type: storer.CouponPercentage, Percent: 10},
+			restrictTo: "book",
+			items:      []item{{"mug", 1}},
+			wantErr:    ErrInvalidCoupon,
+		},
+		{
+			name:    "below the minimum",
+			coupon:  storer.Coupon{Type: storer.CouponFixed, Amount: 100, MinOrderValue: 3000},
+			items:   []item{{"mug", 2}},
+			wantErr: ErrInvalidCoupon,
+		},
+		{
+			name:    "not valid yet",
+			coupon:  storer.Coupon{Type: storer.CouponFixed, Amount: 100, StartsAt: &future},
+			items:   []item{{"mug", 1}},
+			wantErr: ErrInvalidCoupon,
+		},
+		{
+			name:    "expired",
+			coupon:  storer.Coupon{Type: storer.CouponFixed, Amount: 100, EndsAt: &past},
+			items:   []item{{"mug", 1}},
+			wantErr: ErrInvalidCoupon,
+		},
+		{
+			name:    "unknown code",
+			coupon:  storer.Coupon{Type: storer.CouponFixed, Amount: 100},
+			code:    "NOPE",
+			items:   []item{{"mug", 1}},
+			wantErr: ErrInvalidCoupon,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, st := newTestServer(t)
+			books, err := st.CreateCategory(ctx, &storer.Category{Name: "Books", Slug: "books"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := st.CreateTaxRate(ctx, &storer.TaxRate{Name: "Books", Category: "books", Rate: 0.05}); err != nil {
+				t.Fatal(err)
+			}
+			ids := map[string]int{
+				"mug":  createProduct(t, st, &storer.Product{Name: "mug", Price: 1000}).ID,
+				"book": createProduct(t, st, &storer.Product{Name: "book", Price: 1000, CategoryID: &books.ID}).ID,
+			}
+
+			c := tt.coupon
+			c.Code = "SAVE"
+			switch tt.restrictTo {
+			case "books":
+				c.CategoryIDs = []int{books.ID}
+			case "book":
+				c.ProductIDs = []int{ids["book"]}
+			}
+			if _, err := s.CreateCoupon(ctx, &c); err != nil {
+				t.Fatal(err)
+			}
+
+			o := &storer.Order{ShippingCountry: "US", CouponCode: tt.code}
+			if o.CouponCode == "" {
+				o.CouponCode = c.Code
+			}
+			for _, it := range tt.items {
+				o.Items = append(o.Items, storer.OrderItem{ProductID: ids[it.product], Quantity: it.qty})
+			}
+			err = s.priceOrder(ctx, o)
+			if tt.wantErr != nil {
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatal(err)
+			}
+			if o.DiscountPrice != tt.wantDiscount || o.TaxPrice != tt.wantTax {
+				t.Errorf("discount %d, tax %d, want %d and %d", o.DiscountPrice, o.TaxPrice, tt.wantDiscount, tt.wantTax)
+			}
+			if o.CouponID == nil || *o.CouponID != c.ID || o.CouponCode != c.Code {
+				t.Errorf("order has coupon %v %q, want %d %q", o.CouponID, o.CouponCode, c.ID, c.Code)
+			}
+		})
+	}
+}
@@ -0,0 +1,168 @@
+package server
+
+import (
+	"bytes"
+	"context"
+	"crypto/rand"
+	"ecom_apiv1/internal/imaging"
+	"ecom_apiv1/internal/storer"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"sort"
+)
+
+// ErrInvalidImage is returned for uploads that are not a supported image and
+// for image orders that do not list exactly the product's images.
+var ErrInvalidImage = errors.New("invalid image")
+
+// ThumbnailSizes are the thumbnails generated for every product image, by
+// name, as the longest side in pixels.
+var ThumbnailSizes = map[string]int{
+	"small":  150,
+	"medium": 600,
+}
+
+// ImageURL is the stable URL an image blob is served under.
+func ImageURL(key string) string {
+	return "/images/" + key
+}
+
+// ProductImageURL is the URL of the main image of p: its first uploaded
+// image, or else the image URL it was created with.
+func ProductImageURL(p *storer.Product) string {
+	if len(p.Images) > 0 {
+		return ImageURL(p.Images[0].Key)
+	}
+	return p.Image
+}
+
+// AddProductImage stores an uploaded image and its thumbnails and appends it
+// to the product's images.
+func (s *Server) AddProductImage(ctx context.Context, productID int, data []byte) (*storer.ProductImage, error) {
+	if _, err := s.storer.GetProduct(ctx, productID); err != nil {
+		return nil, err
+	}
+	format, err := imaging.DetectFormat(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+	img, err := imaging.Decode(data)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidImage, err)
+	}
+
+	prefix, err := imagePrefix(productID)
+	if err != nil {
+		return nil, err
+	}
+	pi := &storer.ProductImage{
+		ProductID:   productID,
+		Key:         prefix + "original." + format.Ext,
+		ContentType: format.ContentType,
+		Width:       img.Bounds().Dx(),
+		Height:      img.Bounds().Dy(),
+		Thumbnails:  storer.ThumbnailKeys{},
+	}
+	if err := s.blobs.Put(ctx, pi.Key, bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("error storing image: %w", err)
+	}
+
+	thumbFormat := imaging.ThumbnailFormat(format)
+	names := make([]string, 0, len(ThumbnailSizes))
+	for name := range ThumbnailSizes {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		var buf bytes.Buffer
+		if err := imaging.Encode(&buf, imaging.Thumbnail(img, ThumbnailSizes[name]), thumbFormat); err != nil {
+			s.deleteImageBlobs(ctx, pi)
+			return nil, fmt.Errorf("error encoding %s thumbnail: %w", name, err)
+		}
+		key := prefix + name + "." + thumbFormat.Ext
+		if err := s.blobs.Put(ctx, key, &buf); err != nil {
+			s.deleteImageBlobs(ctx, pi)
+			return nil, fmt.Errorf("error storing %s thumbnail: %w", name, err)
+		}
+		pi.Thumbnails[name] = key
+	}
+
+	created, err := s.storer.CreateProductImage(ctx, pi)
+	if err != nil {
+		s.deleteImageBlobs(ctx, pi)
+		return nil, err
+	}
+	return created, nil
+}
+
+// ReorderProductImages positions the images of a product in the order of
+// imageIDs, which must list each of its images exactly once.
+func (s *Server) ReorderProductImages(ctx context.Context, productID int, imageIDs []int) (*storer.Product, error) {
+	p, err := s.storer.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+	if len(imageIDs) != len(p.Images) {
+		return nil, fmt.Errorf("%w: expected %d image IDs, got %d", ErrInvalidImage, len(p.Images), len(imageIDs))
+	}
+	images := make(map[int]bool, len(p.Images))
+	for _, img := range p.Images {
+		images[img.ID] = true
+	}
+	for _, id := range imageIDs {
+		if !images[id] {
+			return nil, fmt.Errorf("%w: image %d is not an image of product %d or is listed twice", ErrInvalidImage, id, productID)
+		}
+		delete(images, id)
+	}
+	if err := s.storer.ReorderProductImages(ctx, productID, imageIDs); err != nil {
+		return nil, err
+	}
+	return s.storer.GetProduct(ctx, productID)
+}
+
+func (s *Server) GetProductImage(ctx context.Context, id int) (*storer.ProductImage, error) {
+	return s.storer.GetProductImage(ctx, id)
+}
+
+// DeleteProductImage removes an image and its blobs.
+func (s *Server) DeleteProductImage(ctx context.Context, img *storer.ProductImage) error {
+	if err := s.storer.DeleteProductImage(ctx, img.ID); err != nil {
+		return err
+	}
+	s.deleteImageBlobs(ctx, img)
+	return nil
+}
+
+// OpenImage returns the contents of an image blob.
+func (s *Server) OpenImage(ctx context.Context, key string) (io.ReadCloser, error) {
+	return s.blobs.Get(ctx, key)
+}
+
+// deleteImageBlobs removes the original and thumbnails of img. The rows are
+// the source of truth, so a blob that cannot be deleted is only logged.
+func (s *Server) deleteImageBlobs(ctx context.Context, img *storer.ProductImage) {
+	keys := []string{img.Key}
+	for _, key := range img.Thumbnails {
+		keys = append(keys, key)
+	}
+	for _, key := range keys {
+		if err := s.blobs.Delete(ctx, key); err != nil {
+			log.Printf("error deleting image blob %s: %v", key, err)
+		}
+	}
+}
+
+// imagePrefix returns a fresh key prefix for the blobs of a new image of the
+// product, so uploaded images never overwrite each other and their URLs stay
+// stable.
+func imagePrefix(productID int) (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("error generating image key: %w", err)
+	}
+	return fmt.Sprintf("products/%d/%s/", productID, hex.EncodeToString(b)), nil
+}
@@ -0,0 +1,289 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/shipping"
+	"ecom_apiv1/internal/storer"
+	"ecom_apiv1/internal/tax"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidOrder is returned for orders that cannot be priced, such as an
+// order without items or one that references an unknown product.
+var ErrInvalidOrder = errors.New("invalid order")
+
+// PriceMismatchError is returned when the prices a client sent with an order
+// do not match the prices calculated by the server.
+type PriceMismatchError struct {
+	Field    string
+	Sent     money.Money
+	Expected money.Money
+}
+
+func (e *PriceMismatchError) Error() string {
+	return fmt.Sprintf("%s mismatch: sent %s, expected %s", e.Field, e.Sent, e.Expected)
+}
+
+// priceOrder fills in the name, image and price of every item from the
+// products table and calculates the order totals. Any price the client
+// already set is compared against the calculated one; zero means "not sent".
+//
+// Items are priced after any running sale, and buy X get Y promotions take
+// PromotionPrice off them. A coupon code on the order then takes
+// DiscountPrice off what is left. Both discounts are taken off the items
+// they apply to before tax is worked out, and free shipping thresholds look
+// at the discounted price.
+//
+// TaxPrice is the whole tax on the order, while TotalPrice only adds the tax
+// that is not already included in the catalog prices. Every amount is in the
+// store currency.
+func (s *Server) priceOrder(ctx context.Context, o *storer.Order) error {
+	o.CouponID = nil
+	o.Currency = s.currency
+	ps, err := s.runningPromotions(ctx)
+	if err != nil {
+		return err
+	}
+	products, err := s.fillOrderItems(ctx, o, ps)
+	if err != nil {
+		return err
+	}
+	byID := ps.categories
+
+	var itemsPrice money.Amount
+	var weight float64
+	for i, p := range products {
+		itemsPrice += o.Items[i].Price.Times(o.Items[i].Quantity)
+		weight += p.Weight * float64(o.Items[i].Quantity)
+	}
+
+	promotions := orderPromotions(ps, o, products)
+	promotionPrice := money.Sum(promotions)
+
+	amounts := make([]money.Amount, len(o.Items))
+	for i, oi := range o.Items {
+		amounts[i] = oi.Price.Times(oi.Quantity) - promotions[i]
+	}
+	discounts := make([]money.Amount, len(o.Items))
+	if o.CouponCode != "" {
+		discounts, err = s.applyCoupon(ctx, o, products, byID, amounts)
+		if err != nil {
+			return err
+		}
+	}
+	discountPrice := money.Sum(discounts)
+
+	taxReq := tax.Request{
+		Country: o.ShippingCountry,
+		Region:  o.ShippingRegion,
+	}
+	for i, p := range products {
+		var category string
+		if p.CategoryID != nil {
+			category = byID[*p.CategoryID].Slug
+		}
+		taxReq.Lines = append(taxReq.Lines, tax.Line{
+			ProductID: p.ID,
+			Category:  category,
+			Amount:    amounts[i] - discounts[i],
+		})
+	}
+
+	taxRes, err := s.tax.Calculate(ctx, taxReq)
+	if err != nil {
+		return fmt.Errorf("error calculating tax: %w", err)
+	}
+	for i, lt := range taxRes.Lines {
+		o.Items[i].TaxRate = lt.Rate
+		o.Items[i].TaxPrice = lt.Amount
+		o.Items[i].TaxInclusive = lt.Inclusive
+	}
+
+	shippingOpt, err := s.chooseShipping(ctx, o, itemsPrice-promotionPrice-discountPrice, weight)
+	if err != nil {
+		return err
+	}
+	o.ShippingMethod = shippingOpt.Method
+
+	shippingPrice := shippingOpt.Price
+	taxPrice := taxRes.Total
+	totalPrice := itemsPrice - promotionPrice - discountPrice + taxRes.Exclusive + shippingPrice
+
+	checks := []struct {
+		field    string
+		sent     money.Amount
+		expected money.Amount
+	}{
+		{"items_price", o.ItemsPrice, itemsPrice},
+		{"promotion_price", o.PromotionPrice, promotionPrice},
+		{"discount_price", o.DiscountPrice, discountPrice},
+		{"tax_price", o.TaxPrice, taxPrice},
+		{"shipping_price", o.ShippingPrice, shippingPrice},
+		{"total_price", o.TotalPrice, totalPrice},
+	}
+	for _, c := range checks {
+		if err := s.checkPrice(c.field, c.sent, c.expected); err != nil {
+			return err
+		}
+	}
+
+	o.ItemsPrice = itemsPrice
+	o.PromotionPrice = promotionPrice
+	o.DiscountPrice = discountPrice
+	o.TaxPrice = taxPrice
+	o.ShippingPrice = shippingPrice
+	o.TotalPrice = totalPrice
+	return nil
+}
+
+// QuoteShipping returns the shipping options for the items and destination
+// of o without creating the order.
+func (s *Server) QuoteShipping(ctx context.Context, o *storer.Order) ([]shipping.Option, error) {
+	ps, err := s.runningPromotions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	products, err := s.fillOrderItems(ctx, o, ps)
+	if err != nil {
+		return nil, err
+	}
+	var itemsPrice money.Amount
+	var weight float64
+	for i, p := range products {
+		itemsPrice += o.Items[i].Price.Times(o.Items[i].Quantity)
+		weight += p.Weight * float64(o.Items[i].Quantity)
+	}
+	itemsPrice -= money.Sum(orderPromotions(ps, o, products))
+	return s.quoteShipping(ctx, o, itemsPrice, weight)
+}
+
+// orderPromotions returns the buy X get Y discount on each item of o.
+func orderPromotions(ps *promotionSet, o *storer.Order, products []*storer.Product) []money.Amount {
+	prices := make([]money.Amount, len(o.Items))
+	quantities := make([]int, len(o.Items))
+	for i, oi := range o.Items {
+		prices[i], quantities[i] = oi.Price, oi.Quantity
+	}
+	return ps.lineDiscounts(products, prices, quantities)
+}
+
+// chooseShipping returns the option for the order's shipping method, or the
+// first available option when the client did not pick one.
+func (s *Server) chooseShipping(ctx context.Context, o *storer.Order, itemsPrice money.Amount, weight float64) (*shipping.Option, error) {
+	options, err := s.quoteShipping(ctx, o, itemsPrice, weight)
+	if err != nil {
+		return nil, err
+	}
+	if len(options) == 0 {
+		return nil, fmt.Errorf("%w: no shipping method available for this order", ErrInvalidOrder)
+	}
+	if o.ShippingMethod == "" {
+		return &options[0], nil
+	}
+	for i := range options {
+		if options[i].Method == o.ShippingMethod {
+			return &options[i], nil
+		}
+	}
+	return nil, fmt.Errorf("%w: shipping method %q is not available for this order", ErrInvalidOrder, o.ShippingMethod)
+}
+
+func (s *Server) quoteShipping(ctx context.Context, o *storer.Order, itemsPrice money.Amount, weight float64) ([]shipping.Option, error) {
+	options, err := s.shipping.Quote(ctx, shipping.Request{
+		Country:    o.ShippingCountry,
+		Region:     o.ShippingRegion,
+		Weight:     weight,
+		Currency:   s.currency,
+		ItemsPrice: itemsPrice,
+	})
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidOrder, err)
+	}
+	return options, nil
+}
+
+// fillOrderItems validates the items of o and copies name, image and price
+// from the products table onto them. Items of a product with variants must
+// name one of its variants, whose SKU, price and image take precedence. The
+// price is the sale price under ps. It returns the product of every item.
+func (s *Server) fillOrderItems(ctx context.Context, o *storer.Order, ps *promotionSet) ([]*storer.Product, error) {
+	if len(o.Items) == 0 {
+		return nil, fmt.Errorf("%w: order has no items", ErrInvalidOrder)
+	}
+
+	products := make([]*storer.Product, len(o.Items))
+	for i := range o.Items {
+		oi := &o.Items[i]
+		if oi.Quantity <= 0 {
+			return nil, fmt.Errorf("%w: quantity for product %d must be positive", ErrInvalidOrder, oi.ProductID)
+		}
+		p, err := s.storer.GetProduct(ctx, oi.ProductID)
+		if err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return nil, fmt.Errorf("%w: product %d not found", ErrInvalidOrder, oi.ProductID)
+			}
+			return nil, err
+		}
+		var v *storer.ProductVariant
+		switch {
+		case oi.VariantID != nil:
+			v = findVariant(p, *oi.VariantID)
+			if v == nil {
+				return nil, fmt.Errorf("%w: variant %d of product %d not found", ErrInvalidOrder, *oi.VariantID, p.ID)
+			}
+		case len(p.Variants) > 0:
+			return nil, fmt.Errorf("%w: product %d requires a variant", ErrInvalidOrder, p.ID)
+		}
+		price, image, sku := sellingDetails(p, v)
+		price = ps.salePrice(p, price)
+		if err := s.checkPrice(fmt.Sprintf("price of product %d", p.ID), oi.Price, price); err != nil {
+			return nil, err
+		}
+		oi.Name = p.Name
+		oi.SKU = sku
+		oi.Image = image
+		oi.Price = price
+		products[i] = p
+	}
+	return products, nil
+}
+
+func (s *Server) checkPrice(field string, sent, expected money.Amount) error {
+	if sent != 0 && sent != expected {
+		return &PriceMismatchError{
+			Field:    field,
+			Sent:     money.New(sent, s.currency),
+			Expected: money.New(expected, s.currency),
+		}
+	}
+	return nil
+}
+
+// sellingDetails returns the price, image and SKU that p sells with, or v if
+// it is not nil. A variant's own price and image take precedence over the
+// product's.
+func sellingDetails(p *storer.Product, v *storer.ProductVariant) (price money.Amount, image, sku string) {
+	price, image = p.Price, ProductImageURL(p)
+	if v == nil {
+		return price, image, ""
+	}
+	if v.Price != nil {
+		price = *v.Price
+	}
+	if v.Image != "" {
+		image = v.Image
+	}
+	return price, image, v.SKU
+}
+
+func findVariant(p *storer.Product, id int) *storer.ProductVariant {
+	for i := range p.Variants {
+		if p.Variants[i].ID == id {
+			return &p.Variants[i]
+		}
+	}
+	return nil
+}
@@ -0,0 +1,162 @@
+package server
+
+import (
+	"context"
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/storer"
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPriceOrder(t *testing.T) {
+	type item struct {
+		product string
+		qty     int
+		price   money.Amount
+	}
+	type totals struct {
+		items, tax, shipping, total money.Amount
+		method                      string
+	}
+	tests := []struct {
+		name         string
+		country      string
+		method       string
+		items        []item
+		sentTotal    money.Amount
+		want         totals
+		wantErr      error
+		wantMismatch string
+	}{
+		{
+			name:    "tax and shipping by weight",
+			country: "US",
+			items:   []item{{"mug", 2, 0}},
+			want:    totals{items: 2000, tax: 300, shipping: 600, total: 2900, method: "standard"},
+		},
+		{
+			name:    "free shipping over the threshold",
+			country: "US",
+			items:   []item{{"lamp", 2, 0}},
+			want:    totals{items: 12000, tax: 1800, shipping: 0, total: 13800, method: "standard"},
+		},
+		{
+			name:    "chosen shipping method",
+			country: "US",
+			method:  "express",
+			items:   []item{{"mug", 2, 0}},
+			want:    totals{items: 2000, tax: 300, shipping: 1750, total: 4050, method: "express"},
+		},
+		{
+			name:    "included tax is not added",
+			country: "DE",
+			items:   []item{{"lamp", 1, 0}},
+			want:    totals{items: 6000, tax: 958, shipping: 500, total: 6500, method: "standard"},
+		},
+		{
+			name:    "category rate",
+			country: "US",
+			items:   []item{{"book", 1, 0}},
+			want:    totals{items: 1000, tax: 50, shipping: 500, total: 1550, method: "standard"},
+		},
+		{
+			name:      "matching prices from the client",
+			country:   "US",
+			items:     []item{{"mug", 2, 1000}},
+			sentTotal: 2900,
+			want:      totals{items: 2000, tax: 300, shipping: 600, total: 2900, method: "standard"},
+		},
+		{
+			name:         "total from the client does not match",
+			country:      "US",
+			items:        []item{{"mug", 2, 0}},
+			sentTotal:    2800,
+			wantMismatch: "total_price",
+		},
+		{
+			name:         "item price from the client does not match",
+			country:      "US",
+			items:        []item{{"mug", 2, 999}},
+			wantMismatch: "price of product",
+		},
+		{
+			name:    "no items",
+			country: "US",
+			wantErr: ErrInvalidOrder,
+		},
+		{
+			name:    "zero quantity",
+			country: "US",
+			items:   []item{{"mug", 0, 0}},
+			wantErr: ErrInvalidOrder,
+		},
+		{
+			name:    "unknown product",
+			country: "US",
+			items:   []item{{"ghost", 1, 0}},
+			wantErr: ErrInvalidOrder,
+		},
+		{
+			name:    "unknown shipping method",
+			country: "US",
+			method:  "pigeon",
+			items:   []item{{"mug", 1, 0}},
+			wantErr: ErrInvalidOrder,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, st := newTestServer(t)
+			books, err := st.CreateCategory(ctx, &storer.Category{Name: "Books", Slug: "books"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, tr := range []storer.TaxRate{
+				{Name: "Books", Category: "books", Rate: 0.05},
+				{Name: "Germany", Country: "DE", Rate: 0.19, Inclusive: true},
+			} {
+				if _, err := st.CreateTaxRate(ctx, &tr); err != nil {
+					t.Fatal(err)
+				}
+			}
+			ids := map[string]int{
+				"mug":   createProduct(t, st, &storer.Product{Name: "mug", Price: 1000, Weight: 0.5}).ID,
+				"lamp":  createProduct(t, st, &storer.Product{Name: "lamp", Price: 6000}).ID,
+				"book":  createProduct(t, st, &storer.Product{Name: "book", Price: 1000, CategoryID: &books.ID}).ID,
+				"ghost": 999,
+			}
+
+			o := &storer.Order{ShippingCountry: tt.country, ShippingMethod: tt.method, TotalPrice: tt.sentTotal}
+			for _, it := range tt.items {
+				o.Items = append(o.Items, storer.OrderItem{ProductID: ids[it.product], Quantity: it.qty, Price: it.price})
+			}
+			err = s.priceOrder(ctx, o)
+
+			var mismatch *PriceMismatchError
+			switch {
+			case tt.wantErr != nil:
+				if !errors.Is(err, tt.wantErr) {
+					t.Fatalf("error = %v, want %v", err, tt.wantErr)
+				}
+				return
+			case tt.wantMismatch != "":
+				if !errors.As(err, &mismatch) || !strings.HasPrefix(mismatch.Field, tt.wantMismatch) {
+					t.Fatalf("error = %v, want a mismatch of %s", err, tt.wantMismatch)
+				}
+				return
+			case err != nil:
+				t.Fatal(err)
+			}
+
+			got := totals{items: o.ItemsPrice, tax: o.TaxPrice, shipping: o.ShippingPrice, total: o.TotalPrice, method: o.ShippingMethod}
+			if got != tt.want {
+				t.Errorf("totals = %+v, want %+v", got, tt.want)
+			}
+			if o.Currency != money.DefaultCurrency {
+				t.Errorf("currency = %q, want %q", o.Currency, money.DefaultCurrency)
+			}
+		})
+	}
+}
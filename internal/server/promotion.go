@@ -0,0 +1,249 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/storer"
+	"errors"
+	"fmt"
+	"sort"
+	"time"
+)
+
+// ErrInvalidPromotion is returned for promotions that cover unknown
+// categories or products.
+var ErrInvalidPromotion = errors.New("invalid promotion")
+
+// ProductPrice is what a product sells for under the promotions running
+// now. SalePrice is the price after the product's sale, or the price itself
+// without one; VariantSalePrices holds the same for each variant, by ID.
+// Promotions are the promotions that apply to the product: its sale first,
+// then its buy X get Y offer. Prices are in Currency.
+type ProductPrice struct {
+	Currency          money.Currency
+	SalePrice         money.Amount
+	VariantSalePrices map[int]money.Amount
+	Promotions        []storer.Promotion
+}
+
+func (s *Server) CreatePromotion(ctx context.Context, p *storer.Promotion) (*storer.Promotion, error) {
+	if err := s.checkPromotion(ctx, p); err != nil {
+		return nil, err
+	}
+	return s.storer.CreatePromotion(ctx, p)
+}
+
+func (s *Server) GetPromotion(ctx context.Context, id int) (*storer.Promotion, error) {
+	return s.storer.GetPromotion(ctx, id)
+}
+
+func (s *Server) ListPromotions(ctx context.Context) ([]storer.Promotion, error) {
+	return s.storer.ListPromotions(ctx)
+}
+
+func (s *Server) UpdatePromotion(ctx context.Context, p *storer.Promotion) (*storer.Promotion, error) {
+	if err := s.checkPromotion(ctx, p); err != nil {
+		return nil, err
+	}
+	return s.storer.UpdatePromotion(ctx, p)
+}
+
+func (s *Server) DeletePromotion(ctx context.Context, id int) error {
+	return s.storer.DeletePromotion(ctx, id)
+}
+
+// PriceProducts works out what each of products sells for right now.
+func (s *Server) PriceProducts(ctx context.Context, products []storer.Product) ([]ProductPrice, error) {
+	ps, err := s.runningPromotions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	prices := make([]ProductPrice, len(products))
+	for i := range products {
+		p := &products[i]
+		pp := ProductPrice{Currency: s.currency, VariantSalePrices: make(map[int]money.Amount, len(p.Variants))}
+		sale := ps.sale(p)
+		pp.SalePrice = applySale(sale, p.Price)
+		for j := range p.Variants {
+			price, _, _ := sellingDetails(p, &p.Variants[j])
+			pp.VariantSalePrices[p.Variants[j].ID] = applySale(sale, price)
+		}
+		if sale != nil {
+			pp.Promotions = append(pp.Promotions, *sale)
+		}
+		if offer := ps.buyXGetY(p); offer != nil {
+			pp.Promotions = append(pp.Promotions, *offer)
+		}
+		prices[i] = pp
+	}
+	return prices, nil
+}
+
+// PriceProduct works out what p sells for right now.
+func (s *Server) PriceProduct(ctx context.Context, p *storer.Product) (ProductPrice, error) {
+	prices, err := s.PriceProducts(ctx, []storer.Product{*p})
+	if err != nil {
+		return ProductPrice{}, err
+	}
+	return prices[0], nil
+}
+
+// checkPromotion makes sure the categories and products p covers exist, and
+// drops duplicates among them.
+func (s *Server) checkPromotion(ctx context.Context, p *storer.Promotion) error {
+	p.CategoryIDs, p.ProductIDs = uniqueIDs(p.CategoryIDs), uniqueIDs(p.ProductIDs)
+	for _, id := range p.CategoryIDs {
+		if _, err := s.storer.GetCategory(ctx, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: category %d not found", ErrInvalidPromotion, id)
+			}
+			return err
+		}
+	}
+	for _, id := range p.ProductIDs {
+		if _, err := s.storer.GetProduct(ctx, id); err != nil {
+			if errors.Is(err, sql.ErrNoRows) {
+				return fmt.Errorf("%w: product %d not found", ErrInvalidPromotion, id)
+			}
+			return err
+		}
+	}
+	return nil
+}
+
+// promotionSet is the promotions running at one moment, along with the
+// category tree needed to tell which products they cover.
+type promotionSet struct {
+	promotions []storer.Promotion
+	categories map[int]storer.Category
+}
+
+func (s *Server) runningPromotions(ctx context.Context) (*promotionSet, error) {
+	promotions, err := s.storer.ListPromotions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	categories, err := s.storer.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	ps := &promotionSet{categories: make(map[int]storer.Category, len(categories))}
+	for _, c := range categories {
+		ps.categories[c.ID] = c
+	}
+	now := time.Now()
+	for _, p := range promotions {
+		if p.Running(now) {
+			ps.promotions = append(ps.promotions, p)
+		}
+	}
+	return ps, nil
+}
+
+// sale returns the sale with the largest discount on p, or nil if p is not
+// on sale.
+func (ps *promotionSet) sale(p *storer.Product) *storer.Promotion {
+	var best *storer.Promotion
+	for i := range ps.promotions {
+		promo := &ps.promotions[i]
+		if promo.Type != storer.PromotionSale || !covers(promo.ProductIDs, promo.CategoryIDs, p, ps.categories) {
+			continue
+		}
+		if best == nil || promo.Percent > best.Percent {
+			best = promo
+		}
+	}
+	return best
+}
+
+// buyXGetY returns the buy X get Y offer on p that gives away the largest
+// share of the units, or nil if there is none.
+func (ps *promotionSet) buyXGetY(p *storer.Product) *storer.Promotion {
+	var best *storer.Promotion
+	for i := range ps.promotions {
+		promo := &ps.promotions[i]
+		if promo.Type != storer.PromotionBuyXGetY || !covers(promo.ProductIDs, promo.CategoryIDs, p, ps.categories) {
+			continue
+		}
+		// Compare GetQuantity/(BuyQuantity+GetQuantity) without division.
+		if best == nil || promo.GetQuantity*(best.BuyQuantity+best.GetQuantity) > best.GetQuantity*(promo.BuyQuantity+promo.GetQuantity) {
+			best = promo
+		}
+	}
+	return best
+}
+
+// salePrice returns price, which p or one of its variants sells for, with
+// the sale on p taken off.
+func (ps *promotionSet) salePrice(p *storer.Product, price money.Amount) money.Amount {
+	return applySale(ps.sale(p), price)
+}
+
+// lineDiscounts returns the buy X get Y discount on each line, where line i
+// has quantities[i] units of products[i] at prices[i]. Units of the same
+// product count together across lines, so different variants add up, and
+// the cheapest units are the free ones.
+func (ps *promotionSet) lineDiscounts(products []*storer.Product, prices []money.Amount, quantities []int) []money.Amount {
+	discounts := make([]money.Amount, len(products))
+	lines := make(map[int][]int)
+	var productIDs []int
+	for i, p := range products {
+		if _, ok := lines[p.ID]; !ok {
+			productIDs = append(productIDs, p.ID)
+		}
+		lines[p.ID] = append(lines[p.ID], i)
+	}
+	for _, id := range productIDs {
+		idx := lines[id]
+		offer := ps.buyXGetY(products[idx[0]])
+		if offer == nil {
+			continue
+		}
+		units := 0
+		for _, i := range idx {
+			units += quantities[i]
+		}
+		free := units / (offer.BuyQuantity + offer.GetQuantity) * offer.GetQuantity
+		sort.SliceStable(idx, func(a, b int) bool { return prices[idx[a]] < prices[idx[b]] })
+		for _, i := range idx {
+			n := min(free, quantities[i])
+			discounts[i] = prices[i].Times(n)
+			free -= n
+		}
+	}
+	return discounts
+}
+
+// applySale takes sale off price. The discount is rounded to the nearest
+// minor unit, so the sale price is too.
+func applySale(sale *storer.Promotion, price money.Amount) money.Amount {
+	if sale == nil {
+		return price
+	}
+	return price - price.Percent(sale.Percent)
+}
+
+// covers reports whether a coupon or promotion restricted to productIDs and
+// categoryIDs covers p. Without any restriction it covers every product. A
+// category also covers its subcategories.
+func covers(productIDs, categoryIDs []int, p *storer.Product, categories map[int]storer.Category) bool {
+	if len(productIDs) == 0 && len(categoryIDs) == 0 {
+		return true
+	}
+	for _, id := range productIDs {
+		if id == p.ID {
+			return true
+		}
+	}
+	// The depth bound guards against a cycle left behind by a bad update.
+	for id, depth := p.CategoryID, 0; id != nil && depth <= len(categories); depth++ {
+		for _, cid := range categoryIDs {
+			if cid == *id {
+				return true
+			}
+		}
+		id = categories[*id].ParentID
+	}
+	return false
+}
@@ -0,0 +1,159 @@
+package server
+
+import (
+	"context"
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/storer"
+	"testing"
+	"time"
+)
+
+func TestPromotionPricing(t *testing.T) {
+	past, future := time.Now().Add(-time.Hour), time.Now().Add(time.Hour)
+	type item struct {
+		product string
+		qty     int
+	}
+	tests := []struct {
+		name       string
+		promotions []storer.Promotion
+		// covers lists the products, or the books category, that each
+		// promotion is restricted to.
+		covers        [][]string
+		items         []item
+		wantItems     money.Amount
+		wantPromotion money.Amount
+	}{
+		{
+			name:       "sale",
+			promotions: []storer.Promotion{{Type: storer.PromotionSale, Percent: 20}},
+			items:      []item{{"mug", 1}},
+			wantItems:  800,
+		},
+		{
+			name:       "largest sale wins",
+			promotions: []storer.Promotion{{Type: storer.PromotionSale, Percent: 10}, {Type: storer.PromotionSale, Percent: 25}},
+			covers:     [][]string{nil, {"mug"}},
+			items:      []item{{"mug", 1}, {"lamp", 1}},
+			wantItems:  750 + 5400,
+		},
+		{
+			name:       "sale price rounds to the nearest cent",
+			promotions: []storer.Promotion{{Type: storer.PromotionSale, Percent: 12.345}},
+			items:      []item{{"lamp", 1}},
+			wantItems:  5259,
+		},
+		{
+			name:       "sale on a category covers its subcategories",
+			promotions: []storer.Promotion{{Type: storer.PromotionSale, Percent: 50}},
+			covers:     [][]string{{"books"}},
+			items:      []item{{"novel", 1}, {"mug", 1}},
+			wantItems:  500 + 1000,
+		},
+		{
+			name:       "sale not started",
+			promotions: []storer.Promotion{{Type: storer.PromotionSale, Percent: 20, StartsAt: &future}},
+			items:      []item{{"mug", 1}},
+			wantItems:  1000,
+		},
+		{
+			name:       "sale ended",
+			promotions: []storer.Promotion{{Type: storer.PromotionSale, Percent: 20, EndsAt: &past}},
+			items:      []item{{"mug", 1}},
+			wantItems:  1000,
+		},
+		{
+			name:          "buy 2 get 1",
+			promotions:    []storer.Promotion{{Type: storer.PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1}},
+			items:         []item{{"mug", 5}},
+			wantItems:     5000,
+			wantPromotion: 1000,
+		},
+		{
+			name:          "best share of free units wins",
+			promotions:    []storer.Promotion{{Type: storer.PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1}, {Type: storer.PromotionBuyXGetY, BuyQuantity: 3, GetQuantity: 2}},
+			items:         []item{{"mug", 5}},
+			wantItems:     5000,
+			wantPromotion: 2000,
+		},
+		{
+			name:          "cheapest variant is free",
+			promotions:    []storer.Promotion{{Type: storer.PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1}},
+			items:         []item{{"tee-l", 2}, {"tee-s", 1}},
+			wantItems:     3400,
+			wantPromotion: 1000,
+		},
+		{
+			name:          "sale and buy x get y stack",
+			promotions:    []storer.Promotion{{Type: storer.PromotionSale, Percent: 10}, {Type: storer.PromotionBuyXGetY, BuyQuantity: 2, GetQuantity: 1}},
+			items:         []item{{"mug", 3}},
+			wantItems:     2700,
+			wantPromotion: 900,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, st := newTestServer(t)
+			books, err := st.CreateCategory(ctx, &storer.Category{Name: "Books", Slug: "books"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			novels, err := st.CreateCategory(ctx, &storer.Category{Name: "Novels", Slug: "novels", ParentID: &books.ID})
+			if err != nil {
+				t.Fatal(err)
+			}
+			tee := createProduct(t, st, &storer.Product{Name: "tee", Price: 1000})
+			small := money.Amount(1000)
+			large := money.Amount(1200)
+			variants := make(map[string]int)
+			for name, v := range map[string]*storer.ProductVariant{
+				"tee-s": {ProductID: tee.ID, SKU: "TEE-S", OptionValues: storer.OptionValues{"Size": "S"}, Price: &small, CountInStock: 10},
+				"tee-l": {ProductID: tee.ID, SKU: "TEE-L", OptionValues: storer.OptionValues{"Size": "L"}, Price: &large, CountInStock: 10},
+			} {
+				created, err := st.CreateVariant(ctx, v)
+				if err != nil {
+					t.Fatal(err)
+				}
+				variants[name] = created.ID
+			}
+			ids := map[string]int{
+				"mug":   createProduct(t, st, &storer.Product{Name: "mug", Price: 1000}).ID,
+				"lamp":  createProduct(t, st, &storer.Product{Name: "lamp", Price: 6000}).ID,
+				"novel": createProduct(t, st, &storer.Product{Name: "novel", Price: 1000, CategoryID: &novels.ID}).ID,
+				"books": books.ID,
+			}
+
+			for i, p := range tt.promotions {
+				p.Name = tt.name
+				if i < len(tt.covers) {
+					for _, name := range tt.covers[i] {
+						if name == "books" {
+							p.CategoryIDs = append(p.CategoryIDs, ids[name])
+						} else {
+							p.ProductIDs = append(p.ProductIDs, ids[name])
+						}
+					}
+				}
+				if _, err := s.CreatePromotion(ctx, &p); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			o := &storer.Order{ShippingCountry: "US"}
+			for _, it := range tt.items {
+				oi := storer.OrderItem{ProductID: ids[it.product], Quantity: it.qty}
+				if id, ok := variants[it.product]; ok {
+					oi.ProductID, oi.VariantID = tee.ID, &id
+				}
+				o.Items = append(o.Items, oi)
+			}
+			if err := s.priceOrder(ctx, o); err != nil {
+				t.Fatal(err)
+			}
+			if o.ItemsPrice != tt.wantItems || o.PromotionPrice != tt.wantPromotion {
+				t.Errorf("items %d, promotion %d, want %d and %d", o.ItemsPrice, o.PromotionPrice, tt.wantItems, tt.wantPromotion)
+			}
+		})
+	}
+}
@@ -0,0 +1,97 @@
+package server
+
+import (
+	"context"
+	"ecom_apiv1/internal/storer"
+	"errors"
+	"fmt"
+	"strings"
+)
+
+// ErrNotVerifiedBuyer is returned when a customer reviews a product they
+// have no paid order for.
+var ErrNotVerifiedBuyer = errors.New("only customers who bought the product can review it")
+
+// ErrInvalidModeration is returned for moderation requests that do not name a
+// final status, or that reject reviews without a reason.
+var ErrInvalidModeration = errors.New("invalid moderation")
+
+// CreateReview adds a review by a verified buyer of the product. The review
+// is published right away unless the review filter holds it for moderation.
+func (s *Server) CreateReview(ctx context.Context, r *storer.Review) (*storer.Review, error) {
+	if _, err := s.storer.GetProduct(ctx, r.ProductID); err != nil {
+		return nil, err
+	}
+	bought, err := s.storer.HasPurchased(ctx, r.UserID, r.ProductID)
+	if err != nil {
+		return nil, err
+	}
+	if !bought {
+		return nil, ErrNotVerifiedBuyer
+	}
+	r.Status, r.ModerationReason = storer.ReviewStatusApproved, ""
+	s.screenReview(r)
+	return s.storer.CreateReview(ctx, r)
+}
+
+func (s *Server) GetReview(ctx context.Context, id int) (*storer.Review, error) {
+	return s.storer.GetReview(ctx, id)
+}
+
+// ListProductReviews lists the published reviews of a product. It returns an
+// error wrapping sql.ErrNoRows if the product does not exist.
+func (s *Server) ListProductReviews(ctx context.Context, productID int, sort storer.ReviewSort, page storer.Page) ([]storer.Review, *storer.Cursor, error) {
+	if _, err := s.storer.GetProduct(ctx, productID); err != nil {
+		return nil, nil, err
+	}
+	return s.storer.ListProductReviews(ctx, productID, sort, page)
+}
+
+// UpdateReview saves an edited review. Edits go through the review filter
+// again, and an edited rejected review goes back to the moderation queue.
+func (s *Server) UpdateReview(ctx context.Context, r *storer.Review) (*storer.Review, error) {
+	if r.Status == storer.ReviewStatusRejected {
+		r.Status, r.ModerationReason = storer.ReviewStatusPending, "edited after rejection"
+	}
+	s.screenReview(r)
+	return s.storer.UpdateReview(ctx, r)
+}
+
+func (s *Server) DeleteReview(ctx context.Context, id int) error {
+	return s.storer.DeleteReview(ctx, id)
+}
+
+func (s *Server) ListReviewsByStatus(ctx context.Context, status storer.ReviewStatus, page storer.Page) ([]storer.Review, *storer.Cursor, error) {
+	return s.storer.ListReviewsByStatus(ctx, status, page)
+}
+
+// ModerateReviews approves or rejects reviews. A rejection needs a reason,
+// which is shown to the reviewer.
+func (s *Server) ModerateReviews(ctx context.Context, ids []int, status storer.ReviewStatus, reason string) ([]storer.Review, error) {
+	if status != storer.ReviewStatusApproved && status != storer.ReviewStatusRejected {
+		return nil, fmt.Errorf("%w: status must be approved or rejected", ErrInvalidModeration)
+	}
+	if status == storer.ReviewStatusRejected && reason == "" {
+		return nil, fmt.Errorf("%w: a reason is required to reject reviews", ErrInvalidModeration)
+	}
+	if len(ids) == 0 {
+		return nil, fmt.Errorf("%w: no reviews given", ErrInvalidModeration)
+	}
+	seen := make(map[int]bool, len(ids))
+	unique := ids[:0:0]
+	for _, id := range ids {
+		if !seen[id] {
+			seen[id] = true
+			unique = append(unique, id)
+		}
+	}
+	return s.storer.ModerateReviews(ctx, unique, status, reason)
+}
+
+// screenReview holds r for moderation if it contains banned words.
+func (s *Server) screenReview(r *storer.Review) {
+	if words := s.reviews.Match(r.Title, r.Body); len(words) > 0 {
+		r.Status = storer.ReviewStatusPending
+		r.ModerationReason = "contains banned words: " + strings.Join(words, ", ")
+	}
+}
@@ -0,0 +1,68 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"ecom_apiv1/internal/storer"
+	"errors"
+	"testing"
+)
+
+func TestCreateReviewNeedsPurchase(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      storer.OrderStatus
+		product     string
+		twice       bool
+		wantErr     error
+		wantRating  float64
+		wantReviews int
+	}{
+		{name: "no paid order", status: storer.OrderStatusPending, product: "mug", wantErr: ErrNotVerifiedBuyer},
+		{name: "cancelled order", status: storer.OrderStatusCancelled, product: "mug", wantErr: ErrNotVerifiedBuyer},
+		{name: "paid order", status: storer.OrderStatusPaid, product: "mug", wantRating: 4, wantReviews: 1},
+		{name: "second review", status: storer.OrderStatusPaid, product: "mug", twice: true, wantErr: storer.ErrDuplicateReview, wantRating: 4, wantReviews: 1},
+		{name: "unknown product", status: storer.OrderStatusPaid, product: "ghost", wantErr: sql.ErrNoRows},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			s, st := newTestServer(t)
+			mug := createProduct(t, st, &storer.Product{Name: "mug", Price: 1000})
+			u, err := st.CreateUser(ctx, &storer.User{Name: "buyer", Email: "buyer@example.com", Password: "x"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			o, err := s.CreateOrder(ctx, &storer.Order{UserId: u.ID, ShippingCountry: "US", Items: []storer.OrderItem{{ProductID: mug.ID, Quantity: 1}}})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if tt.status != storer.OrderStatusPending {
+				if _, err := s.UpdateOrderStatus(ctx, o.ID, tt.status, u.ID, "test"); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			productID := map[string]int{"mug": mug.ID, "ghost": 999}[tt.product]
+			review := func() error {
+				_, err := s.CreateReview(ctx, &storer.Review{ProductID: productID, UserID: u.ID, Rating: 4, Title: "Nice", Body: "Holds coffee."})
+				return err
+			}
+			err = review()
+			if tt.twice && err == nil {
+				err = review()
+			}
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+
+			p, err := st.GetProduct(ctx, mug.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if p.Rating != tt.wantRating || p.NumReviews != tt.wantReviews {
+				t.Errorf("rating %v from %d reviews, want %v from %d", p.Rating, p.NumReviews, tt.wantRating, tt.wantReviews)
+			}
+		})
+	}
+}
@@ -2,20 +2,48 @@ package server
 
 import (
 	"context"
+	"ecom_apiv1/internal/blob"
+	"ecom_apiv1/internal/moderation"
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/shipping"
 	"ecom_apiv1/internal/storer"
+	"ecom_apiv1/internal/tax"
+	"time"
 )
 
 type Server struct {
-	storer *storer.MySQLStorage
+	storer   storer.Storer
+	tax      tax.Calculator
+	shipping shipping.Calculator
+	blobs    blob.Store
+	reviews  moderation.Filter
+
+	// currency is what the catalog is priced and orders are placed in.
+	currency     money.Currency
+	guestCartTTL time.Duration
 }
 
-func NewServer(storer *storer.MySQLStorage) *Server {
+func NewServer(storer storer.Storer, taxCalculator tax.Calculator, shippingCalculator shipping.Calculator, blobs blob.Store, reviewFilter moderation.Filter, currency money.Currency, guestCartTTL time.Duration) *Server {
 	return &Server{
-		storer: storer,
+		storer:       storer,
+		tax:          taxCalculator,
+		shipping:     shippingCalculator,
+		blobs:        blobs,
+		reviews:      reviewFilter,
+		currency:     currency,
+		guestCartTTL: guestCartTTL,
 	}
 }
 
+// Currency returns the store currency, which every price is in.
+func (s *Server) Currency() money.Currency {
+	return s.currency
+}
+
 func (s *Server) CreateProduct(ctx context.Context, p *storer.Product) (*storer.Product, error) {
+	if err := s.checkProductCategory(ctx, p); err != nil {
+		return nil, err
+	}
 	return s.storer.CreateProduct(ctx, p)
 }
 
@@ -23,19 +51,48 @@ func (s *Server) GetProduct(ctx context.Context, id int) (*storer.Product, error
 	return s.storer.GetProduct(ctx, id)
 }
 
-func (s *Server) ListProducts(ctx context.Context) ([]storer.Product, error) {
-	return s.storer.ListProducts(ctx)
+func (s *Server) ListProducts(ctx context.Context, filter storer.ProductFilter, page storer.Page) ([]storer.Product, *storer.Cursor, error) {
+	return s.storer.ListProducts(ctx, filter, page)
 }
 
+func (s *Server) ProductFacets(ctx context.Context, filter storer.ProductFilter) (*storer.ProductFacets, error) {
+	return s.storer.ProductFacets(ctx, filter)
+}
+
+// UpdateProduct saves p. The stock of a product with variants always stays
+// the sum of the variants' stock.
 func (s *Server) UpdateProduct(ctx context.Context, p *storer.Product) (*storer.Product, error) {
+	if err := s.checkProductCategory(ctx, p); err != nil {
+		return nil, err
+	}
+	if len(p.Variants) > 0 {
+		p.CountInStock = 0
+		for _, v := range p.Variants {
+			p.CountInStock += v.CountInStock
+		}
+	}
 	return s.storer.UpdateProduct(ctx, p)
 }
 
+// DeleteProduct removes a product along with the blobs of its images.
 func (s *Server) DeleteProduct(ctx context.Context, id int) error {
-	return s.storer.DeleteProduct(ctx, id)
+	p, err := s.storer.GetProduct(ctx, id)
+	if err != nil {
+		return err
+	}
+	if err := s.storer.DeleteProduct(ctx, id); err != nil {
+		return err
+	}
+	for i := range p.Images {
+		s.deleteImageBlobs(ctx, &p.Images[i])
+	}
+	return nil
 }
 
 func (s *Server) CreateOrder(ctx context.Context, o *storer.Order) (*storer.Order, error) {
+	if err := s.priceOrder(ctx, o); err != nil {
+		return nil, err
+	}
 	return s.storer.CreateOrder(ctx, o)
 }
 
@@ -43,12 +100,34 @@ func (s *Server) GetOrder(ctx context.Context, id int) (*storer.Order, error) {
 	return s.storer.GetOrder(ctx, id)
 }
 
-func (s *Server) ListOrders(ctx context.Context) ([]storer.Order, error) {
-	return s.storer.ListOrders(ctx)
+func (s *Server) ListUserOrders(ctx context.Context, userId int, page storer.Page) ([]storer.Order, *storer.Cursor, error) {
+	return s.storer.ListUserOrders(ctx, userId, page)
+}
+
+func (s *Server) ListOrders(ctx context.Context, page storer.Page) ([]storer.Order, *storer.Cursor, error) {
+	return s.storer.ListOrders(ctx, page)
+}
+
+// customerCancellable are the statuses in which customers may cancel their
+// own orders. A paid order is closed by an admin recording its refund, and an
+// order being processed can only be cancelled by an admin.
+var customerCancellable = []storer.OrderStatus{storer.OrderStatusPending}
+
+// CancelOrder cancels an order. Admins may cancel it in any status that
+// allows cancelling, customers only before it is paid.
+func (s *Server) CancelOrder(ctx context.Context, id int, changedBy int, byAdmin bool, reason string) (*storer.Order, error) {
+	if byAdmin {
+		return s.storer.UpdateOrderStatus(ctx, id, storer.OrderStatusCancelled, changedBy, reason)
+	}
+	return s.storer.CancelOrder(ctx, id, customerCancellable, changedBy, reason)
+}
+
+func (s *Server) UpdateOrderStatus(ctx context.Context, id int, status storer.OrderStatus, changedBy int, reason string) (*storer.Order, error) {
+	return s.storer.UpdateOrderStatus(ctx, id, status, changedBy, reason)
 }
 
-func (s *Server) DeleteOrder(ctx context.Context, id int) error {
-	return s.storer.DeleteOrders(ctx, id)
+func (s *Server) ListOrderStatusHistory(ctx context.Context, orderID int) ([]storer.OrderStatusChange, error) {
+	return s.storer.ListOrderStatusHistory(ctx, orderID)
 }
 
 func (s *Server) CreateUser(ctx context.Context, u *storer.User) (*storer.User, error) {
@@ -59,8 +138,8 @@ func (s *Server) GetUser(ctx context.Context, email string) (*storer.User, error
 	return s.storer.GetUser(ctx, email)
 }
 
-func (s *Server) ListUsers(ctx context.Context) ([]storer.User, error) {
-	return s.storer.ListUsers(ctx)
+func (s *Server) ListUsers(ctx context.Context, page storer.Page) ([]storer.User, *storer.Cursor, error) {
+	return s.storer.ListUsers(ctx, page)
 }
 
 func (s *Server) UpdateUser(ctx context.Context, u *storer.User) (*storer.User, error) {
@@ -86,3 +165,23 @@ func (s *Server) RevokeSession(ctx context.Context, id string) error {
 func (s *Server) DeleteSession(ctx context.Context, id string) error {
 	return s.storer.DeleteSession(ctx, id)
 }
+
+func (s *Server) CreateTaxRate(ctx context.Context, tr *storer.TaxRate) (*storer.TaxRate, error) {
+	return s.storer.CreateTaxRate(ctx, tr)
+}
+
+func (s *Server) GetTaxRate(ctx context.Context, id int) (*storer.TaxRate, error) {
+	return s.storer.GetTaxRate(ctx, id)
+}
+
+func (s *Server) ListTaxRates(ctx context.Context) ([]storer.TaxRate, error) {
+	return s.storer.ListTaxRates(ctx)
+}
+
+func (s *Server) UpdateTaxRate(ctx context.Context, tr *storer.TaxRate) (*storer.TaxRate, error) {
+	return s.storer.UpdateTaxRate(ctx, tr)
+}
+
+func (s *Server) DeleteTaxRate(ctx context.Context, id int) error {
+	return s.storer.DeleteTaxRate(ctx, id)
+}
@@ -0,0 +1,38 @@
+package server
+
+import (
+	"context"
+	"ecom_apiv1/internal/blob"
+	"ecom_apiv1/internal/moderation"
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/shipping"
+	"ecom_apiv1/internal/storer"
+	"ecom_apiv1/internal/tax"
+	"testing"
+	"time"
+)
+
+// newTestServer returns a server over an empty memory store, priced in US
+// dollars with the default tax rate and shipping zones.
+func newTestServer(t *testing.T) (*Server, *storer.MemoryStorage) {
+	t.Helper()
+	st := storer.NewMemoryStorage()
+	blobs, err := blob.NewLocalStore(t.TempDir())
+	if err != nil {
+		t.Fatal(err)
+	}
+	s := NewServer(st, tax.NewRuleCalculator(st), shipping.NewTableCalculator(shipping.DefaultZones), blobs, moderation.NewWordFilter(nil), money.DefaultCurrency, time.Hour)
+	return s, st
+}
+
+func createProduct(t *testing.T, st storer.Storer, p *storer.Product) *storer.Product {
+	t.Helper()
+	if p.CountInStock == 0 {
+		p.CountInStock = 100
+	}
+	p, err := st.CreateProduct(context.Background(), p)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
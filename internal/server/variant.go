@@ -0,0 +1,131 @@
+package server
+
+import (
+	"context"
+	"database/sql"
+	"ecom_apiv1/internal/storer"
+	"errors"
+	"fmt"
+)
+
+// ErrInvalidVariant is returned for product options and variants that do not
+// fit together: a variant must pick exactly one of the choices of every
+// option, and no two variants of a product may pick the same combination.
+var ErrInvalidVariant = errors.New("invalid variant")
+
+// SetProductOptions replaces the options of a product. The options are
+// positioned in the order given, and every existing variant must still fit
+// the new options.
+func (s *Server) SetProductOptions(ctx context.Context, productID int, options []storer.ProductOption) ([]storer.ProductOption, error) {
+	p, err := s.storer.GetProduct(ctx, productID)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool, len(options))
+	for i := range options {
+		o := &options[i]
+		if o.Name == "" {
+			return nil, fmt.Errorf("%w: option name is required", ErrInvalidVariant)
+		}
+		if names[o.Name] {
+			return nil, fmt.Errorf("%w: duplicate option %q", ErrInvalidVariant, o.Name)
+		}
+		names[o.Name] = true
+		if len(o.Choices) == 0 {
+			return nil, fmt.Errorf("%w: option %q needs at least one choice", ErrInvalidVariant, o.Name)
+		}
+		choices := make(map[string]bool, len(o.Choices))
+		for _, c := range o.Choices {
+			if c == "" || choices[c] {
+				return nil, fmt.Errorf("%w: choices of option %q must be unique and not empty", ErrInvalidVariant, o.Name)
+			}
+			choices[c] = true
+		}
+		o.Position = i
+	}
+	for i := range p.Variants {
+		if err := checkVariantOptions(&p.Variants[i], options); err != nil {
+			return nil, fmt.Errorf("%w (variant %s)", err, p.Variants[i].SKU)
+		}
+	}
+	return s.storer.SetProductOptions(ctx, productID, options)
+}
+
+func (s *Server) CreateVariant(ctx context.Context, v *storer.ProductVariant) (*storer.ProductVariant, error) {
+	if err := s.checkVariant(ctx, v); err != nil {
+		return nil, err
+	}
+	return s.storer.CreateVariant(ctx, v)
+}
+
+func (s *Server) GetVariant(ctx context.Context, id int) (*storer.ProductVariant, error) {
+	return s.storer.GetVariant(ctx, id)
+}
+
+func (s *Server) UpdateVariant(ctx context.Context, v *storer.ProductVariant) (*storer.ProductVariant, error) {
+	if err := s.checkVariant(ctx, v); err != nil {
+		return nil, err
+	}
+	return s.storer.UpdateVariant(ctx, v)
+}
+
+func (s *Server) DeleteVariant(ctx context.Context, id int) error {
+	return s.storer.DeleteVariant(ctx, id)
+}
+
+// checkVariant validates v against the options and the other variants of its
+// product.
+func (s *Server) checkVariant(ctx context.Context, v *storer.ProductVariant) error {
+	p, err := s.storer.GetProduct(ctx, v.ProductID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return fmt.Errorf("%w: product %d not found", ErrInvalidVariant, v.ProductID)
+		}
+		return err
+	}
+	if err := checkVariantOptions(v, p.Options); err != nil {
+		return err
+	}
+	for _, other := range p.Variants {
+		if other.ID != v.ID && sameOptionValues(other.OptionValues, v.OptionValues) {
+			return fmt.Errorf("%w: variant %s already has these options", ErrInvalidVariant, other.SKU)
+		}
+	}
+	return nil
+}
+
+func checkVariantOptions(v *storer.ProductVariant, options []storer.ProductOption) error {
+	if len(v.OptionValues) != len(options) {
+		return fmt.Errorf("%w: a variant must choose a value for each of the %d options", ErrInvalidVariant, len(options))
+	}
+	for _, o := range options {
+		value, ok := v.OptionValues[o.Name]
+		if !ok {
+			return fmt.Errorf("%w: missing value for option %q", ErrInvalidVariant, o.Name)
+		}
+		found := false
+		for _, c := range o.Choices {
+			if c == value {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return fmt.Errorf("%w: %q is not a choice of option %q", ErrInvalidVariant, value, o.Name)
+		}
+	}
+	return nil
+}
+
+func sameOptionValues(a, b storer.OptionValues) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for k, v := range a {
+		if b[k] != v {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,286 @@
+package server
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/storer"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// ErrInvalidWishlist is returned for wishlists without a usable name and for
+// items of unknown products or variants.
+var ErrInvalidWishlist = errors.New("invalid wishlist")
+
+const maxWishlistNameLength = 100
+
+// DetailedWishlist is a wishlist with the current details of every product
+// on it. Prices are in Currency.
+type DetailedWishlist struct {
+	*storer.Wishlist
+	Currency money.Currency
+	Lines    []WishlistLine
+}
+
+// WishlistLine is a wishlist item with the product details it sells with
+// right now. InStock is the stock of the product or variant.
+type WishlistLine struct {
+	storer.WishlistItem
+	Name    string
+	SKU     string
+	Image   string
+	Price   money.Amount
+	InStock int
+}
+
+func (s *Server) ListWishlists(ctx context.Context, userID int) ([]DetailedWishlist, error) {
+	wishlists, err := s.storer.ListUserWishlists(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+	res := make([]DetailedWishlist, 0, len(wishlists))
+	for i := range wishlists {
+		dw, err := s.describeWishlist(ctx, &wishlists[i])
+		if err != nil {
+			return nil, err
+		}
+		res = append(res, *dw)
+	}
+	return res, nil
+}
+
+func (s *Server) CreateWishlist(ctx context.Context, userID int, name string) (*DetailedWishlist, error) {
+	name, err := wishlistName(name)
+	if err != nil {
+		return nil, err
+	}
+	w, err := s.storer.CreateWishlist(ctx, &storer.Wishlist{UserID: userID, Name: name})
+	if err != nil {
+		return nil, err
+	}
+	return s.describeWishlist(ctx, w)
+}
+
+// GetWishlist returns a wishlist of the user. It returns an error wrapping
+// sql.ErrNoRows if the user has no such wishlist.
+func (s *Server) GetWishlist(ctx context.Context, userID, id int) (*DetailedWishlist, error) {
+	w, err := s.userWishlist(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	return s.describeWishlist(ctx, w)
+}
+
+func (s *Server) RenameWishlist(ctx context.Context, userID, id int, name string) (*DetailedWishlist, error) {
+	name, err := wishlistName(name)
+	if err != nil {
+		return nil, err
+	}
+	w, err := s.userWishlist(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	w.Name = name
+	return s.updateWishlist(ctx, w)
+}
+
+func (s *Server) DeleteWishlist(ctx context.Context, userID, id int) error {
+	if _, err := s.userWishlist(ctx, userID, id); err != nil {
+		return err
+	}
+	return s.storer.DeleteWishlist(ctx, id)
+}
+
+// AddWishlistItem saves a product, or one of its variants, on a wishlist of
+// the user. Unlike a cart line, the item does not need a variant yet; adding
+// something the wishlist already holds changes nothing.
+func (s *Server) AddWishlistItem(ctx context.Context, userID int, item *storer.WishlistItem) (*DetailedWishlist, error) {
+	w, err := s.userWishlist(ctx, userID, item.WishlistID)
+	if err != nil {
+		return nil, err
+	}
+	p, err := s.storer.GetProduct(ctx, item.ProductID)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: product %d not found", ErrInvalidWishlist, item.ProductID)
+		}
+		return nil, err
+	}
+	if item.VariantID != nil && findVariant(p, *item.VariantID) == nil {
+		return nil, fmt.Errorf("%w: variant %d of product %d not found", ErrInvalidWishlist, *item.VariantID, p.ID)
+	}
+	if _, err := s.storer.AddWishlistItem(ctx, item); err != nil {
+		return nil, err
+	}
+	return s.GetWishlist(ctx, userID, w.ID)
+}
+
+// RemoveWishlistItem removes an item from a wishlist of the user. It returns
+// an error wrapping sql.ErrNoRows if the item is not on the wishlist.
+func (s *Server) RemoveWishlistItem(ctx context.Context, userID, wishlistID, itemID int) (*DetailedWishlist, error) {
+	if _, err := s.userWishlist(ctx, userID, wishlistID); err != nil {
+		return nil, err
+	}
+	if err := s.storer.DeleteWishlistItem(ctx, wishlistID, itemID); err != nil {
+		return nil, err
+	}
+	return s.GetWishlist(ctx, userID, wishlistID)
+}
+
+// MoveWishlistItemToCart adds quantity of a wishlist item to the user's cart
+// and takes it off the wishlist. variantID picks the variant for an item
+// saved without one; nil keeps the item's own. The item stays on the
+// wishlist if the cart does not accept it, for example when it is out of
+// stock.
+func (s *Server) MoveWishlistItemToCart(ctx context.Context, userID, wishlistID, itemID, quantity int, variantID *int) (*PricedCart, error) {
+	w, err := s.userWishlist(ctx, userID, wishlistID)
+	if err != nil {
+		return nil, err
+	}
+	var item *storer.WishlistItem
+	for i := range w.Items {
+		if w.Items[i].ID == itemID {
+			item = &w.Items[i]
+		}
+	}
+	if item == nil {
+		return nil, fmt.Errorf("error getting wishlist item: %w", sql.ErrNoRows)
+	}
+	if variantID == nil {
+		variantID = item.VariantID
+	}
+	owner := CartOwner{UserID: userID}
+	c, err := s.AddCartItem(ctx, owner, &storer.CartItem{
+		ProductID: item.ProductID,
+		VariantID: variantID,
+		Quantity:  quantity,
+	})
+	if err != nil {
+		return nil, err
+	}
+	if err := s.storer.DeleteWishlistItem(ctx, wishlistID, itemID); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// ShareWishlist makes a wishlist of the user readable by anyone with its
+// share token. A wishlist that is already shared keeps its token.
+func (s *Server) ShareWishlist(ctx context.Context, userID, id int) (*DetailedWishlist, error) {
+	w, err := s.userWishlist(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	if w.ShareToken != nil {
+		return s.describeWishlist(ctx, w)
+	}
+	b := make([]byte, 32)
+	if _, err := rand.Read(b); err != nil {
+		return nil, fmt.Errorf("error generating share token: %w", err)
+	}
+	token := hex.EncodeToString(b)
+	w.ShareToken = &token
+	return s.updateWishlist(ctx, w)
+}
+
+// UnshareWishlist revokes the share token of a wishlist of the user. Sharing
+// it again gives it a new token, so old links stay dead.
+func (s *Server) UnshareWishlist(ctx context.Context, userID, id int) (*DetailedWishlist, error) {
+	w, err := s.userWishlist(ctx, userID, id)
+	if err != nil {
+		return nil, err
+	}
+	w.ShareToken = nil
+	return s.updateWishlist(ctx, w)
+}
+
+// GetSharedWishlist returns the wishlist with the share token. It returns an
+// error wrapping sql.ErrNoRows if no wishlist is shared with that token.
+func (s *Server) GetSharedWishlist(ctx context.Context, token string) (*DetailedWishlist, error) {
+	w, err := s.storer.GetWishlistByShareToken(ctx, token)
+	if err != nil {
+		return nil, err
+	}
+	return s.describeWishlist(ctx, w)
+}
+
+func (s *Server) ListWishlistedProducts(ctx context.Context, page storer.Page) ([]storer.WishlistedProduct, *storer.Cursor, error) {
+	return s.storer.ListWishlistedProducts(ctx, page)
+}
+
+// userWishlist loads a wishlist and makes sure it belongs to the user. Other
+// users' wishlists are reported as missing.
+func (s *Server) userWishlist(ctx context.Context, userID, id int) (*storer.Wishlist, error) {
+	w, err := s.storer.GetWishlist(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if w.UserID != userID {
+		return nil, fmt.Errorf("error getting wishlist: %w", sql.ErrNoRows)
+	}
+	return w, nil
+}
+
+func (s *Server) updateWishlist(ctx context.Context, w *storer.Wishlist) (*DetailedWishlist, error) {
+	now := time.Now()
+	w.UpdatedAt = &now
+	updated, err := s.storer.UpdateWishlist(ctx, w)
+	if err != nil {
+		return nil, err
+	}
+	return s.describeWishlist(ctx, updated)
+}
+
+// describeWishlist looks up the current details of every item of w. Prices
+// are after any running sale.
+func (s *Server) describeWishlist(ctx context.Context, w *storer.Wishlist) (*DetailedWishlist, error) {
+	ps, err := s.runningPromotions(ctx)
+	if err != nil {
+		return nil, err
+	}
+	dw := &DetailedWishlist{Wishlist: w, Currency: s.currency, Lines: make([]WishlistLine, 0, len(w.Items))}
+	products := make(map[int]*storer.Product)
+	for _, item := range w.Items {
+		p, ok := products[item.ProductID]
+		if !ok {
+			var err error
+			p, err = s.storer.GetProduct(ctx, item.ProductID)
+			if err != nil {
+				return nil, err
+			}
+			products[p.ID] = p
+		}
+		var v *storer.ProductVariant
+		if item.VariantID != nil {
+			v = findVariant(p, *item.VariantID)
+		}
+		line := WishlistLine{WishlistItem: item, Name: p.Name, InStock: p.CountInStock}
+		line.Price, line.Image, line.SKU = sellingDetails(p, v)
+		line.Price = ps.salePrice(p, line.Price)
+		switch {
+		case v != nil:
+			line.InStock = v.CountInStock
+		case item.VariantID != nil:
+			// The variant was deleted since the item was saved.
+			line.InStock = 0
+		}
+		dw.Lines = append(dw.Lines, line)
+	}
+	return dw, nil
+}
+
+func wishlistName(name string) (string, error) {
+	name = strings.TrimSpace(name)
+	if name == "" {
+		return "", fmt.Errorf("%w: name is required", ErrInvalidWishlist)
+	}
+	if len(name) > maxWishlistNameLength {
+		return "", fmt.Errorf("%w: name must be at most %d characters", ErrInvalidWishlist, maxWishlistNameLength)
+	}
+	return name, nil
+}
@@ -0,0 +1,30 @@
+package shipping
+
+import (
+	"context"
+	"ecom_apiv1/internal/money"
+)
+
+// Calculator quotes the shipping methods available for a parcel.
+type Calculator interface {
+	Quote(ctx context.Context, req Request) ([]Option, error)
+}
+
+// Request describes a parcel. Weight is in kilograms and ItemsPrice is the
+// order subtotal in Currency, which decides free shipping. Options are
+// priced in Currency too.
+type Request struct {
+	Country    string
+	Region     string
+	Weight     float64
+	Currency   money.Currency
+	ItemsPrice money.Amount
+}
+
+type Option struct {
+	Method string
+	Name   string
+	Zone   string
+	Price  money.Amount
+	Days   string
+}
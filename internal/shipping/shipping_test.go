@@ -0,0 +1,67 @@
+package shipping
+
+import (
+	"context"
+	"ecom_apiv1/internal/money"
+	"maps"
+	"testing"
+)
+
+var testZones = []Zone{
+	{
+		Name:      "EU",
+		Countries: []string{"DE", "FR"},
+		Methods: []Method{
+			{Code: "standard", BaseFee: "4", PerKg: "0.50", FreeOver: "50"},
+		},
+	},
+	DefaultZones[0],
+}
+
+func TestTableCalculatorQuote(t *testing.T) {
+	tests := []struct {
+		name       string
+		zones      []Zone
+		country    string
+		weight     float64
+		itemsPrice money.Amount
+		currency   money.Currency
+		want       map[string]money.Amount
+		wantErr    bool
+	}{
+		{"zone by country", testZones, "DE", 0, 1000, "USD", map[string]money.Amount{"standard": 400}, false},
+		{"country in any case", testZones, "fr", 0, 1000, "USD", map[string]money.Amount{"standard": 400}, false},
+		{"every started kilogram", testZones, "DE", 2.1, 1000, "USD", map[string]money.Amount{"standard": 550}, false},
+		{"free over the threshold", testZones, "DE", 2.1, 5000, "USD", map[string]money.Amount{"standard": 0}, false},
+		{"fallback zone", testZones, "US", 1, 1000, "USD", map[string]money.Amount{"standard": 600, "express": 1750}, false},
+		{"free standard only", testZones, "US", 1, 10000, "USD", map[string]money.Amount{"standard": 0, "express": 1750}, false},
+		{"too heavy for express", testZones, "US", 31, 1000, "USD", map[string]money.Amount{"standard": 3600}, false},
+		{"currency without cents", []Zone{{Methods: []Method{{Code: "standard", BaseFee: "500", PerKg: "100"}}}}, "JP", 1.5, 1000, "JPY", map[string]money.Amount{"standard": 700}, false},
+		{"no zone", testZones[:1], "US", 1, 1000, "USD", nil, true},
+		{"fee with too many decimals", []Zone{{Methods: []Method{{Code: "standard", BaseFee: "4.999"}}}}, "US", 1, 1000, "USD", nil, true},
+	}
+	for _, tt := range tests {
+		options, err := NewTableCalculator(tt.zones).Quote(context.Background(), Request{
+			Country:    tt.country,
+			Weight:     tt.weight,
+			Currency:   tt.currency,
+			ItemsPrice: tt.itemsPrice,
+		})
+		if tt.wantErr {
+			if err == nil {
+				t.Errorf("%s: got %v, want an error", tt.name, options)
+			}
+			continue
+		}
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		got := make(map[string]money.Amount)
+		for _, o := range options {
+			got[o.Method] = o.Price
+		}
+		if !maps.Equal(got, tt.want) {
+			t.Errorf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
@@ -0,0 +1,130 @@
+package shipping
+
+import (
+	"context"
+	"ecom_apiv1/internal/money"
+	"encoding/json"
+	"fmt"
+	"math"
+	"os"
+	"strings"
+)
+
+// Zone groups destination countries that share shipping methods. A zone
+// without countries catches every destination no other zone lists.
+type Zone struct {
+	Name      string   `json:"name"`
+	Countries []string `json:"countries"`
+	Methods   []Method `json:"methods"`
+}
+
+// Method is priced as BaseFee plus PerKg for every started kilogram. It is
+// free when the order subtotal reaches FreeOver, and unavailable for parcels
+// heavier than MaxWeight. Zero or empty disables either limit. The fees are
+// decimals in the currency of the request.
+type Method struct {
+	Code      string        `json:"code"`
+	Name      string        `json:"name"`
+	BaseFee   money.Decimal `json:"base_fee"`
+	PerKg     money.Decimal `json:"per_kg"`
+	FreeOver  money.Decimal `json:"free_over"`
+	MaxWeight float64       `json:"max_weight"`
+	Days      string        `json:"days"`
+}
+
+var DefaultZones = []Zone{
+	{
+		Name: "Worldwide",
+		Methods: []Method{
+			{Code: "standard", Name: "Standard", BaseFee: "5", PerKg: "1", FreeOver: "100", Days: "5-7"},
+			{Code: "express", Name: "Express", BaseFee: "15", PerKg: "2.5", MaxWeight: 30, Days: "1-2"},
+		},
+	},
+}
+
+// LoadZones reads zones from a JSON file holding an array of Zone.
+func LoadZones(path string) ([]Zone, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("error reading shipping zones: %w", err)
+	}
+	var zones []Zone
+	if err := json.Unmarshal(b, &zones); err != nil {
+		return nil, fmt.Errorf("error parsing shipping zones: %w", err)
+	}
+	return zones, nil
+}
+
+// TableCalculator quotes shipping from a fixed table of zones.
+type TableCalculator struct {
+	zones []Zone
+}
+
+func NewTableCalculator(zones []Zone) *TableCalculator {
+	return &TableCalculator{
+		zones: zones,
+	}
+}
+
+func (c *TableCalculator) Quote(ctx context.Context, req Request) ([]Option, error) {
+	zone := c.zoneFor(req.Country)
+	if zone == nil {
+		return nil, fmt.Errorf("no shipping zone for country %q", req.Country)
+	}
+
+	var options []Option
+	for _, m := range zone.Methods {
+		if m.MaxWeight > 0 && req.Weight > m.MaxWeight {
+			continue
+		}
+		baseFee, perKg, freeOver, err := m.fees(req.Currency)
+		if err != nil {
+			return nil, fmt.Errorf("shipping method %q: %w", m.Code, err)
+		}
+		price := baseFee + perKg.Times(int(math.Ceil(req.Weight)))
+		if freeOver > 0 && req.ItemsPrice >= freeOver {
+			price = 0
+		}
+		options = append(options, Option{
+			Method: m.Code,
+			Name:   m.Name,
+			Zone:   zone.Name,
+			Price:  price,
+			Days:   m.Days,
+		})
+	}
+	return options, nil
+}
+
+// fees parses the fees of m in currency c. Empty fees are zero.
+func (m *Method) fees(c money.Currency) (baseFee, perKg, freeOver money.Amount, err error) {
+	amounts := []*money.Amount{&baseFee, &perKg, &freeOver}
+	for i, d := range []money.Decimal{m.BaseFee, m.PerKg, m.FreeOver} {
+		if d == "" {
+			continue
+		}
+		if *amounts[i], err = d.Amount(c); err != nil {
+			return 0, 0, 0, err
+		}
+	}
+	return baseFee, perKg, freeOver, nil
+}
+
+func (c *TableCalculator) zoneFor(country string) *Zone {
+	var fallback *Zone
+	for i := range c.zones {
+		z := &c.zones[i]
+		if len(z.Countries) == 0 {
+			if fallback == nil {
+				fallback = z
+			}
+			continue
+		}
+		for _, zc := range z.Countries {
+			if strings.EqualFold(zc, country) {
+				return z
+			}
+		}
+	}
+	return fallback
+}
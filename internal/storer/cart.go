@@ -0,0 +1,55 @@
+package storer
+
+import "time"
+
+// Cart holds the products a customer intends to buy. A line is identified by
+// its product and variant; adding the same product and variant again raises
+// the quantity of the existing line.
+//
+// A cart belongs either to a user or, for a guest, to whoever holds its
+// Token.
+type Cart struct {
+	ID        int        `db:"id"`
+	UserID    *int       `db:"user_id"`
+	Token     *string    `db:"token"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt *time.Time `db:"updated_at"`
+
+	Items []CartItem `db:"-"`
+}
+
+type CartItem struct {
+	ID        int        `db:"id"`
+	CartID    int        `db:"cart_id"`
+	ProductID int        `db:"product_id"`
+	VariantID *int       `db:"variant_id"`
+	Quantity  int        `db:"quantity"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt *time.Time `db:"updated_at"`
+}
+
+// LastActive returns when the cart last changed.
+func (c *Cart) LastActive() time.Time {
+	if c.UpdatedAt != nil {
+		return *c.UpdatedAt
+	}
+	return c.CreatedAt
+}
+
+// mergeQuantity returns the quantity of a line that is in both carts being
+// merged. Taking the larger quantity instead of the sum keeps a product the
+// customer added both as a guest and while signed in from being doubled.
+func mergeQuantity(from, to int) int {
+	return max(from, to)
+}
+
+// sameLine reports whether a and b are for the same product and variant.
+func (a *CartItem) sameLine(b *CartItem) bool {
+	if a.ProductID != b.ProductID {
+		return false
+	}
+	if a.VariantID == nil || b.VariantID == nil {
+		return a.VariantID == nil && b.VariantID == nil
+	}
+	return *a.VariantID == *b.VariantID
+}
@@ -0,0 +1,133 @@
+package storer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"maps"
+	"testing"
+	"time"
+)
+
+func TestMergeCarts(t *testing.T) {
+	tests := []struct {
+		name  string
+		guest map[string]int
+		user  map[string]int
+		want  map[string]int
+	}{
+		{"different products", map[string]int{"a": 2}, map[string]int{"b": 1}, map[string]int{"a": 2, "b": 1}},
+		{"guest quantity is larger", map[string]int{"a": 3}, map[string]int{"a": 1}, map[string]int{"a": 3}},
+		{"user quantity is larger", map[string]int{"a": 1}, map[string]int{"a": 4}, map[string]int{"a": 4}},
+		{"variants are separate lines", map[string]int{"s": 1, "a": 1}, map[string]int{"m": 2, "a": 1}, map[string]int{"s": 1, "m": 2, "a": 1}},
+		{"empty guest cart", nil, map[string]int{"a": 1}, map[string]int{"a": 1}},
+		{"empty user cart", map[string]int{"a": 2, "s": 1}, nil, map[string]int{"a": 2, "s": 1}},
+	}
+	for _, tt := range tests {
+		for name, st := range testStorers(t) {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				ctx := context.Background()
+				a, err := st.CreateProduct(ctx, &Product{Name: "a", Price: 1000, CountInStock: 10})
+				if err != nil {
+					t.Fatal(err)
+				}
+				b, err := st.CreateProduct(ctx, &Product{Name: "b", Price: 1000, CountInStock: 10})
+				if err != nil {
+					t.Fatal(err)
+				}
+				tee, err := st.CreateProduct(ctx, &Product{Name: "tee", Price: 1000})
+				if err != nil {
+					t.Fatal(err)
+				}
+				lines := map[string]CartItem{"a": {ProductID: a.ID}, "b": {ProductID: b.ID}}
+				for _, size := range []string{"s", "m"} {
+					v, err := st.CreateVariant(ctx, &ProductVariant{ProductID: tee.ID, SKU: "tee-" + size, OptionValues: OptionValues{"Size": size}, CountInStock: 10})
+					if err != nil {
+						t.Fatal(err)
+					}
+					lines[size] = CartItem{ProductID: tee.ID, VariantID: &v.ID}
+				}
+				u, err := st.CreateUser(ctx, &User{Name: "buyer", Email: "buyer@example.com", Password: "x"})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				guest, err := st.CreateGuestCart(ctx, "token")
+				if err != nil {
+					t.Fatal(err)
+				}
+				user, err := st.GetUserCart(ctx, u.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				for cartID, items := range map[int]map[string]int{guest.ID: tt.guest, user.ID: tt.user} {
+					for line, qty := range items {
+						item := lines[line]
+						item.CartID, item.Quantity = cartID, qty
+						if _, err := st.AddCartItem(ctx, &item); err != nil {
+							t.Fatal(err)
+						}
+					}
+				}
+
+				if err := st.MergeCarts(ctx, guest.ID, user.ID); err != nil {
+					t.Fatal(err)
+				}
+
+				merged, err := st.GetUserCart(ctx, u.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				got := make(map[string]int)
+				for _, item := range merged.Items {
+					for line, l := range lines {
+						if item.sameLine(&l) {
+							if _, dup := got[line]; dup {
+								t.Errorf("line %s is in the cart twice", line)
+							}
+							got[line] = item.Quantity
+						}
+					}
+				}
+				if !maps.Equal(got, tt.want) {
+					t.Errorf("merged cart = %v, want %v", got, tt.want)
+				}
+				if _, err := st.GetGuestCart(ctx, "token"); !errors.Is(err, sql.ErrNoRows) {
+					t.Errorf("guest cart after merge: error = %v, want sql.ErrNoRows", err)
+				}
+			})
+		}
+	}
+}
+
+func TestDeleteExpiredGuestCarts(t *testing.T) {
+	for name, st := range testStorers(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			u, err := st.CreateUser(ctx, &User{Name: "buyer", Email: "buyer@example.com", Password: "x"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if _, err := st.GetUserCart(ctx, u.ID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := st.CreateGuestCart(ctx, "token"); err != nil {
+				t.Fatal(err)
+			}
+
+			if n, err := st.DeleteExpiredGuestCarts(ctx, time.Now().Add(-time.Minute)); err != nil || n != 0 {
+				t.Errorf("deleting carts idle for a minute = %d, %v, want 0", n, err)
+			}
+			if n, err := st.DeleteExpiredGuestCarts(ctx, time.Now().Add(time.Minute)); err != nil || n != 1 {
+				t.Errorf("deleting every idle cart = %d, %v, want 1", n, err)
+			}
+			if _, err := st.GetGuestCart(ctx, "token"); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("guest cart after expiry: error = %v, want sql.ErrNoRows", err)
+			}
+			c, err := st.GetUserCart(ctx, u.ID)
+			if err != nil || c.UserID == nil || *c.UserID != u.ID {
+				t.Errorf("user cart after expiry = %+v, %v", c, err)
+			}
+		})
+	}
+}
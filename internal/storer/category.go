@@ -0,0 +1,89 @@
+package storer
+
+import (
+	"database/sql"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// SortCategories orders categories the way they are listed: by sort order,
+// then name, then ID.
+func SortCategories(categories []Category) {
+	sort.Slice(categories, func(i, j int) bool {
+		a, b := categories[i], categories[j]
+		if a.SortOrder != b.SortOrder {
+			return a.SortOrder < b.SortOrder
+		}
+		if a.Name != b.Name {
+			return a.Name < b.Name
+		}
+		return a.ID < b.ID
+	})
+}
+
+// CategoryPath returns the path of the category with the given ID, or "" if
+// it is not in categories.
+func CategoryPath(categories []Category, id int) string {
+	byID := make(map[int]*Category, len(categories))
+	for i := range categories {
+		byID[categories[i].ID] = &categories[i]
+	}
+	var slugs []string
+	// The depth bound guards against a cycle left behind by a bad update.
+	for c, ok := byID[id]; ok && len(slugs) <= len(categories); {
+		slugs = append([]string{c.Slug}, slugs...)
+		if c.ParentID == nil {
+			return strings.Join(slugs, "/")
+		}
+		c, ok = byID[*c.ParentID]
+	}
+	return ""
+}
+
+// categorySubtree returns the IDs of the category at path and of all its
+// descendants. It returns an error wrapping sql.ErrNoRows when no category
+// has that path.
+func categorySubtree(categories []Category, path string) ([]int, error) {
+	var root *Category
+	var parentID *int
+	for _, slug := range strings.Split(strings.Trim(path, "/"), "/") {
+		root = nil
+		for i := range categories {
+			c := &categories[i]
+			if c.Slug == slug && sameParent(c.ParentID, parentID) {
+				root = c
+				break
+			}
+		}
+		if root == nil {
+			return nil, fmt.Errorf("category %q not found: %w", path, sql.ErrNoRows)
+		}
+		parentID = &root.ID
+	}
+
+	children := make(map[int][]int)
+	for _, c := range categories {
+		if c.ParentID != nil {
+			children[*c.ParentID] = append(children[*c.ParentID], c.ID)
+		}
+	}
+	ids := []int{root.ID}
+	seen := map[int]bool{root.ID: true}
+	for i := 0; i < len(ids); i++ {
+		for _, child := range children[ids[i]] {
+			if !seen[child] {
+				seen[child] = true
+				ids = append(ids, child)
+			}
+		}
+	}
+	return ids, nil
+}
+
+func sameParent(a, b *int) bool {
+	if a == nil || b == nil {
+		return a == nil && b == nil
+	}
+	return *a == *b
+}
@@ -0,0 +1,63 @@
+package storer
+
+import (
+	"ecom_apiv1/internal/money"
+	"time"
+)
+
+type CouponType string
+
+const (
+	// CouponPercentage takes Value percent off the qualifying items.
+	CouponPercentage CouponType = "percentage"
+	// CouponFixed takes Value off the qualifying items, but never more
+	// than they cost.
+	CouponFixed CouponType = "fixed"
+)
+
+func (t CouponType) Valid() bool {
+	return t == CouponPercentage || t == CouponFixed
+}
+
+// Coupon is a discount code. Percentage coupons take Percent off the items
+// they apply to, fixed coupons take off Amount. It can be redeemed between
+// StartsAt and EndsAt, each of which may be open, on orders whose items cost
+// at least MinOrderValue. A UsageLimit or PerCustomerLimit of zero means
+// unlimited. Amounts are in the store currency.
+//
+// A coupon with CategoryIDs or ProductIDs only discounts the items of those
+// products and of products in those categories or their subcategories.
+type Coupon struct {
+	ID               int          `db:"id"`
+	Code             string       `db:"code"`
+	Type             CouponType   `db:"type"`
+	Percent          float64      `db:"percent"`
+	Amount           money.Amount `db:"amount"`
+	MinOrderValue    money.Amount `db:"min_order_value"`
+	StartsAt         *time.Time   `db:"starts_at"`
+	EndsAt           *time.Time   `db:"ends_at"`
+	UsageLimit       int          `db:"usage_limit"`
+	PerCustomerLimit int          `db:"per_customer_limit"`
+	TimesUsed        int          `db:"times_used"`
+	CreatedAt        time.Time    `db:"created_at"`
+	UpdatedAt        *time.Time   `db:"updated_at"`
+
+	CategoryIDs []int `db:"-"`
+	ProductIDs  []int `db:"-"`
+}
+
+// Restricted reports whether c only applies to some products.
+func (c *Coupon) Restricted() bool {
+	return len(c.CategoryIDs) > 0 || len(c.ProductIDs) > 0
+}
+
+// CouponRedemption records that an order used a coupon. Cancelling the order
+// deletes it again.
+type CouponRedemption struct {
+	ID        int          `db:"id"`
+	CouponID  int          `db:"coupon_id"`
+	UserID    int          `db:"user_id"`
+	OrderID   int          `db:"order_id"`
+	Discount  money.Amount `db:"discount"`
+	CreatedAt time.Time    `db:"created_at"`
+}
@@ -0,0 +1,87 @@
+package storer
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestCouponLimits(t *testing.T) {
+	// A step places an order with the coupon for a customer, or cancels the
+	// order placed in an earlier step.
+	type step struct {
+		user    string
+		cancel  int
+		wantErr error
+	}
+	order := func(user string, wantErr error) step { return step{user: user, wantErr: wantErr} }
+	cancel := func(i int) step { return step{cancel: i + 1} }
+
+	tests := []struct {
+		name        string
+		usageLimit  int
+		perCustomer int
+		steps       []step
+		wantUsed    int
+	}{
+		{"unlimited", 0, 0, []step{order("ann", nil), order("ann", nil), order("bob", nil)}, 3},
+		{"usage limit", 2, 0, []step{order("ann", nil), order("bob", nil), order("cid", ErrCouponLimitReached)}, 2},
+		{"per customer limit", 0, 1, []step{order("ann", nil), order("ann", ErrCouponLimitReached), order("bob", nil)}, 2},
+		{"both limits", 3, 2, []step{order("ann", nil), order("ann", nil), order("ann", ErrCouponLimitReached), order("bob", nil), order("cid", ErrCouponLimitReached)}, 3},
+		{"cancelling gives the use back", 1, 1, []step{order("ann", nil), cancel(0), order("ann", nil), order("bob", ErrCouponLimitReached)}, 1},
+	}
+	for _, tt := range tests {
+		for name, st := range testStorers(t) {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				ctx := context.Background()
+				p, err := st.CreateProduct(ctx, &Product{Name: "p", Price: 1000, CountInStock: 100})
+				if err != nil {
+					t.Fatal(err)
+				}
+				c, err := st.CreateCoupon(ctx, &Coupon{Code: "SAVE", Type: CouponFixed, Amount: 100, UsageLimit: tt.usageLimit, PerCustomerLimit: tt.perCustomer})
+				if err != nil {
+					t.Fatal(err)
+				}
+				users := make(map[string]int)
+				orders := make([]int, len(tt.steps))
+				for i, s := range tt.steps {
+					if s.cancel > 0 {
+						if _, err := st.CancelOrder(ctx, orders[s.cancel-1], []OrderStatus{OrderStatusPending}, 0, "test"); err != nil {
+							t.Fatal(err)
+						}
+						continue
+					}
+					if _, ok := users[s.user]; !ok {
+						u, err := st.CreateUser(ctx, &User{Name: s.user, Email: s.user + "@example.com", Password: "x"})
+						if err != nil {
+							t.Fatal(err)
+						}
+						users[s.user] = u.ID
+					}
+					o, err := st.CreateOrder(ctx, &Order{
+						UserId:        users[s.user],
+						PaymentMethod: "card",
+						CouponID:      &c.ID,
+						CouponCode:    c.Code,
+						DiscountPrice: 100,
+						Items:         []OrderItem{{Name: p.Name, Quantity: 1, Price: p.Price, ProductID: p.ID}},
+					})
+					if !errors.Is(err, s.wantErr) {
+						t.Fatalf("step %d: order by %s: error = %v, want %v", i, s.user, err, s.wantErr)
+					}
+					if err == nil {
+						orders[i] = o.ID
+					}
+				}
+
+				got, err := st.GetCoupon(ctx, c.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got.TimesUsed != tt.wantUsed {
+					t.Errorf("times used = %d, want %d", got.TimesUsed, tt.wantUsed)
+				}
+			})
+		}
+	}
+}
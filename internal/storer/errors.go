@@ -0,0 +1,60 @@
+package storer
+
+import (
+	"errors"
+	"fmt"
+)
+
+// ErrCategoryInUse is returned when deleting a category that still has
+// subcategories or products.
+var ErrCategoryInUse = errors.New("category has subcategories or products")
+
+// ErrDuplicateSKU is returned when a variant would share its SKU with
+// another variant.
+var ErrDuplicateSKU = errors.New("sku is already in use")
+
+// ErrDuplicateReview is returned when a customer reviews a product they have
+// already reviewed.
+var ErrDuplicateReview = errors.New("product already reviewed")
+
+// ErrDuplicateCouponCode is returned when a coupon would share its code with
+// another coupon.
+var ErrDuplicateCouponCode = errors.New("coupon code is already in use")
+
+// ErrCouponRedeemed is returned when deleting a coupon that orders have
+// already used. Such a coupon can be ended instead.
+var ErrCouponRedeemed = errors.New("coupon has been redeemed")
+
+// ErrCouponLimitReached is returned by CreateOrder when the order's coupon
+// has been used as often as it may be, in total or by the customer. Nothing
+// is written in that case.
+var ErrCouponLimitReached = errors.New("coupon usage limit reached")
+
+// InsufficientStockError is returned by CreateOrder when one or more products
+// or variants do not have enough stock left for the requested quantity.
+// Nothing is written in that case.
+type InsufficientStockError struct {
+	ProductIDs []int
+	VariantIDs []int
+}
+
+func (e *InsufficientStockError) Error() string {
+	switch {
+	case len(e.VariantIDs) == 0:
+		return fmt.Sprintf("insufficient stock for products %v", e.ProductIDs)
+	case len(e.ProductIDs) == 0:
+		return fmt.Sprintf("insufficient stock for variants %v", e.VariantIDs)
+	}
+	return fmt.Sprintf("insufficient stock for products %v and variants %v", e.ProductIDs, e.VariantIDs)
+}
+
+// InvalidStatusTransitionError is returned when an order is asked to move to
+// a status that its current status does not allow.
+type InvalidStatusTransitionError struct {
+	From OrderStatus
+	To   OrderStatus
+}
+
+func (e *InvalidStatusTransitionError) Error() string {
+	return fmt.Sprintf("cannot change order status from %s to %s", e.From, e.To)
+}
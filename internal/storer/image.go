@@ -0,0 +1,38 @@
+package storer
+
+import (
+	"database/sql/driver"
+	"encoding/json"
+	"time"
+)
+
+// ProductImage is an uploaded image of a product. Key is the blob key of the
+// original and Thumbnails maps each thumbnail size name to its blob key.
+// A product's images are listed by Position; the first one is its main
+// image.
+type ProductImage struct {
+	ID          int           `db:"id"`
+	ProductID   int           `db:"product_id"`
+	Position    int           `db:"position"`
+	Key         string        `db:"blob_key"`
+	ContentType string        `db:"content_type"`
+	Width       int           `db:"width"`
+	Height      int           `db:"height"`
+	Thumbnails  ThumbnailKeys `db:"thumbnails"`
+	CreatedAt   time.Time     `db:"created_at"`
+}
+
+// ThumbnailKeys is stored as a JSON object.
+type ThumbnailKeys map[string]string
+
+func (k ThumbnailKeys) Value() (driver.Value, error) {
+	if k == nil {
+		k = ThumbnailKeys{}
+	}
+	b, err := json.Marshal(map[string]string(k))
+	return string(b), err
+}
+
+func (k *ThumbnailKeys) Scan(src interface{}) error {
+	return scanJSON(src, (*map[string]string)(k))
+}
@@ -0,0 +1,52 @@
+package storer
+
+type OrderStatus string
+
+const (
+	OrderStatusPending    OrderStatus = "pending"
+	OrderStatusPaid       OrderStatus = "paid"
+	OrderStatusProcessing OrderStatus = "processing"
+	OrderStatusShipped    OrderStatus = "shipped"
+	OrderStatusDelivered  OrderStatus = "delivered"
+	OrderStatusCancelled  OrderStatus = "cancelled"
+	OrderStatusRefunded   OrderStatus = "refunded"
+)
+
+// orderTransitions lists the statuses an order may move to from each status.
+// Cancelled and refunded are final.
+var orderTransitions = map[OrderStatus][]OrderStatus{
+	OrderStatusPending:    {OrderStatusPaid, OrderStatusCancelled},
+	OrderStatusPaid:       {OrderStatusProcessing, OrderStatusCancelled, OrderStatusRefunded},
+	OrderStatusProcessing: {OrderStatusShipped, OrderStatusCancelled, OrderStatusRefunded},
+	OrderStatusShipped:    {OrderStatusDelivered, OrderStatusRefunded},
+	OrderStatusDelivered:  {OrderStatusRefunded},
+	OrderStatusCancelled:  nil,
+	OrderStatusRefunded:   nil,
+}
+
+func (s OrderStatus) Valid() bool {
+	_, ok := orderTransitions[s]
+	return ok
+}
+
+// releasesReservation reports whether moving from s to to gives the order's
+// stock and coupon use back. That is the case for cancelling and for a refund
+// before the order has shipped.
+func (s OrderStatus) releasesReservation(to OrderStatus) bool {
+	switch to {
+	case OrderStatusCancelled:
+		return true
+	case OrderStatusRefunded:
+		return s == OrderStatusPending || s == OrderStatusPaid || s == OrderStatusProcessing
+	}
+	return false
+}
+
+func (s OrderStatus) CanTransitionTo(to OrderStatus) bool {
+	for _, next := range orderTransitions[s] {
+		if next == to {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,169 @@
+package storer
+
+import (
+	"context"
+	"errors"
+	"testing"
+)
+
+func TestOrderStatusReleasesReservation(t *testing.T) {
+	tests := []struct {
+		from, to OrderStatus
+		want     bool
+	}{
+		{OrderStatusPending, OrderStatusCancelled, true},
+		{OrderStatusPaid, OrderStatusCancelled, true},
+		{OrderStatusProcessing, OrderStatusCancelled, true},
+		{OrderStatusPaid, OrderStatusRefunded, true},
+		{OrderStatusProcessing, OrderStatusRefunded, true},
+		{OrderStatusShipped, OrderStatusRefunded, false},
+		{OrderStatusDelivered, OrderStatusRefunded, false},
+		{OrderStatusPending, OrderStatusPaid, false},
+		{OrderStatusProcessing, OrderStatusShipped, false},
+	}
+	for _, tt := range tests {
+		if got := tt.from.releasesReservation(tt.to); got != tt.want {
+			t.Errorf("%s -> %s releases reservation = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestOrderStatusCanTransitionTo(t *testing.T) {
+	tests := []struct {
+		from, to OrderStatus
+		want     bool
+	}{
+		{OrderStatusPending, OrderStatusPaid, true},
+		{OrderStatusPending, OrderStatusShipped, false},
+		{OrderStatusPaid, OrderStatusRefunded, true},
+		{OrderStatusShipped, OrderStatusCancelled, false},
+		{OrderStatusDelivered, OrderStatusRefunded, true},
+		{OrderStatusCancelled, OrderStatusPending, false},
+		{OrderStatusRefunded, OrderStatusPaid, false},
+	}
+	for _, tt := range tests {
+		if got := tt.from.CanTransitionTo(tt.to); got != tt.want {
+			t.Errorf("%s -> %s = %v, want %v", tt.from, tt.to, got, tt.want)
+		}
+	}
+}
+
+func TestUpdateOrderStatusReleasesStockAndCoupon(t *testing.T) {
+	tests := []struct {
+		name          string
+		path          []OrderStatus
+		wantStock     int
+		wantTimesUsed int
+	}{
+		{"cancel pending", []OrderStatus{OrderStatusCancelled}, 10, 0},
+		{"refund paid", []OrderStatus{OrderStatusPaid, OrderStatusRefunded}, 10, 0},
+		{"refund processing", []OrderStatus{OrderStatusPaid, OrderStatusProcessing, OrderStatusRefunded}, 10, 0},
+		{"refund shipped", []OrderStatus{OrderStatusPaid, OrderStatusProcessing, OrderStatusShipped, OrderStatusRefunded}, 7, 1},
+		{"paid", []OrderStatus{OrderStatusPaid}, 7, 1},
+	}
+	for name, st := range testStorers(t) {
+		ctx := context.Background()
+		u, err := st.CreateUser(ctx, &User{Name: "buyer", Email: "buyer@example.com", Password: "x"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				p, err := st.CreateProduct(ctx, &Product{Name: tt.name, Price: 1000, CountInStock: 10})
+				if err != nil {
+					t.Fatal(err)
+				}
+				c, err := st.CreateCoupon(ctx, &Coupon{Code: "C" + p.Name, Type: CouponFixed, Amount: 100})
+				if err != nil {
+					t.Fatal(err)
+				}
+				o, err := st.CreateOrder(ctx, &Order{
+					UserId:        u.ID,
+					PaymentMethod: "card",
+					CouponID:      &c.ID,
+					CouponCode:    c.Code,
+					DiscountPrice: 100,
+					Items:         []OrderItem{{Name: p.Name, Quantity: 3, Price: p.Price, ProductID: p.ID}},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, status := range tt.path {
+					if _, err := st.UpdateOrderStatus(ctx, o.ID, status, u.ID, "test"); err != nil {
+						t.Fatalf("moving to %s: %v", status, err)
+					}
+				}
+
+				p, err = st.GetProduct(ctx, p.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if p.CountInStock != tt.wantStock {
+					t.Errorf("stock = %d, want %d", p.CountInStock, tt.wantStock)
+				}
+				c, err = st.GetCoupon(ctx, c.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if c.TimesUsed != tt.wantTimesUsed {
+					t.Errorf("times_used = %d, want %d", c.TimesUsed, tt.wantTimesUsed)
+				}
+			})
+		}
+	}
+}
+
+func TestCancelOrderOnlyFromAllowedStatuses(t *testing.T) {
+	tests := []struct {
+		name    string
+		path    []OrderStatus
+		wantErr bool
+	}{
+		{"pending", nil, false},
+		{"paid", []OrderStatus{OrderStatusPaid}, true},
+		{"processing", []OrderStatus{OrderStatusPaid, OrderStatusProcessing}, true},
+	}
+	for name, st := range testStorers(t) {
+		ctx := context.Background()
+		u, err := st.CreateUser(ctx, &User{Name: "buyer", Email: "buyer@example.com", Password: "x"})
+		if err != nil {
+			t.Fatal(err)
+		}
+		p, err := st.CreateProduct(ctx, &Product{Name: "p", Price: 1000, CountInStock: 10})
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, tt := range tests {
+			t.Run(name+"/"+tt.name, func(t *testing.T) {
+				o, err := st.CreateOrder(ctx, &Order{
+					UserId:        u.ID,
+					PaymentMethod: "card",
+					Items:         []OrderItem{{Name: p.Name, Quantity: 1, Price: p.Price, ProductID: p.ID}},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, status := range tt.path {
+					if _, err := st.UpdateOrderStatus(ctx, o.ID, status, u.ID, "test"); err != nil {
+						t.Fatalf("moving to %s: %v", status, err)
+					}
+				}
+
+				_, err = st.CancelOrder(ctx, o.ID, []OrderStatus{OrderStatusPending}, u.ID, "test")
+				var transitionErr *InvalidStatusTransitionError
+				if got := errors.As(err, &transitionErr); got != tt.wantErr {
+					t.Fatalf("CancelOrder error = %v, want transition error %v", err, tt.wantErr)
+				}
+
+				got, err := st.GetOrder(ctx, o.ID)
+				if err != nil {
+					t.Fatal(err)
+				}
+				cancelled := got.Status == OrderStatusCancelled
+				if cancelled == tt.wantErr {
+					t.Errorf("status = %s after cancelling", got.Status)
+				}
+			})
+		}
+	}
+}
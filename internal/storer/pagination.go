@@ -0,0 +1,33 @@
+package storer
+
+import "errors"
+
+// ErrInvalidCursor is returned when a cursor does not belong to the listing
+// it is used with, for example because the sort order changed.
+var ErrInvalidCursor = errors.New("invalid cursor")
+
+// Page selects one page of a keyset-paginated listing: up to Limit rows that
+// come after the row identified by After. A nil After starts at the first
+// row.
+type Page struct {
+	Limit int
+	After *Cursor
+}
+
+// Cursor identifies the last row of a page. Listings ordered by ID only need
+// the ID; sorted listings also carry the sort they were produced with and the
+// row's value for that sort.
+type Cursor struct {
+	ID    int     `json:"id"`
+	Sort  string  `json:"s,omitempty"`
+	Value float64 `json:"v,omitempty"`
+}
+
+// nextCursor trims rows fetched with Limit+1 back to Limit and returns the
+// cursor for the following page, or nil when there is none.
+func nextCursor(n, limit int, lastID func(i int) int) (int, *Cursor) {
+	if n <= limit {
+		return n, nil
+	}
+	return limit, &Cursor{ID: lastID(limit - 1)}
+}
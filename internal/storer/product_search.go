@@ -0,0 +1,294 @@
+package storer
+
+import (
+	"ecom_apiv1/internal/money"
+	"sort"
+	"strings"
+	"unicode"
+)
+
+type ProductSort string
+
+const (
+	ProductSortDefault   ProductSort = ""
+	ProductSortPriceAsc  ProductSort = "price_asc"
+	ProductSortPriceDesc ProductSort = "price_desc"
+	ProductSortRating    ProductSort = "rating"
+	ProductSortNewest    ProductSort = "newest"
+	ProductSortRelevance ProductSort = "relevance"
+)
+
+func (s ProductSort) Valid() bool {
+	switch s {
+	case ProductSortDefault, ProductSortPriceAsc, ProductSortPriceDesc, ProductSortRating, ProductSortNewest, ProductSortRelevance:
+		return true
+	}
+	return false
+}
+
+// ProductFilter narrows a product listing. Zero values do not filter.
+// Category is a category path and also matches the products of its
+// subcategories.
+type ProductFilter struct {
+	Query     string
+	Category  string
+	MinPrice  *money.Amount
+	MaxPrice  *money.Amount
+	MinRating int
+	InStock   bool
+	Sort      ProductSort
+
+	// categoryIDs is Category resolved to the IDs of its subtree by the
+	// backend.
+	categoryIDs []int
+}
+
+// resolveCategory fills in categoryIDs from the category tree. It returns an
+// error wrapping sql.ErrNoRows if the category path does not exist.
+func (f *ProductFilter) resolveCategory(categories []Category) error {
+	f.categoryIDs = nil
+	if f.Category == "" {
+		return nil
+	}
+	ids, err := categorySubtree(categories, f.Category)
+	if err != nil {
+		return err
+	}
+	f.categoryIDs = ids
+	return nil
+}
+
+// EffectiveSort resolves the default sort: relevance when searching by text,
+// otherwise catalog order (by ID).
+func (f ProductFilter) EffectiveSort() ProductSort {
+	if f.Sort != ProductSortDefault {
+		return f.Sort
+	}
+	if f.Query != "" {
+		return ProductSortRelevance
+	}
+	return ProductSortDefault
+}
+
+// searchTerms splits a free-text query into lower-case words for the backends
+// without a full-text index. A product matches when every term appears in
+// its name or description.
+func searchTerms(query string) []string {
+	return strings.FieldsFunc(strings.ToLower(query), func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsNumber(r)
+	})
+}
+
+// productScore is the relevance used by those backends: two points for every
+// term found in the name and one for every term found in the description.
+// It returns false when some term is not found at all.
+func productScore(p *Product, terms []string) (float64, bool) {
+	name := strings.ToLower(p.Name)
+	description := strings.ToLower(p.Description)
+	score := 0.0
+	for _, t := range terms {
+		inName := strings.Contains(name, t)
+		inDescription := strings.Contains(description, t)
+		if !inName && !inDescription {
+			return 0, false
+		}
+		if inName {
+			score += 2
+		}
+		if inDescription {
+			score++
+		}
+	}
+	return score, true
+}
+
+// sortValue returns the value a product is ordered by for sort, which is what
+// a cursor has to remember besides the ID.
+func sortValue(sort ProductSort, p *Product, score float64) float64 {
+	switch sort {
+	case ProductSortPriceAsc, ProductSortPriceDesc:
+		return float64(p.Price)
+	case ProductSortRating:
+		return p.Rating
+	case ProductSortRelevance:
+		return score
+	}
+	return 0
+}
+
+// descending reports whether sort lists products from the highest value (or
+// ID) down. Ties on the sort value are always broken by ID in the same
+// direction, which keeps the order total for keyset pagination.
+func (s ProductSort) descending() bool {
+	switch s {
+	case ProductSortPriceDesc, ProductSortRating, ProductSortNewest, ProductSortRelevance:
+		return true
+	}
+	return false
+}
+
+// byValue reports whether sort orders by a value other than the ID.
+func (s ProductSort) byValue() bool {
+	switch s {
+	case ProductSortPriceAsc, ProductSortPriceDesc, ProductSortRating, ProductSortRelevance:
+		return true
+	}
+	return false
+}
+
+// checkProductCursor rejects a cursor that was produced by a listing with a
+// different sort.
+func checkProductCursor(sort ProductSort, c *Cursor) error {
+	if c != nil && c.Sort != string(sort) {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// productCursor completes a cursor from nextCursor with the sort and the sort
+// value of the last product on the page.
+func productCursor(c *Cursor, sort ProductSort, p *Product, score float64) *Cursor {
+	if c == nil {
+		return nil
+	}
+	c.Sort = string(sort)
+	if sort.byValue() {
+		c.Value = sortValue(sort, p, score)
+	}
+	return c
+}
+
+// matches reports whether p passes every condition of f, along with its
+// relevance for the search terms of f.Query.
+func (f ProductFilter) matches(p *Product, terms []string) (float64, bool) {
+	score, ok := productScore(p, terms)
+	switch {
+	case !ok:
+		return 0, false
+	case f.Category != "" && !inCategories(p.CategoryID, f.categoryIDs):
+		return 0, false
+	case f.MinPrice != nil && p.Price < *f.MinPrice:
+		return 0, false
+	case f.MaxPrice != nil && p.Price > *f.MaxPrice:
+		return 0, false
+	case p.Rating < float64(f.MinRating):
+		return 0, false
+	case f.InStock && p.CountInStock <= 0:
+		return 0, false
+	}
+	return score, true
+}
+
+func inCategories(id *int, ids []int) bool {
+	if id == nil {
+		return false
+	}
+	for _, v := range ids {
+		if v == *id {
+			return true
+		}
+	}
+	return false
+}
+
+// PriceBucketBounds are the upper bounds of the price facet buckets, in minor
+// units. The last bucket has no upper bound.
+var PriceBucketBounds = []money.Amount{2500, 5000, 10000, 20000}
+
+// ProductFacets counts the products matching a filter by category, price
+// bucket, rating and stock. Each facet ignores the filter's own condition
+// for that facet, so the counts show what selecting another value would
+// return; all other conditions apply.
+type ProductFacets struct {
+	Categories []CategoryCount
+	Prices     []PriceBucket
+	Ratings    []RatingBucket
+	InStock    int
+	OutOfStock int
+}
+
+// CategoryCount counts the products directly in one category. CategoryID is
+// nil for products without a category.
+type CategoryCount struct {
+	CategoryID *int `db:"category_id"`
+	Name       string
+	Path       string
+	Count      int `db:"count"`
+}
+
+// fillCategoryCounts names the counted categories and sorts the counts,
+// largest first.
+func fillCategoryCounts(counts []CategoryCount, categories []Category) {
+	for i := range counts {
+		id := counts[i].CategoryID
+		if id == nil {
+			continue
+		}
+		for _, c := range categories {
+			if c.ID == *id {
+				counts[i].Name = c.Name
+				counts[i].Path = CategoryPath(categories, c.ID)
+				break
+			}
+		}
+	}
+	sort.Slice(counts, func(i, j int) bool {
+		if counts[i].Count != counts[j].Count {
+			return counts[i].Count > counts[j].Count
+		}
+		return counts[i].Path < counts[j].Path
+	})
+}
+
+// PriceBucket counts the products with Min <= price < Max. Max is nil for the
+// last bucket.
+type PriceBucket struct {
+	Min   money.Amount
+	Max   *money.Amount
+	Count int
+}
+
+// RatingBucket counts the products rated MinRating or higher, matching the
+// min_rating filter.
+type RatingBucket struct {
+	MinRating int
+	Count     int
+}
+
+// priceBucket returns the index of the price bucket for price.
+func priceBucket(price money.Amount) int {
+	for i, bound := range PriceBucketBounds {
+		if price < bound {
+			return i
+		}
+	}
+	return len(PriceBucketBounds)
+}
+
+// newPriceBuckets turns per-bucket counts into buckets with their bounds.
+func newPriceBuckets(counts []int) []PriceBucket {
+	buckets := make([]PriceBucket, len(PriceBucketBounds)+1)
+	for i := range buckets {
+		if i > 0 {
+			buckets[i].Min = PriceBucketBounds[i-1]
+		}
+		if i < len(PriceBucketBounds) {
+			max := PriceBucketBounds[i]
+			buckets[i].Max = &max
+		}
+		buckets[i].Count = counts[i]
+	}
+	return buckets
+}
+
+// newRatingBuckets turns the number of products per rating (0 to 5) into
+// cumulative "N and up" buckets for N from 1 to 5.
+func newRatingBuckets(perRating [6]int) []RatingBucket {
+	buckets := make([]RatingBucket, 5)
+	count := 0
+	for r := 5; r >= 1; r-- {
+		count += perRating[r]
+		buckets[r-1] = RatingBucket{MinRating: r, Count: count}
+	}
+	return buckets
+}
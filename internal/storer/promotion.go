@@ -0,0 +1,45 @@
+package storer
+
+import "time"
+
+type PromotionType string
+
+const (
+	// PromotionSale takes Percent percent off the price of every unit.
+	PromotionSale PromotionType = "sale"
+	// PromotionBuyXGetY gives GetQuantity units free for every BuyQuantity
+	// units bought of the same product.
+	PromotionBuyXGetY PromotionType = "buy_x_get_y"
+)
+
+func (t PromotionType) Valid() bool {
+	return t == PromotionSale || t == PromotionBuyXGetY
+}
+
+// Promotion is a discount that applies by itself between StartsAt and
+// EndsAt, each of which may be open. A promotion with CategoryIDs or
+// ProductIDs only covers those products and the products in those
+// categories or their subcategories; otherwise it covers every product.
+type Promotion struct {
+	ID          int           `db:"id"`
+	Name        string        `db:"name"`
+	Type        PromotionType `db:"type"`
+	Percent     float64       `db:"percent"`
+	BuyQuantity int           `db:"buy_quantity"`
+	GetQuantity int           `db:"get_quantity"`
+	StartsAt    *time.Time    `db:"starts_at"`
+	EndsAt      *time.Time    `db:"ends_at"`
+	CreatedAt   time.Time     `db:"created_at"`
+	UpdatedAt   *time.Time    `db:"updated_at"`
+
+	CategoryIDs []int `db:"-"`
+	ProductIDs  []int `db:"-"`
+}
+
+// Running reports whether p applies at time t.
+func (p *Promotion) Running(t time.Time) bool {
+	if p.StartsAt != nil && t.Before(*p.StartsAt) {
+		return false
+	}
+	return p.EndsAt == nil || t.Before(*p.EndsAt)
+}
@@ -0,0 +1,131 @@
+package storer
+
+import (
+	"math"
+	"time"
+)
+
+// Review is a customer's review of a product. A customer reviews a product
+// at most once. UserName is the reviewer's name at the time of reading.
+// Only approved reviews are listed publicly and count toward the product's
+// rating; ModerationReason says why a review was held back or rejected.
+type Review struct {
+	ID               int          `db:"id"`
+	ProductID        int          `db:"product_id"`
+	UserID           int          `db:"user_id"`
+	UserName         string       `db:"user_name"`
+	Rating           int          `db:"rating"`
+	Title            string       `db:"title"`
+	Body             string       `db:"body"`
+	Status           ReviewStatus `db:"status"`
+	ModerationReason string       `db:"moderation_reason"`
+	ModeratedAt      *time.Time   `db:"moderated_at"`
+	CreatedAt        time.Time    `db:"created_at"`
+	UpdatedAt        *time.Time   `db:"updated_at"`
+}
+
+type ReviewStatus string
+
+const (
+	ReviewStatusPending  ReviewStatus = "pending"
+	ReviewStatusApproved ReviewStatus = "approved"
+	ReviewStatusRejected ReviewStatus = "rejected"
+)
+
+func (s ReviewStatus) Valid() bool {
+	switch s {
+	case ReviewStatusPending, ReviewStatusApproved, ReviewStatusRejected:
+		return true
+	}
+	return false
+}
+
+type ReviewSort string
+
+const (
+	ReviewSortNewest     ReviewSort = "newest"
+	ReviewSortOldest     ReviewSort = "oldest"
+	ReviewSortRatingDesc ReviewSort = "rating_desc"
+	ReviewSortRatingAsc  ReviewSort = "rating_asc"
+)
+
+// Valid reports whether s is a known sort. The empty sort lists the newest
+// reviews first.
+func (s ReviewSort) Valid() bool {
+	switch s {
+	case "", ReviewSortNewest, ReviewSortOldest, ReviewSortRatingDesc, ReviewSortRatingAsc:
+		return true
+	}
+	return false
+}
+
+func (s ReviewSort) effective() ReviewSort {
+	if s == "" {
+		return ReviewSortNewest
+	}
+	return s
+}
+
+// descending reports whether s lists reviews from the highest rating (or
+// ID) down. As with products, ties on the rating are broken by ID in the same
+// direction.
+func (s ReviewSort) descending() bool {
+	return s == ReviewSortNewest || s == ReviewSortRatingDesc
+}
+
+func (s ReviewSort) byRating() bool {
+	return s == ReviewSortRatingDesc || s == ReviewSortRatingAsc
+}
+
+// checkReviewCursor rejects a cursor that was produced by a listing with a
+// different sort.
+func checkReviewCursor(sort ReviewSort, c *Cursor) error {
+	if c != nil && c.Sort != string(sort) {
+		return ErrInvalidCursor
+	}
+	return nil
+}
+
+// reviewCursor completes a cursor from nextCursor with the sort and the
+// rating of the last review on the page.
+func reviewCursor(c *Cursor, sort ReviewSort, r *Review) *Cursor {
+	if c == nil {
+		return nil
+	}
+	c.Sort = string(sort)
+	if sort.byRating() {
+		c.Value = float64(r.Rating)
+	}
+	return c
+}
+
+// reviewBefore reports whether a comes before b in a listing sorted by sort.
+func reviewBefore(sort ReviewSort, a, b *Review) bool {
+	if sort.byRating() && a.Rating != b.Rating {
+		if sort.descending() {
+			return a.Rating > b.Rating
+		}
+		return a.Rating < b.Rating
+	}
+	if sort.descending() {
+		return a.ID > b.ID
+	}
+	return a.ID < b.ID
+}
+
+// purchasedStatuses are the order statuses that make the buyer a verified
+// buyer of the ordered products: the order was paid for and not refunded.
+var purchasedStatuses = []OrderStatus{
+	OrderStatusPaid,
+	OrderStatusProcessing,
+	OrderStatusShipped,
+	OrderStatusDelivered,
+}
+
+// averageRating rounds a mean rating to two decimals, as stored.
+func averageRating(sum, n int) float64 {
+	if n == 0 {
+		return 0
+	}
+	return math.Round(float64(sum)/float64(n)*100) / 100
+}
@@ -0,0 +1,71 @@
+package storer
+
+import (
+	"context"
+	"testing"
+)
+
+func TestHasPurchased(t *testing.T) {
+	tests := []struct {
+		name   string
+		path   []OrderStatus
+		buyer  string
+		bought string
+		want   bool
+	}{
+		{"pending", nil, "buyer", "p", false},
+		{"paid", []OrderStatus{OrderStatusPaid}, "buyer", "p", true},
+		{"processing", []OrderStatus{OrderStatusPaid, OrderStatusProcessing}, "buyer", "p", true},
+		{"shipped", []OrderStatus{OrderStatusPaid, OrderStatusProcessing, OrderStatusShipped}, "buyer", "p", true},
+		{"delivered", []OrderStatus{OrderStatusPaid, OrderStatusProcessing, OrderStatusShipped, OrderStatusDelivered}, "buyer", "p", true},
+		{"cancelled", []OrderStatus{OrderStatusCancelled}, "buyer", "p", false},
+		{"refunded", []OrderStatus{OrderStatusPaid, OrderStatusRefunded}, "buyer", "p", false},
+		{"another customer's order", []OrderStatus{OrderStatusPaid}, "other", "p", false},
+		{"another product", []OrderStatus{OrderStatusPaid}, "buyer", "q", false},
+	}
+	for _, tt := range tests {
+		for name, st := range testStorers(t) {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				ctx := context.Background()
+				products := make(map[string]int)
+				for _, name := range []string{"p", "q"} {
+					p, err := st.CreateProduct(ctx, &Product{Name: name, Price: 1000, CountInStock: 10})
+					if err != nil {
+						t.Fatal(err)
+					}
+					products[name] = p.ID
+				}
+				users := make(map[string]int)
+				for _, name := range []string{"buyer", "other"} {
+					u, err := st.CreateUser(ctx, &User{Name: name, Email: name + "@example.com", Password: "x"})
+					if err != nil {
+						t.Fatal(err)
+					}
+					users[name] = u.ID
+				}
+
+				o, err := st.CreateOrder(ctx, &Order{
+					UserId:        users[tt.buyer],
+					PaymentMethod: "card",
+					Items:         []OrderItem{{Name: tt.bought, Quantity: 1, Price: 1000, ProductID: products[tt.bought]}},
+				})
+				if err != nil {
+					t.Fatal(err)
+				}
+				for _, status := range tt.path {
+					if _, err := st.UpdateOrderStatus(ctx, o.ID, status, 0, "test"); err != nil {
+						t.Fatal(err)
+					}
+				}
+
+				got, err := st.HasPurchased(ctx, users["buyer"], products["p"])
+				if err != nil {
+					t.Fatal(err)
+				}
+				if got != tt.want {
+					t.Errorf("HasPurchased = %v, want %v", got, tt.want)
+				}
+			})
+		}
+	}
+}
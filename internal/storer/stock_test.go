@@ -0,0 +1,183 @@
+package storer
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"sync"
+	"testing"
+)
+
+func TestCreateOrderReservesStock(t *testing.T) {
+	type line struct {
+		item string
+		qty  int
+	}
+	tests := []struct {
+		name      string
+		lines     []line
+		wantShort []string
+		wantStock map[string]int
+	}{
+		{
+			name:      "within stock",
+			lines:     []line{{"a", 3}, {"b", 2}},
+			wantStock: map[string]int{"a": 2, "b": 0, "c": 2, "v": 2},
+		},
+		{
+			name:      "variant",
+			lines:     []line{{"v", 2}},
+			wantStock: map[string]int{"a": 5, "b": 2, "c": 0, "v": 0},
+		},
+		{
+			name:      "one product short",
+			lines:     []line{{"a", 1}, {"b", 3}},
+			wantShort: []string{"b"},
+			wantStock: map[string]int{"a": 5, "b": 2, "c": 2, "v": 2},
+		},
+		{
+			name:      "lines of a product are summed",
+			lines:     []line{{"a", 3}, {"a", 3}},
+			wantShort: []string{"a"},
+			wantStock: map[string]int{"a": 5, "b": 2, "c": 2, "v": 2},
+		},
+		{
+			name:      "product and variant short",
+			lines:     []line{{"a", 6}, {"v", 3}},
+			wantShort: []string{"a", "v"},
+			wantStock: map[string]int{"a": 5, "b": 2, "c": 2, "v": 2},
+		},
+	}
+	for _, tt := range tests {
+		for name, st := range testStorers(t) {
+			t.Run(tt.name+"/"+name, func(t *testing.T) {
+				ctx := context.Background()
+				ids := make(map[string]int)
+				for _, p := range []struct {
+					name  string
+					stock int
+				}{{"a", 5}, {"b", 2}, {"c", 0}} {
+					created, err := st.CreateProduct(ctx, &Product{Name: p.name, Price: 1000, CountInStock: p.stock})
+					if err != nil {
+						t.Fatal(err)
+					}
+					ids[p.name] = created.ID
+				}
+				v, err := st.CreateVariant(ctx, &ProductVariant{ProductID: ids["c"], SKU: "c-v", OptionValues: OptionValues{"Size": "M"}, CountInStock: 2})
+				if err != nil {
+					t.Fatal(err)
+				}
+				ids["v"] = v.ID
+				u, err := st.CreateUser(ctx, &User{Name: "buyer", Email: "buyer@example.com", Password: "x"})
+				if err != nil {
+					t.Fatal(err)
+				}
+
+				o := &Order{UserId: u.ID, PaymentMethod: "card"}
+				for _, l := range tt.lines {
+					oi := OrderItem{Name: l.item, Quantity: l.qty, Price: 1000, ProductID: ids[l.item]}
+					if l.item == "v" {
+						oi.ProductID, oi.VariantID = ids["c"], &v.ID
+					}
+					o.Items = append(o.Items, oi)
+				}
+				_, err = st.CreateOrder(ctx, o)
+
+				var short *InsufficientStockError
+				switch {
+				case tt.wantShort == nil && err != nil:
+					t.Fatalf("CreateOrder: %v", err)
+				case tt.wantShort != nil && !errors.As(err, &short):
+					t.Fatalf("CreateOrder error = %v, want *InsufficientStockError", err)
+				case tt.wantShort != nil:
+					var got []string
+					for name, id := range ids {
+						isVariant := name == "v"
+						if isVariant && slices.Contains(short.VariantIDs, id) || !isVariant && slices.Contains(short.ProductIDs, id) {
+							got = append(got, name)
+						}
+					}
+					slices.Sort(got)
+					if !slices.Equal(got, tt.wantShort) {
+						t.Errorf("short of %v, want %v", got, tt.wantShort)
+					}
+				}
+
+				for name, want := range tt.wantStock {
+					var got int
+					if name == "v" {
+						v, err := st.GetVariant(ctx, ids[name])
+						if err != nil {
+							t.Fatal(err)
+						}
+						got = v.CountInStock
+					} else {
+						p, err := st.GetProduct(ctx, ids[name])
+						if err != nil {
+							t.Fatal(err)
+						}
+						got = p.CountInStock
+					}
+					if got != want {
+						t.Errorf("stock of %s = %d, want %d", name, got, want)
+					}
+				}
+			})
+		}
+	}
+}
+
+func TestCreateOrderDoesNotOversell(t *testing.T) {
+	const stock, buyers = 5, 12
+	for name, st := range testStorers(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			p, err := st.CreateProduct(ctx, &Product{Name: "p", Price: 1000, CountInStock: stock})
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, err := st.CreateUser(ctx, &User{Name: "buyer", Email: "buyer@example.com", Password: "x"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			var wg sync.WaitGroup
+			errs := make(chan error, buyers)
+			for range buyers {
+				wg.Add(1)
+				go func() {
+					defer wg.Done()
+					_, err := st.CreateOrder(ctx, &Order{
+						UserId:        u.ID,
+						PaymentMethod: "card",
+						Items:         []OrderItem{{Name: p.Name, Quantity: 1, Price: p.Price, ProductID: p.ID}},
+					})
+					errs <- err
+				}()
+			}
+			wg.Wait()
+			close(errs)
+
+			var sold int
+			for err := range errs {
+				var short *InsufficientStockError
+				switch {
+				case err == nil:
+					sold++
+				case !errors.As(err, &short):
+					t.Errorf("CreateOrder: %v", err)
+				}
+			}
+			if sold != stock {
+				t.Errorf("sold %d, want %d", sold, stock)
+			}
+			got, err := st.GetProduct(ctx, p.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.CountInStock != 0 {
+				t.Errorf("stock left = %d, want 0", got.CountInStock)
+			}
+		})
+	}
+}
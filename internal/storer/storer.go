@@ -0,0 +1,102 @@
+package storer
+
+import (
+	"context"
+	"time"
+)
+
+// Storer is the persistence layer used by the server. MySQLStorage,
+// SQLiteStorage and MemoryStorage implement it.
+type Storer interface {
+	CreateProduct(ctx context.Context, p *Product) (*Product, error)
+	GetProduct(ctx context.Context, id int) (*Product, error)
+	ListProducts(ctx context.Context, filter ProductFilter, page Page) ([]Product, *Cursor, error)
+	ProductFacets(ctx context.Context, filter ProductFilter) (*ProductFacets, error)
+	UpdateProduct(ctx context.Context, p *Product) (*Product, error)
+	DeleteProduct(ctx context.Context, id int) error
+	SetProductOptions(ctx context.Context, productID int, options []ProductOption) ([]ProductOption, error)
+	CreateVariant(ctx context.Context, v *ProductVariant) (*ProductVariant, error)
+	GetVariant(ctx context.Context, id int) (*ProductVariant, error)
+	UpdateVariant(ctx context.Context, v *ProductVariant) (*ProductVariant, error)
+	DeleteVariant(ctx context.Context, id int) error
+	CreateProductImage(ctx context.Context, img *ProductImage) (*ProductImage, error)
+	GetProductImage(ctx context.Context, id int) (*ProductImage, error)
+	ReorderProductImages(ctx context.Context, productID int, imageIDs []int) error
+	DeleteProductImage(ctx context.Context, id int) error
+	CreateReview(ctx context.Context, r *Review) (*Review, error)
+	GetReview(ctx context.Context, id int) (*Review, error)
+	ListProductReviews(ctx context.Context, productID int, sort ReviewSort, page Page) ([]Review, *Cursor, error)
+	UpdateReview(ctx context.Context, r *Review) (*Review, error)
+	DeleteReview(ctx context.Context, id int) error
+	ListReviewsByStatus(ctx context.Context, status ReviewStatus, page Page) ([]Review, *Cursor, error)
+	ModerateReviews(ctx context.Context, ids []int, status ReviewStatus, reason string) ([]Review, error)
+	HasPurchased(ctx context.Context, userID, productID int) (bool, error)
+	GetUserCart(ctx context.Context, userID int) (*Cart, error)
+	CreateGuestCart(ctx context.Context, token string) (*Cart, error)
+	GetGuestCart(ctx context.Context, token string) (*Cart, error)
+	MergeCarts(ctx context.Context, fromID, toID int) error
+	DeleteExpiredGuestCarts(ctx context.Context, before time.Time) (int64, error)
+	AddCartItem(ctx context.Context, item *CartItem) (*CartItem, error)
+	UpdateCartItem(ctx context.Context, cartID, itemID, quantity int) error
+	DeleteCartItem(ctx context.Context, cartID, itemID int) error
+	ClearCart(ctx context.Context, cartID int) error
+	CreateWishlist(ctx context.Context, w *Wishlist) (*Wishlist, error)
+	GetWishlist(ctx context.Context, id int) (*Wishlist, error)
+	GetWishlistByShareToken(ctx context.Context, token string) (*Wishlist, error)
+	ListUserWishlists(ctx context.Context, userID int) ([]Wishlist, error)
+	UpdateWishlist(ctx context.Context, w *Wishlist) (*Wishlist, error)
+	DeleteWishlist(ctx context.Context, id int) error
+	AddWishlistItem(ctx context.Context, item *WishlistItem) (*WishlistItem, error)
+	DeleteWishlistItem(ctx context.Context, wishlistID, itemID int) error
+	ListWishlistedProducts(ctx context.Context, page Page) ([]WishlistedProduct, *Cursor, error)
+
+	CreateCategory(ctx context.Context, c *Category) (*Category, error)
+	GetCategory(ctx context.Context, id int) (*Category, error)
+	ListCategories(ctx context.Context) ([]Category, error)
+	UpdateCategory(ctx context.Context, c *Category) (*Category, error)
+	DeleteCategory(ctx context.Context, id int) error
+
+	CreateOrder(ctx context.Context, o *Order) (*Order, error)
+	GetOrder(ctx context.Context, id int) (*Order, error)
+	ListUserOrders(ctx context.Context, userId int, page Page) ([]Order, *Cursor, error)
+	ListOrders(ctx context.Context, page Page) ([]Order, *Cursor, error)
+	UpdateOrderStatus(ctx context.Context, id int, status OrderStatus, changedBy int, reason string) (*Order, error)
+	CancelOrder(ctx context.Context, id int, from []OrderStatus, changedBy int, reason string) (*Order, error)
+	ListOrderStatusHistory(ctx context.Context, orderID int) ([]OrderStatusChange, error)
+
+	CreateUser(ctx context.Context, u *User) (*User, error)
+	GetUser(ctx context.Context, email string) (*User, error)
+	ListUsers(ctx context.Context, page Page) ([]User, *Cursor, error)
+	UpdateUser(ctx context.Context, u *User) (*User, error)
+	DeleteUser(ctx context.Context, id int) error
+
+	CreateSession(ctx context.Context, s *Session) (*Session, error)
+	GetSession(ctx context.Context, id string) (*Session, error)
+	RevokeSession(ctx context.Context, id string) error
+	DeleteSession(ctx context.Context, id string) error
+
+	CreateTaxRate(ctx context.Context, tr *TaxRate) (*TaxRate, error)
+	GetTaxRate(ctx context.Context, id int) (*TaxRate, error)
+	ListTaxRates(ctx context.Context) ([]TaxRate, error)
+	UpdateTaxRate(ctx context.Context, tr *TaxRate) (*TaxRate, error)
+	DeleteTaxRate(ctx context.Context, id int) error
+
+	CreateCoupon(ctx context.Context, c *Coupon) (*Coupon, error)
+	GetCoupon(ctx context.Context, id int) (*Coupon, error)
+	GetCouponByCode(ctx context.Context, code string) (*Coupon, error)
+	ListCoupons(ctx context.Context) ([]Coupon, error)
+	UpdateCoupon(ctx context.Context, c *Coupon) (*Coupon, error)
+	DeleteCoupon(ctx context.Context, id int) error
+
+	CreatePromotion(ctx context.Context, p *Promotion) (*Promotion, error)
+	GetPromotion(ctx context.Context, id int) (*Promotion, error)
+	ListPromotions(ctx context.Context) ([]Promotion, error)
+	UpdatePromotion(ctx context.Context, p *Promotion) (*Promotion, error)
+	DeletePromotion(ctx context.Context, id int) error
+}
+
+var (
+	_ Storer = (*MySQLStorage)(nil)
+	_ Storer = (*SQLiteStorage)(nil)
+	_ Storer = (*MemoryStorage)(nil)
+)
@@ -0,0 +1,1655 @@
+package storer
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"slices"
+	"sort"
+	"sync"
+	"time"
+)
+
+// MemoryStorage keeps everything in process memory. It is safe for
+// concurrent use and is meant for tests and local demos.
+type MemoryStorage struct {
+	mu sync.RWMutex
+
+	products   map[int]Product
+	orders     map[int]Order
+	orderItems map[int]OrderItem
+	users      map[int]User
+	sessions   map[string]Session
+	taxRates   map[int]TaxRate
+	categories map[int]Category
+	options    map[int][]ProductOption
+	variants   map[int]ProductVariant
+	images     map[int]ProductImage
+	reviews    map[int]Review
+	carts      map[int]Cart
+	cartItems  map[int]CartItem
+	wishlists  map[int]Wishlist
+	wishItems  map[int]WishlistItem
+	coupons    map[int]Coupon
+	promotions map[int]Promotion
+
+	statusHistory []OrderStatusChange
+	redemptions   []CouponRedemption
+
+	lastProductID    int
+	lastOrderID      int
+	lastOrderItemID  int
+	lastUserID       int
+	lastTaxRateID    int
+	lastCategoryID   int
+	lastOptionID     int
+	lastVariantID    int
+	lastImageID      int
+	lastReviewID     int
+	lastCartID       int
+	lastCartItemID   int
+	lastWishlistID   int
+	lastWishItemID   int
+	lastCouponID     int
+	lastPromotionID  int
+	lastRedemptionID int
+	lastStatusID     int
+}
+
+// NewMemoryStorage returns an empty store seeded with the same default tax
+// rate the SQL migrations insert.
+func NewMemoryStorage() *MemoryStorage {
+	return &MemoryStorage{
+		products:   make(map[int]Product),
+		orders:     make(map[int]Order),
+		orderItems: make(map[int]OrderItem),
+		users:      make(map[int]User),
+		sessions:   make(map[string]Session),
+		taxRates: map[int]TaxRate{
+			1: {ID: 1, Name: "Default", Rate: 0.15, CreatedAt: time.Now()},
+		},
+		categories: make(map[int]Category),
+		options:    make(map[int][]ProductOption),
+		variants:   make(map[int]ProductVariant),
+		images:     make(map[int]ProductImage),
+		reviews:    make(map[int]Review),
+		carts:      make(map[int]Cart),
+		cartItems:  make(map[int]CartItem),
+		wishlists:  make(map[int]Wishlist),
+		wishItems:  make(map[int]WishlistItem),
+		coupons:    make(map[int]Coupon),
+		promotions: make(map[int]Promotion),
+
+		lastTaxRateID: 1,
+	}
+}
+
+func (m *MemoryStorage) CreateProduct(ctx context.Context, p *Product) (*Product, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastProductID++
+	p.ID = m.lastProductID
+	p.Rating, p.NumReviews = 0, 0
+	p.CreatedAt = time.Now()
+	m.storeProductLocked(*p)
+	return p, nil
+}
+
+func (m *MemoryStorage) GetProduct(ctx context.Context, id int) (*Product, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	p, ok := m.products[id]
+	if !ok {
+		return nil, fmt.Errorf("error getting product: %w", sql.ErrNoRows)
+	}
+	p = m.withDetailsLocked(p)
+	return &p, nil
+}
+
+func (m *MemoryStorage) ListProducts(ctx context.Context, filter ProductFilter, page Page) ([]Product, *Cursor, error) {
+	order := filter.EffectiveSort()
+	if err := checkProductCursor(order, page.After); err != nil {
+		return nil, nil, err
+	}
+
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if err := filter.resolveCategory(m.categoryListLocked()); err != nil {
+		return nil, nil, err
+	}
+	terms := searchTerms(filter.Query)
+	type scored struct {
+		p     Product
+		value float64
+	}
+	// less reports whether a is listed before the row at (value, id).
+	less := func(a scored, value float64, id int) bool {
+		if order.byValue() && a.value != value {
+			return (a.value < value) != order.descending()
+		}
+		if a.p.ID == id {
+			return false
+		}
+		return (a.p.ID < id) != order.descending()
+	}
+
+	matches := make([]scored, 0, len(m.products))
+	for _, p := range m.products {
+		score, ok := filter.matches(&p, terms)
+		if !ok {
+			continue
+		}
+		s := scored{p: p, value: sortValue(order, &p, score)}
+		if page.After != nil && !less(scored{value: page.After.Value, p: Product{ID: page.After.ID}}, s.value, s.p.ID) {
+			continue
+		}
+		matches = append(matches, s)
+	}
+	sort.Slice(matches, func(i, j int) bool { return less(matches[i], matches[j].value, matches[j].p.ID) })
+
+	n, next := nextCursor(len(matches), page.Limit, func(i int) int { return matches[i].p.ID })
+	products := make([]Product, n)
+	for i := range products {
+		products[i] = m.withDetailsLocked(matches[i].p)
+	}
+	if n > 0 {
+		next = productCursor(next, order, &matches[n-1].p, matches[n-1].value)
+	}
+	return products, next, nil
+}
+
+func (m *MemoryStorage) ProductFacets(ctx context.Context, filter ProductFilter) (*ProductFacets, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	categoryList := m.categoryListLocked()
+	if err := filter.resolveCategory(categoryList); err != nil {
+		return nil, err
+	}
+	terms := searchTerms(filter.Query)
+	anyCategory, anyPrice, anyRating, anyStock := filter, filter, filter, filter
+	anyCategory.Category, anyCategory.categoryIDs = "", nil
+	anyPrice.MinPrice, anyPrice.MaxPrice = nil, nil
+	anyRating.MinRating = 0
+	anyStock.InStock = false
+
+	// Products without a category are counted under ID 0.
+	categories := make(map[int]int)
+	prices := make([]int, len(PriceBucketBounds)+1)
+	var ratings [6]int
+	facets := &ProductFacets{}
+	for _, p := range m.products {
+		if _, ok := anyCategory.matches(&p, terms); ok {
+			id := 0
+			if p.CategoryID != nil {
+				id = *p.CategoryID
+			}
+			categories[id]++
+		}
+		if _, ok := anyPrice.matches(&p, terms); ok {
+			prices[priceBucket(p.Price)]++
+		}
+		if _, ok := anyRating.matches(&p, terms); ok && p.Rating >= 0 && p.Rating <= 5 {
+			ratings[int(p.Rating)]++
+		}
+		if _, ok := anyStock.matches(&p, terms); ok {
+			if p.CountInStock > 0 {
+				facets.InStock++
+			} else {
+				facets.OutOfStock++
+			}
+		}
+	}
+
+	for id, count := range categories {
+		cc := CategoryCount{Count: count}
+		if id != 0 {
+			id := id
+			cc.CategoryID = &id
+		}
+		facets.Categories = append(facets.Categories, cc)
+	}
+	fillCategoryCounts(facets.Categories, categoryList)
+	facets.Prices = newPriceBuckets(prices)
+	facets.Ratings = newRatingBuckets(ratings)
+	return facets, nil
+}
+
+func (m *MemoryStorage) UpdateProduct(ctx context.Context, p *Product) (*Product, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if old, ok := m.products[p.ID]; ok {
+		p.Rating, p.NumReviews = old.Rating, old.NumReviews
+		m.storeProductLocked(*p)
+	}
+	return p, nil
+}
+
+func (m *MemoryStorage) DeleteProduct(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.products, id)
+	delete(m.options, id)
+	for _, v := range m.variants {
+		if v.ProductID == id {
+			delete(m.variants, v.ID)
+		}
+	}
+	for _, img := range m.images {
+		if img.ProductID == id {
+			delete(m.images, img.ID)
+		}
+	}
+	for _, r := range m.reviews {
+		if r.ProductID == id {
+			delete(m.reviews, r.ID)
+		}
+	}
+	for _, item := range m.cartItems {
+		if item.ProductID == id {
+			delete(m.cartItems, item.ID)
+		}
+	}
+	for _, item := range m.wishItems {
+		if item.ProductID == id {
+			delete(m.wishItems, item.ID)
+		}
+	}
+	return nil
+}
+
+// storeProductLocked saves p without its options, variants and images, which
+// are kept separately. The caller must hold m.mu for writing.
+func (m *MemoryStorage) storeProductLocked(p Product) {
+	p.Options = nil
+	p.Variants = nil
+	p.Images = nil
+	m.products[p.ID] = p
+}
+
+// withDetailsLocked returns p with its options, variants and images
+// attached. The caller must hold m.mu.
+func (m *MemoryStorage) withDetailsLocked(p Product) Product {
+	p.Options = append([]ProductOption(nil), m.options[p.ID]...)
+	p.Variants = nil
+	for _, v := range m.variants {
+		if v.ProductID == p.ID {
+			p.Variants = append(p.Variants, v)
+		}
+	}
+	sort.Slice(p.Variants, func(i, j int) bool { return p.Variants[i].ID < p.Variants[j].ID })
+	p.Images = nil
+	for _, img := range m.images {
+		if img.ProductID == p.ID {
+			p.Images = append(p.Images, img)
+		}
+	}
+	sort.Slice(p.Images, func(i, j int) bool {
+		if p.Images[i].Position != p.Images[j].Position {
+			return p.Images[i].Position < p.Images[j].Position
+		}
+		return p.Images[i].ID < p.Images[j].ID
+	})
+	return p
+}
+
+func (m *MemoryStorage) CreateProductImage(ctx context.Context, img *ProductImage) (*ProductImage, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.products[img.ProductID]; !ok {
+		return nil, fmt.Errorf("error getting product: %w", sql.ErrNoRows)
+	}
+	img.Position = 0
+	for _, other := range m.images {
+		if other.ProductID == img.ProductID && other.Position >= img.Position {
+			img.Position = other.Position + 1
+		}
+	}
+	m.lastImageID++
+	img.ID = m.lastImageID
+	img.CreatedAt = time.Now()
+	m.images[img.ID] = *img
+	return img, nil
+}
+
+func (m *MemoryStorage) GetProductImage(ctx context.Context, id int) (*ProductImage, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	img, ok := m.images[id]
+	if !ok {
+		return nil, fmt.Errorf("error getting product image: %w", sql.ErrNoRows)
+	}
+	return &img, nil
+}
+
+func (m *MemoryStorage) ReorderProductImages(ctx context.Context, productID int, imageIDs []int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i, id := range imageIDs {
+		if img, ok := m.images[id]; ok && img.ProductID == productID {
+			img.Position = i
+			m.images[id] = img
+		}
+	}
+	return nil
+}
+
+func (m *MemoryStorage) DeleteProductImage(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.images, id)
+	return nil
+}
+
+func (m *MemoryStorage) SetProductOptions(ctx context.Context, productID int, options []ProductOption) ([]ProductOption, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for i := range options {
+		m.lastOptionID++
+		options[i].ID = m.lastOptionID
+		options[i].ProductID = productID
+	}
+	stored := append([]ProductOption(nil), options...)
+	sort.SliceStable(stored, func(i, j int) bool { return stored[i].Position < stored[j].Position })
+	m.options[productID] = stored
+	return options, nil
+}
+
+func (m *MemoryStorage) CreateVariant(ctx context.Context, v *ProductVariant) (*ProductVariant, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.skuTakenLocked(v) {
+		return nil, ErrDuplicateSKU
+	}
+	m.lastVariantID++
+	v.ID = m.lastVariantID
+	v.CreatedAt = time.Now()
+	m.variants[v.ID] = *v
+	m.syncProductStockLocked(v.ProductID)
+	return v, nil
+}
+
+func (m *MemoryStorage) GetVariant(ctx context.Context, id int) (*ProductVariant, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	v, ok := m.variants[id]
+	if !ok {
+		return nil, fmt.Errorf("error getting variant: %w", sql.ErrNoRows)
+	}
+	return &v, nil
+}
+
+func (m *MemoryStorage) UpdateVariant(ctx context.Context, v *ProductVariant) (*ProductVariant, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if m.skuTakenLocked(v) {
+		return nil, ErrDuplicateSKU
+	}
+	if _, ok := m.variants[v.ID]; ok {
+		m.variants[v.ID] = *v
+		m.syncProductStockLocked(v.ProductID)
+	}
+	return v, nil
+}
+
+func (m *MemoryStorage) DeleteVariant(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if v, ok := m.variants[id]; ok {
+		delete(m.variants, id)
+		m.syncProductStockLocked(v.ProductID)
+	}
+	return nil
+}
+
+func (m *MemoryStorage) skuTakenLocked(v *ProductVariant) bool {
+	for _, other := range m.variants {
+		if other.ID != v.ID && other.SKU == v.SKU {
+			return true
+		}
+	}
+	return false
+}
+
+// syncProductStockLocked mirrors syncProductStock for the in-memory store.
+func (m *MemoryStorage) syncProductStockLocked(productID int) {
+	p, ok := m.products[productID]
+	if !ok {
+		return
+	}
+	p.CountInStock = 0
+	for _, v := range m.variants {
+		if v.ProductID == productID {
+			p.CountInStock += v.CountInStock
+		}
+	}
+	m.products[productID] = p
+}
+
+func (m *MemoryStorage) CreateReview(ctx context.Context, r *Review) (*Review, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.products[r.ProductID]; !ok {
+		return nil, fmt.Errorf("error getting product: %w", sql.ErrNoRows)
+	}
+	for _, other := range m.reviews {
+		if other.ProductID == r.ProductID && other.UserID == r.UserID {
+			return nil, ErrDuplicateReview
+		}
+	}
+	m.lastReviewID++
+	r.ID = m.lastReviewID
+	r.CreatedAt = time.Now()
+	r.UserName = ""
+	m.reviews[r.ID] = *r
+	m.refreshProductRatingLocked(r.ProductID)
+	return m.reviewLocked(r.ID), nil
+}
+
+func (m *MemoryStorage) GetReview(ctx context.Context, id int) (*Review, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.reviews[id]; !ok {
+		return nil, fmt.Errorf("error getting review: %w", sql.ErrNoRows)
+	}
+	return m.reviewLocked(id), nil
+}
+
+func (m *MemoryStorage) ListProductReviews(ctx context.Context, productID int, order ReviewSort, page Page) ([]Review, *Cursor, error) {
+	order = order.effective()
+	if err := checkReviewCursor(order, page.After); err != nil {
+		return nil, nil, err
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var after *Review
+	if page.After != nil {
+		after = &Review{ID: page.After.ID, Rating: int(page.After.Value)}
+	}
+	reviews := []Review{}
+	for _, r := range m.reviews {
+		if r.ProductID != productID || r.Status != ReviewStatusApproved {
+			continue
+		}
+		if after != nil && !reviewBefore(order, after, &r) {
+			continue
+		}
+		reviews = append(reviews, *m.reviewLocked(r.ID))
+	}
+	sort.Slice(reviews, func(i, j int) bool { return reviewBefore(order, &reviews[i], &reviews[j]) })
+	n, next := nextCursor(len(reviews), page.Limit, func(i int) int { return reviews[i].ID })
+	reviews = reviews[:n]
+	if n > 0 {
+		next = reviewCursor(next, order, &reviews[n-1])
+	}
+	return reviews, next, nil
+}
+
+func (m *MemoryStorage) UpdateReview(ctx context.Context, r *Review) (*Review, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.reviews[r.ID]
+	if !ok {
+		return r, nil
+	}
+	old.Rating, old.Title, old.Body, old.UpdatedAt = r.Rating, r.Title, r.Body, r.UpdatedAt
+	old.Status, old.ModerationReason = r.Status, r.ModerationReason
+	m.reviews[r.ID] = old
+	m.refreshProductRatingLocked(old.ProductID)
+	return m.reviewLocked(r.ID), nil
+}
+
+func (m *MemoryStorage) DeleteReview(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	r, ok := m.reviews[id]
+	if !ok {
+		return fmt.Errorf("error getting review: %w", sql.ErrNoRows)
+	}
+	delete(m.reviews, id)
+	m.refreshProductRatingLocked(r.ProductID)
+	return nil
+}
+
+func (m *MemoryStorage) ListReviewsByStatus(ctx context.Context, status ReviewStatus, page Page) ([]Review, *Cursor, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	reviews := []Review{}
+	for _, r := range m.reviews {
+		if r.Status == status && (page.After == nil || r.ID > page.After.ID) {
+			reviews = append(reviews, *m.reviewLocked(r.ID))
+		}
+	}
+	sort.Slice(reviews, func(i, j int) bool { return reviews[i].ID < reviews[j].ID })
+	n, next := nextCursor(len(reviews), page.Limit, func(i int) int { return reviews[i].ID })
+	return reviews[:n], next, nil
+}
+
+func (m *MemoryStorage) ModerateReviews(ctx context.Context, ids []int, status ReviewStatus, reason string) ([]Review, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, id := range ids {
+		if _, ok := m.reviews[id]; !ok {
+			return nil, fmt.Errorf("error getting review %d: %w", id, sql.ErrNoRows)
+		}
+	}
+	now := time.Now()
+	products := make(map[int]bool)
+	for _, id := range ids {
+		r := m.reviews[id]
+		r.Status, r.ModerationReason, r.ModeratedAt = status, reason, &now
+		m.reviews[id] = r
+		products[r.ProductID] = true
+	}
+	for productID := range products {
+		m.refreshProductRatingLocked(productID)
+	}
+
+	sorted := append([]int(nil), ids...)
+	sort.Ints(sorted)
+	reviews := make([]Review, 0, len(sorted))
+	for _, id := range sorted {
+		reviews = append(reviews, *m.reviewLocked(id))
+	}
+	return reviews, nil
+}
+
+func (m *MemoryStorage) HasPurchased(ctx context.Context, userID, productID int) (bool, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, oi := range m.orderItems {
+		if oi.ProductID != productID {
+			continue
+		}
+		o, ok := m.orders[oi.OrderID]
+		if !ok || o.UserId != userID {
+			continue
+		}
+		for _, status := range purchasedStatuses {
+			if o.Status == status {
+				return true, nil
+			}
+		}
+	}
+	return false, nil
+}
+
+// reviewLocked returns a copy of a stored review with the reviewer's name.
+// The caller must hold m.mu.
+func (m *MemoryStorage) reviewLocked(id int) *Review {
+	r := m.reviews[id]
+	r.UserName = m.users[r.UserID].Name
+	return &r
+}
+
+// refreshProductRatingLocked recomputes the rating and review count of a
+// product from its reviews. The caller must hold m.mu for writing.
+func (m *MemoryStorage) refreshProductRatingLocked(productID int) {
+	p, ok := m.products[productID]
+	if !ok {
+		return
+	}
+	sum, n := 0, 0
+	for _, r := range m.reviews {
+		if r.ProductID == productID && r.Status == ReviewStatusApproved {
+			sum += r.Rating
+			n++
+		}
+	}
+	p.Rating, p.NumReviews = averageRating(sum, n), n
+	m.products[productID] = p
+}
+
+func (m *MemoryStorage) GetUserCart(ctx context.Context, userID int) (*Cart, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range m.carts {
+		if c.UserID != nil && *c.UserID == userID {
+			return m.cartLocked(c.ID), nil
+		}
+	}
+	m.lastCartID++
+	m.carts[m.lastCartID] = Cart{ID: m.lastCartID, UserID: &userID, CreatedAt: time.Now()}
+	return m.cartLocked(m.lastCartID), nil
+}
+
+func (m *MemoryStorage) CreateGuestCart(ctx context.Context, token string) (*Cart, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.lastCartID++
+	m.carts[m.lastCartID] = Cart{ID: m.lastCartID, Token: &token, CreatedAt: now, UpdatedAt: &now}
+	return m.cartLocked(m.lastCartID), nil
+}
+
+func (m *MemoryStorage) GetGuestCart(ctx context.Context, token string) (*Cart, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, c := range m.carts {
+		if c.UserID == nil && c.Token != nil && *c.Token == token {
+			return m.cartLocked(c.ID), nil
+		}
+	}
+	return nil, fmt.Errorf("error getting cart: %w", sql.ErrNoRows)
+}
+
+func (m *MemoryStorage) MergeCarts(ctx context.Context, fromID, toID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	_, fromOK := m.carts[fromID]
+	_, toOK := m.carts[toID]
+	if !fromOK || !toOK {
+		return fmt.Errorf("error getting carts: %w", sql.ErrNoRows)
+	}
+	to := m.cartLocked(toID).Items
+	now := time.Now()
+lines:
+	for _, item := range m.cartLocked(fromID).Items {
+		for _, existing := range to {
+			if !existing.sameLine(&item) {
+				continue
+			}
+			quantity := mergeQuantity(item.Quantity, existing.Quantity)
+			if quantity != existing.Quantity {
+				existing.Quantity, existing.UpdatedAt = quantity, &now
+				m.cartItems[existing.ID] = existing
+			}
+			delete(m.cartItems, item.ID)
+			continue lines
+		}
+		item.CartID, item.UpdatedAt = toID, &now
+		m.cartItems[item.ID] = item
+	}
+	delete(m.carts, fromID)
+	m.touchCartLocked(toID)
+	return nil
+}
+
+func (m *MemoryStorage) DeleteExpiredGuestCarts(ctx context.Context, before time.Time) (int64, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	var n int64
+	for _, c := range m.carts {
+		if c.UserID != nil || !c.LastActive().Before(before) {
+			continue
+		}
+		for _, item := range m.cartItems {
+			if item.CartID == c.ID {
+				delete(m.cartItems, item.ID)
+			}
+		}
+		delete(m.carts, c.ID)
+		n++
+	}
+	return n, nil
+}
+
+func (m *MemoryStorage) AddCartItem(ctx context.Context, item *CartItem) (*CartItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.carts[item.CartID]; !ok {
+		return nil, fmt.Errorf("error getting cart: %w", sql.ErrNoRows)
+	}
+	now := time.Now()
+	for _, existing := range m.cartItems {
+		if existing.CartID == item.CartID && existing.sameLine(item) {
+			existing.Quantity += item.Quantity
+			existing.UpdatedAt = &now
+			m.cartItems[existing.ID] = existing
+			m.touchCartLocked(item.CartID)
+			return &existing, nil
+		}
+	}
+	m.lastCartItemID++
+	item.ID = m.lastCartItemID
+	item.CreatedAt = now
+	m.cartItems[item.ID] = *item
+	m.touchCartLocked(item.CartID)
+	return item, nil
+}
+
+func (m *MemoryStorage) UpdateCartItem(ctx context.Context, cartID, itemID, quantity int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.cartItems[itemID]
+	if !ok || item.CartID != cartID {
+		return fmt.Errorf("error getting cart item: %w", sql.ErrNoRows)
+	}
+	now := time.Now()
+	item.Quantity, item.UpdatedAt = quantity, &now
+	m.cartItems[itemID] = item
+	m.touchCartLocked(cartID)
+	return nil
+}
+
+func (m *MemoryStorage) DeleteCartItem(ctx context.Context, cartID, itemID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.cartItems[itemID]
+	if !ok || item.CartID != cartID {
+		return fmt.Errorf("error getting cart item: %w", sql.ErrNoRows)
+	}
+	delete(m.cartItems, itemID)
+	m.touchCartLocked(cartID)
+	return nil
+}
+
+func (m *MemoryStorage) ClearCart(ctx context.Context, cartID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, item := range m.cartItems {
+		if item.CartID == cartID {
+			delete(m.cartItems, item.ID)
+		}
+	}
+	m.touchCartLocked(cartID)
+	return nil
+}
+
+// cartLocked returns a copy of a cart with its lines, oldest first. The
+// caller must hold m.mu.
+func (m *MemoryStorage) cartLocked(id int) *Cart {
+	c := m.carts[id]
+	c.Items = nil
+	for _, item := range m.cartItems {
+		if item.CartID == id {
+			c.Items = append(c.Items, item)
+		}
+	}
+	sort.Slice(c.Items, func(i, j int) bool { return c.Items[i].ID < c.Items[j].ID })
+	return &c
+}
+
+// touchCartLocked records that a cart changed. The caller must hold m.mu for
+// writing.
+func (m *MemoryStorage) touchCartLocked(id int) {
+	if c, ok := m.carts[id]; ok {
+		now := time.Now()
+		c.UpdatedAt = &now
+		m.carts[id] = c
+	}
+}
+
+func (m *MemoryStorage) CreateWishlist(ctx context.Context, w *Wishlist) (*Wishlist, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastWishlistID++
+	w.ID = m.lastWishlistID
+	w.ShareToken, w.UpdatedAt = nil, nil
+	w.CreatedAt = time.Now()
+	m.wishlists[w.ID] = *w
+	return m.wishlistLocked(w.ID), nil
+}
+
+func (m *MemoryStorage) GetWishlist(ctx context.Context, id int) (*Wishlist, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	if _, ok := m.wishlists[id]; !ok {
+		return nil, fmt.Errorf("error getting wishlist: %w", sql.ErrNoRows)
+	}
+	return m.wishlistLocked(id), nil
+}
+
+func (m *MemoryStorage) GetWishlistByShareToken(ctx context.Context, token string) (*Wishlist, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, w := range m.wishlists {
+		if w.ShareToken != nil && *w.ShareToken == token {
+			return m.wishlistLocked(w.ID), nil
+		}
+	}
+	return nil, fmt.Errorf("error getting wishlist: %w", sql.ErrNoRows)
+}
+
+func (m *MemoryStorage) ListUserWishlists(ctx context.Context, userID int) ([]Wishlist, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	wishlists := []Wishlist{}
+	for _, w := range m.wishlists {
+		if w.UserID == userID {
+			wishlists = append(wishlists, *m.wishlistLocked(w.ID))
+		}
+	}
+	sort.Slice(wishlists, func(i, j int) bool { return wishlists[i].ID < wishlists[j].ID })
+	return wishlists, nil
+}
+
+func (m *MemoryStorage) UpdateWishlist(ctx context.Context, w *Wishlist) (*Wishlist, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.wishlists[w.ID]
+	if !ok {
+		return nil, fmt.Errorf("error getting wishlist: %w", sql.ErrNoRows)
+	}
+	old.Name, old.ShareToken, old.UpdatedAt = w.Name, w.ShareToken, w.UpdatedAt
+	m.wishlists[w.ID] = old
+	return m.wishlistLocked(w.ID), nil
+}
+
+func (m *MemoryStorage) DeleteWishlist(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, item := range m.wishItems {
+		if item.WishlistID == id {
+			delete(m.wishItems, item.ID)
+		}
+	}
+	delete(m.wishlists, id)
+	return nil
+}
+
+func (m *MemoryStorage) AddWishlistItem(ctx context.Context, item *WishlistItem) (*WishlistItem, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.wishlists[item.WishlistID]; !ok {
+		return nil, fmt.Errorf("error getting wishlist: %w", sql.ErrNoRows)
+	}
+	for _, existing := range m.wishItems {
+		if existing.WishlistID == item.WishlistID && existing.sameLine(item) {
+			return &existing, nil
+		}
+	}
+	m.lastWishItemID++
+	item.ID = m.lastWishItemID
+	item.CreatedAt = time.Now()
+	m.wishItems[item.ID] = *item
+	m.touchWishlistLocked(item.WishlistID)
+	return item, nil
+}
+
+func (m *MemoryStorage) DeleteWishlistItem(ctx context.Context, wishlistID, itemID int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	item, ok := m.wishItems[itemID]
+	if !ok || item.WishlistID != wishlistID {
+		return fmt.Errorf("error getting wishlist item: %w", sql.ErrNoRows)
+	}
+	delete(m.wishItems, itemID)
+	m.touchWishlistLocked(wishlistID)
+	return nil
+}
+
+func (m *MemoryStorage) ListWishlistedProducts(ctx context.Context, page Page) ([]WishlistedProduct, *Cursor, error) {
+	if page.After != nil && page.After.Sort != wishlistedSort {
+		return nil, nil, ErrInvalidCursor
+	}
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	wishlists := make(map[int]map[int]bool)
+	users := make(map[int]map[int]bool)
+	for _, item := range m.wishItems {
+		w := m.wishlists[item.WishlistID]
+		if wishlists[item.ProductID] == nil {
+			wishlists[item.ProductID] = make(map[int]bool)
+			users[item.ProductID] = make(map[int]bool)
+		}
+		wishlists[item.ProductID][w.ID] = true
+		users[item.ProductID][w.UserID] = true
+	}
+	var after *WishlistedProduct
+	if page.After != nil {
+		after = &WishlistedProduct{ProductID: page.After.ID, Wishlists: int(page.After.Value)}
+	}
+	products := []WishlistedProduct{}
+	for productID := range wishlists {
+		p := WishlistedProduct{
+			ProductID: productID,
+			Name:      m.products[productID].Name,
+			Wishlists: len(wishlists[productID]),
+			Users:     len(users[productID]),
+		}
+		if after != nil && !wishlistedBefore(after, &p) {
+			continue
+		}
+		products = append(products, p)
+	}
+	sort.Slice(products, func(i, j int) bool { return wishlistedBefore(&products[i], &products[j]) })
+	n, next := nextCursor(len(products), page.Limit, func(i int) int { return products[i].ProductID })
+	products = products[:n]
+	if n > 0 {
+		next = wishlistedCursor(next, &products[n-1])
+	}
+	return products, next, nil
+}
+
+// wishlistLocked returns a copy of a wishlist with its items, oldest first.
+// The caller must hold m.mu.
+func (m *MemoryStorage) wishlistLocked(id int) *Wishlist {
+	w := m.wishlists[id]
+	w.Items = nil
+	for _, item := range m.wishItems {
+		if item.WishlistID == id {
+			w.Items = append(w.Items, item)
+		}
+	}
+	sort.Slice(w.Items, func(i, j int) bool { return w.Items[i].ID < w.Items[j].ID })
+	return &w
+}
+
+// touchWishlistLocked records that a wishlist changed. The caller must hold
+// m.mu for writing.
+func (m *MemoryStorage) touchWishlistLocked(id int) {
+	if w, ok := m.wishlists[id]; ok {
+		now := time.Now()
+		w.UpdatedAt = &now
+		m.wishlists[id] = w
+	}
+}
+
+func (m *MemoryStorage) CreateCategory(ctx context.Context, c *Category) (*Category, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, other := range m.categories {
+		if other.Slug == c.Slug {
+			return nil, fmt.Errorf("error inserting category: duplicate slug %q", c.Slug)
+		}
+	}
+	m.lastCategoryID++
+	c.ID = m.lastCategoryID
+	c.CreatedAt = time.Now()
+	m.categories[c.ID] = *c
+	return c, nil
+}
+
+func (m *MemoryStorage) GetCategory(ctx context.Context, id int) (*Category, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.categories[id]
+	if !ok {
+		return nil, fmt.Errorf("error getting category: %w", sql.ErrNoRows)
+	}
+	return &c, nil
+}
+
+func (m *MemoryStorage) ListCategories(ctx context.Context) ([]Category, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	return m.categoryListLocked(), nil
+}
+
+func (m *MemoryStorage) categoryListLocked() []Category {
+	categories := make([]Category, 0, len(m.categories))
+	for _, c := range m.categories {
+		categories = append(categories, c)
+	}
+	SortCategories(categories)
+	return categories
+}
+
+func (m *MemoryStorage) UpdateCategory(ctx context.Context, c *Category) (*Category, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, other := range m.categories {
+		if other.ID != c.ID && other.Slug == c.Slug {
+			return nil, fmt.Errorf("error updating category: duplicate slug %q", c.Slug)
+		}
+	}
+	if _, ok := m.categories[c.ID]; ok {
+		m.categories[c.ID] = *c
+	}
+	return c, nil
+}
+
+func (m *MemoryStorage) DeleteCategory(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, c := range m.categories {
+		if c.ParentID != nil && *c.ParentID == id {
+			return ErrCategoryInUse
+		}
+	}
+	for _, p := range m.products {
+		if p.CategoryID != nil && *p.CategoryID == id {
+			return ErrCategoryInUse
+		}
+	}
+	delete(m.categories, id)
+	return nil
+}
+
+func (m *MemoryStorage) CreateOrder(ctx context.Context, o *Order) (*Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	// everything below happens under one lock, so the stock update, the order
+	// and its items become visible together or not at all
+	wantedProducts := make(map[int]int)
+	wantedVariants := make(map[int]int)
+	for _, oi := range o.Items {
+		if oi.VariantID != nil {
+			wantedVariants[*oi.VariantID] += oi.Quantity
+		} else {
+			wantedProducts[oi.ProductID] += oi.Quantity
+		}
+	}
+	var shortProducts, shortVariants []int
+	for id, qty := range wantedProducts {
+		if p, ok := m.products[id]; !ok || p.CountInStock < qty {
+			shortProducts = append(shortProducts, id)
+		}
+	}
+	for id, qty := range wantedVariants {
+		if v, ok := m.variants[id]; !ok || v.CountInStock < qty {
+			shortVariants = append(shortVariants, id)
+		}
+	}
+	if len(shortProducts) > 0 || len(shortVariants) > 0 {
+		sort.Ints(shortProducts)
+		sort.Ints(shortVariants)
+		return nil, fmt.Errorf("error creating order: %w", &InsufficientStockError{ProductIDs: shortProducts, VariantIDs: shortVariants})
+	}
+	if o.CouponID != nil {
+		if err := m.checkCouponLimitsLocked(*o.CouponID, o.UserId); err != nil {
+			return nil, fmt.Errorf("error creating order: %w", err)
+		}
+	}
+	for _, oi := range o.Items {
+		m.adjustStockLocked(oi, -oi.Quantity)
+	}
+
+	m.lastOrderID++
+	o.ID = m.lastOrderID
+	if o.CouponID != nil {
+		m.redeemCouponLocked(o)
+	}
+	o.Status = OrderStatusPending
+	o.CreatedAt = time.Now()
+	m.addStatusChangeLocked(OrderStatusChange{
+		OrderID:   o.ID,
+		ToStatus:  OrderStatusPending,
+		ChangedBy: o.UserId,
+		Reason:    "order created",
+	})
+
+	items := make([]OrderItem, len(o.Items))
+	for i, oi := range o.Items {
+		m.lastOrderItemID++
+		oi.ID = m.lastOrderItemID
+		oi.OrderID = o.ID
+		m.orderItems[oi.ID] = oi
+		items[i] = oi
+	}
+	o.Items = items
+
+	stored := *o
+	stored.Items = nil
+	m.orders[o.ID] = stored
+	return o, nil
+}
+
+func (m *MemoryStorage) GetOrder(ctx context.Context, id int) (*Order, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	o, ok := m.orders[id]
+	if !ok {
+		return nil, fmt.Errorf("error getting order: %w", sql.ErrNoRows)
+	}
+	o.Items = m.orderItemsLocked(o.ID)
+	return &o, nil
+}
+
+func (m *MemoryStorage) ListUserOrders(ctx context.Context, userId int, page Page) ([]Order, *Cursor, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	orders, next := m.pageOrdersLocked(func(o Order) bool { return o.UserId == userId }, page)
+	return orders, next, nil
+}
+
+func (m *MemoryStorage) ListOrders(ctx context.Context, page Page) ([]Order, *Cursor, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	orders, next := m.pageOrdersLocked(func(o Order) bool { return true }, page)
+	return orders, next, nil
+}
+
+// pageOrdersLocked returns one page of the orders accepted by keep, newest
+// first, with their items. The caller must hold m.mu.
+func (m *MemoryStorage) pageOrdersLocked(keep func(Order) bool, page Page) ([]Order, *Cursor) {
+	var orders []Order
+	for _, o := range m.orders {
+		if keep(o) && (page.After == nil || o.ID < page.After.ID) {
+			orders = append(orders, o)
+		}
+	}
+	sort.Slice(orders, func(i, j int) bool { return orders[i].ID > orders[j].ID })
+	n, next := nextCursor(len(orders), page.Limit, func(i int) int { return orders[i].ID })
+	orders = orders[:n]
+	for i := range orders {
+		orders[i].Items = m.orderItemsLocked(orders[i].ID)
+	}
+	return orders, next
+}
+
+func (m *MemoryStorage) UpdateOrderStatus(ctx context.Context, id int, status OrderStatus, changedBy int, reason string) (*Order, error) {
+	return m.updateOrderStatus(id, nil, status, changedBy, reason)
+}
+
+func (m *MemoryStorage) CancelOrder(ctx context.Context, id int, from []OrderStatus, changedBy int, reason string) (*Order, error) {
+	return m.updateOrderStatus(id, from, OrderStatusCancelled, changedBy, reason)
+}
+
+// updateOrderStatus moves an order to status if allowed is nil or holds the
+// order's current status.
+func (m *MemoryStorage) updateOrderStatus(id int, allowed []OrderStatus, status OrderStatus, changedBy int, reason string) (*Order, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	o, ok := m.orders[id]
+	if !ok {
+		return nil, fmt.Errorf("error getting order: %w", sql.ErrNoRows)
+	}
+	from := o.Status
+	if allowed != nil && !slices.Contains(allowed, from) {
+		return nil, fmt.Errorf("error updating order status: %w", &InvalidStatusTransitionError{From: from, To: status})
+	}
+	if err := m.setOrderStatusLocked(&o, status, changedBy, reason); err != nil {
+		return nil, fmt.Errorf("error updating order status: %w", err)
+	}
+	o.Items = m.orderItemsLocked(o.ID)
+	if from.releasesReservation(status) {
+		for _, oi := range o.Items {
+			m.adjustStockLocked(oi, oi.Quantity)
+		}
+		m.releaseCouponLocked(o.ID)
+	}
+	return &o, nil
+}
+
+// adjustStockLocked changes the stock of an item's variant, if it has one,
+// and of its product by delta.
+func (m *MemoryStorage) adjustStockLocked(oi OrderItem, delta int) {
+	if oi.VariantID != nil {
+		if v, ok := m.variants[*oi.VariantID]; ok {
+			v.CountInStock += delta
+			m.variants[v.ID] = v
+		}
+	}
+	if p, ok := m.products[oi.ProductID]; ok {
+		p.CountInStock += delta
+		m.products[p.ID] = p
+	}
+}
+
+func (m *MemoryStorage) ListOrderStatusHistory(ctx context.Context, orderID int) ([]OrderStatusChange, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	var history []OrderStatusChange
+	for _, c := range m.statusHistory {
+		if c.OrderID == orderID {
+			history = append(history, c)
+		}
+	}
+	return history, nil
+}
+
+// setOrderStatusLocked mirrors setOrderStatus for the in-memory store. The
+// caller must hold m.mu for writing.
+func (m *MemoryStorage) setOrderStatusLocked(o *Order, status OrderStatus, changedBy int, reason string) error {
+	if !o.Status.CanTransitionTo(status) {
+		return &InvalidStatusTransitionError{From: o.Status, To: status}
+	}
+	now := time.Now()
+	m.addStatusChangeLocked(OrderStatusChange{
+		OrderID:    o.ID,
+		FromStatus: o.Status,
+		ToStatus:   status,
+		ChangedBy:  changedBy,
+		Reason:     reason,
+	})
+	o.Status = status
+	o.UpdatedAt = &now
+
+	stored := *o
+	stored.Items = nil
+	m.orders[o.ID] = stored
+	return nil
+}
+
+func (m *MemoryStorage) addStatusChangeLocked(c OrderStatusChange) {
+	m.lastStatusID++
+	c.ID = m.lastStatusID
+	c.CreatedAt = time.Now()
+	m.statusHistory = append(m.statusHistory, c)
+}
+
+// orderItemsLocked returns the items of an order sorted by ID. The caller
+// must hold m.mu.
+func (m *MemoryStorage) orderItemsLocked(orderID int) []OrderItem {
+	var items []OrderItem
+	for _, oi := range m.orderItems {
+		if oi.OrderID == orderID {
+			items = append(items, oi)
+		}
+	}
+	sort.Slice(items, func(i, j int) bool { return items[i].ID < items[j].ID })
+	return items
+}
+
+func (m *MemoryStorage) CreateUser(ctx context.Context, u *User) (*User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, existing := range m.users {
+		if existing.Email == u.Email {
+			return nil, fmt.Errorf("error inserting user: duplicate email %q", u.Email)
+		}
+	}
+	m.lastUserID++
+	u.ID = m.lastUserID
+	u.CreatedAt = time.Now()
+	m.users[u.ID] = *u
+	return u, nil
+}
+
+func (m *MemoryStorage) GetUser(ctx context.Context, email string) (*User, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, u := range m.users {
+		if u.Email == email {
+			return &u, nil
+		}
+	}
+	return nil, fmt.Errorf("error getting user: %w", sql.ErrNoRows)
+}
+
+func (m *MemoryStorage) ListUsers(ctx context.Context, page Page) ([]User, *Cursor, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	users := make([]User, 0, len(m.users))
+	for _, u := range m.users {
+		if page.After == nil || u.ID > page.After.ID {
+			users = append(users, u)
+		}
+	}
+	sort.Slice(users, func(i, j int) bool { return users[i].ID < users[j].ID })
+	n, next := nextCursor(len(users), page.Limit, func(i int) int { return users[i].ID })
+	return users[:n], next, nil
+}
+
+func (m *MemoryStorage) UpdateUser(ctx context.Context, u *User) (*User, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.users[u.ID]; ok {
+		m.users[u.ID] = *u
+	}
+	return u, nil
+}
+
+func (m *MemoryStorage) DeleteUser(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.users, id)
+	return nil
+}
+
+func (m *MemoryStorage) CreateSession(ctx context.Context, s *Session) (*Session, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.sessions[s.ID]; ok {
+		return nil, fmt.Errorf("error inserting session: duplicate id %q", s.ID)
+	}
+	s.CreatedAt = time.Now()
+	m.sessions[s.ID] = *s
+	return s, nil
+}
+
+func (m *MemoryStorage) GetSession(ctx context.Context, id string) (*Session, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	s, ok := m.sessions[id]
+	if !ok {
+		return nil, fmt.Errorf("error getting session: %w", sql.ErrNoRows)
+	}
+	return &s, nil
+}
+
+func (m *MemoryStorage) RevokeSession(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if s, ok := m.sessions[id]; ok {
+		s.IsRevoked = true
+		m.sessions[id] = s
+	}
+	return nil
+}
+
+func (m *MemoryStorage) DeleteSession(ctx context.Context, id string) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.sessions, id)
+	return nil
+}
+
+func (m *MemoryStorage) CreateTaxRate(ctx context.Context, tr *TaxRate) (*TaxRate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastTaxRateID++
+	tr.ID = m.lastTaxRateID
+	tr.CreatedAt = time.Now()
+	m.taxRates[tr.ID] = *tr
+	return tr, nil
+}
+
+func (m *MemoryStorage) GetTaxRate(ctx context.Context, id int) (*TaxRate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	tr, ok := m.taxRates[id]
+	if !ok {
+		return nil, fmt.Errorf("error getting tax rate: %w", sql.ErrNoRows)
+	}
+	return &tr, nil
+}
+
+func (m *MemoryStorage) ListTaxRates(ctx context.Context) ([]TaxRate, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	rates := make([]TaxRate, 0, len(m.taxRates))
+	for _, tr := range m.taxRates {
+		rates = append(rates, tr)
+	}
+	sort.Slice(rates, func(i, j int) bool { return rates[i].ID < rates[j].ID })
+	return rates, nil
+}
+
+func (m *MemoryStorage) UpdateTaxRate(ctx context.Context, tr *TaxRate) (*TaxRate, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if _, ok := m.taxRates[tr.ID]; ok {
+		m.taxRates[tr.ID] = *tr
+	}
+	return tr, nil
+}
+
+func (m *MemoryStorage) DeleteTaxRate(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.taxRates, id)
+	return nil
+}
+
+func (m *MemoryStorage) CreateCoupon(ctx context.Context, c *Coupon) (*Coupon, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	if err := m.checkCouponCodeLocked(c); err != nil {
+		return nil, err
+	}
+	m.lastCouponID++
+	c.ID = m.lastCouponID
+	c.TimesUsed = 0
+	c.CreatedAt = time.Now()
+	c.UpdatedAt = nil
+	m.coupons[c.ID] = copyCoupon(*c)
+	return c, nil
+}
+
+func (m *MemoryStorage) GetCoupon(ctx context.Context, id int) (*Coupon, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	c, ok := m.coupons[id]
+	if !ok {
+		return nil, fmt.Errorf("error getting coupon: %w", sql.ErrNoRows)
+	}
+	c = copyCoupon(c)
+	return &c, nil
+}
+
+func (m *MemoryStorage) GetCouponByCode(ctx context.Context, code string) (*Coupon, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	for _, c := range m.coupons {
+		if c.Code == code {
+			c = copyCoupon(c)
+			return &c, nil
+		}
+	}
+	return nil, fmt.Errorf("error getting coupon: %w", sql.ErrNoRows)
+}
+
+func (m *MemoryStorage) ListCoupons(ctx context.Context) ([]Coupon, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	coupons := make([]Coupon, 0, len(m.coupons))
+	for _, c := range m.coupons {
+		coupons = append(coupons, copyCoupon(c))
+	}
+	sort.Slice(coupons, func(i, j int) bool { return coupons[i].ID < coupons[j].ID })
+	return coupons, nil
+}
+
+// UpdateCoupon saves c and replaces its restrictions. TimesUsed is kept as
+// it is in the store.
+func (m *MemoryStorage) UpdateCoupon(ctx context.Context, c *Coupon) (*Coupon, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.coupons[c.ID]
+	if !ok {
+		return nil, fmt.Errorf("error getting coupon: %w", sql.ErrNoRows)
+	}
+	if err := m.checkCouponCodeLocked(c); err != nil {
+		return nil, err
+	}
+	c.TimesUsed, c.CreatedAt = old.TimesUsed, old.CreatedAt
+	m.coupons[c.ID] = copyCoupon(*c)
+	return c, nil
+}
+
+// DeleteCoupon deletes a coupon that has never been redeemed. It returns
+// ErrCouponRedeemed otherwise.
+func (m *MemoryStorage) DeleteCoupon(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, r := range m.redemptions {
+		if r.CouponID == id {
+			return ErrCouponRedeemed
+		}
+	}
+	delete(m.coupons, id)
+	return nil
+}
+
+func (m *MemoryStorage) checkCouponCodeLocked(c *Coupon) error {
+	for _, other := range m.coupons {
+		if other.ID != c.ID && other.Code == c.Code {
+			return ErrDuplicateCouponCode
+		}
+	}
+	return nil
+}
+
+// checkCouponLimitsLocked returns ErrCouponLimitReached if the coupon cannot
+// be used once more by the user. The caller must hold m.mu.
+func (m *MemoryStorage) checkCouponLimitsLocked(couponID, userID int) error {
+	c, ok := m.coupons[couponID]
+	if !ok {
+		return fmt.Errorf("error getting coupon: %w", sql.ErrNoRows)
+	}
+	if c.UsageLimit > 0 && c.TimesUsed >= c.UsageLimit {
+		return fmt.Errorf("%w: coupon %s", ErrCouponLimitReached, c.Code)
+	}
+	if c.PerCustomerLimit > 0 {
+		n := 0
+		for _, r := range m.redemptions {
+			if r.CouponID == couponID && r.UserID == userID {
+				n++
+			}
+		}
+		if n >= c.PerCustomerLimit {
+			return fmt.Errorf("%w: coupon %s for this customer", ErrCouponLimitReached, c.Code)
+		}
+	}
+	return nil
+}
+
+// redeemCouponLocked counts a use of the coupon of o. The caller must hold
+// m.mu for writing and have checked the limits.
+func (m *MemoryStorage) redeemCouponLocked(o *Order) {
+	c := m.coupons[*o.CouponID]
+	c.TimesUsed++
+	m.coupons[c.ID] = c
+	m.lastRedemptionID++
+	m.redemptions = append(m.redemptions, CouponRedemption{
+		ID:        m.lastRedemptionID,
+		CouponID:  c.ID,
+		UserID:    o.UserId,
+		OrderID:   o.ID,
+		Discount:  o.DiscountPrice,
+		CreatedAt: time.Now(),
+	})
+}
+
+// releaseCouponLocked gives back the coupon use of an order, if it used one.
+// The caller must hold m.mu for writing.
+func (m *MemoryStorage) releaseCouponLocked(orderID int) {
+	kept := m.redemptions[:0]
+	for _, r := range m.redemptions {
+		if r.OrderID != orderID {
+			kept = append(kept, r)
+			continue
+		}
+		if c, ok := m.coupons[r.CouponID]; ok {
+			c.TimesUsed--
+			m.coupons[c.ID] = c
+		}
+	}
+	m.redemptions = kept
+}
+
+// copyCoupon returns c with its own copies of the restriction slices.
+func copyCoupon(c Coupon) Coupon {
+	c.CategoryIDs = append([]int(nil), c.CategoryIDs...)
+	c.ProductIDs = append([]int(nil), c.ProductIDs...)
+	return c
+}
+
+func (m *MemoryStorage) CreatePromotion(ctx context.Context, p *Promotion) (*Promotion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	m.lastPromotionID++
+	p.ID = m.lastPromotionID
+	p.CreatedAt = time.Now()
+	p.UpdatedAt = nil
+	m.promotions[p.ID] = copyPromotion(*p)
+	return p, nil
+}
+
+func (m *MemoryStorage) GetPromotion(ctx context.Context, id int) (*Promotion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	p, ok := m.promotions[id]
+	if !ok {
+		return nil, fmt.Errorf("error getting promotion: %w", sql.ErrNoRows)
+	}
+	p = copyPromotion(p)
+	return &p, nil
+}
+
+func (m *MemoryStorage) ListPromotions(ctx context.Context) ([]Promotion, error) {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+
+	promotions := make([]Promotion, 0, len(m.promotions))
+	for _, p := range m.promotions {
+		promotions = append(promotions, copyPromotion(p))
+	}
+	sort.Slice(promotions, func(i, j int) bool { return promotions[i].ID < promotions[j].ID })
+	return promotions, nil
+}
+
+// UpdatePromotion saves p and replaces its restrictions.
+func (m *MemoryStorage) UpdatePromotion(ctx context.Context, p *Promotion) (*Promotion, error) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	old, ok := m.promotions[p.ID]
+	if !ok {
+		return nil, fmt.Errorf("error getting promotion: %w", sql.ErrNoRows)
+	}
+	p.CreatedAt = old.CreatedAt
+	m.promotions[p.ID] = copyPromotion(*p)
+	return p, nil
+}
+
+func (m *MemoryStorage) DeletePromotion(ctx context.Context, id int) error {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	delete(m.promotions, id)
+	return nil
+}
+
+// copyPromotion returns p with its own copies of the restriction slices.
+func copyPromotion(p Promotion) Promotion {
+	p.CategoryIDs = append([]int(nil), p.CategoryIDs...)
+	p.ProductIDs = append([]int(nil), p.ProductIDs...)
+	return p
+}
@@ -0,0 +1,2146 @@
+package storer
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"slices"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jmoiron/sqlx"
+)
+
+// sqlStorage holds the queries shared by the database/sql backends. The
+// statements stick to the subset of SQL that MySQL and SQLite agree on.
+type sqlStorage struct {
+	*sqlx.DB
+}
+
+func (st *sqlStorage) CreateProduct(ctx context.Context, p *Product) (*Product, error) {
+	p.CreatedAt = time.Now().UTC()
+	res, err := st.DB.NamedExecContext(ctx, "INSERT INTO products (name, image, category_id, description, price, weight, count_in_stock, created_at) VALUES (:name, :image, :category_id, :description, :price, :weight, :count_in_stock, :created_at)", p)
+	if err != nil {
+		return nil, fmt.Errorf("error inserting product: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	p.ID = int(id)
+	return p, nil
+}
+
+func (st *sqlStorage) GetProduct(ctx context.Context, id int) (*Product, error) {
+	var p Product
+	err := st.GetContext(ctx, &p, "SELECT * FROM products WHERE ID = ?", id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting product: %w", err)
+	}
+	products := []Product{p}
+	if err := st.attachProductDetails(ctx, products); err != nil {
+		return nil, err
+	}
+	return &products[0], nil
+}
+
+// productRow is a product together with its search relevance.
+type productRow struct {
+	Product
+	Score float64 `db:"score"`
+}
+
+func (st *sqlStorage) ListProducts(ctx context.Context, filter ProductFilter, page Page) ([]Product, *Cursor, error) {
+	order := filter.EffectiveSort()
+	if err := checkProductCursor(order, page.After); err != nil {
+		return nil, nil, err
+	}
+	if filter.Category != "" {
+		categories, err := st.ListCategories(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		if err := filter.resolveCategory(categories); err != nil {
+			return nil, nil, err
+		}
+	}
+
+	score, scoreArgs, where, args := st.productConditions(filter)
+	query := "SELECT products.*, " + score + " AS score FROM products"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	queryArgs := append(scoreArgs, args...)
+
+	// The sort column is compared in an outer query so that it can refer to
+	// the score alias.
+	cmp, dir := ">", "ASC"
+	if order.descending() {
+		cmp, dir = "<", "DESC"
+	}
+	var col string
+	switch order {
+	case ProductSortPriceAsc, ProductSortPriceDesc:
+		col = "price"
+	case ProductSortRating:
+		col = "rating"
+	case ProductSortRelevance:
+		col = "score"
+	}
+	query = "SELECT * FROM (" + query + ") p"
+	if page.After != nil {
+		if col != "" {
+			query += fmt.Sprintf(" WHERE (%[1]s %[2]s ? OR (%[1]s = ? AND id %[2]s ?))", col, cmp)
+			queryArgs = append(queryArgs, page.After.Value, page.After.Value, page.After.ID)
+		} else {
+			query += " WHERE id " + cmp + " ?"
+			queryArgs = append(queryArgs, page.After.ID)
+		}
+	}
+	if col != "" {
+		query += " ORDER BY " + col + " " + dir + ", id " + dir
+	} else {
+		query += " ORDER BY id " + dir
+	}
+	query += " LIMIT ?"
+	queryArgs = append(queryArgs, page.Limit+1)
+
+	var rows []productRow
+	if err := st.DB.SelectContext(ctx, &rows, query, queryArgs...); err != nil {
+		return nil, nil, fmt.Errorf("error listing products: %w", err)
+	}
+	n, next := nextCursor(len(rows), page.Limit, func(i int) int { return rows[i].ID })
+	products := make([]Product, n)
+	for i := range products {
+		products[i] = rows[i].Product
+	}
+	if err := st.attachProductDetails(ctx, products); err != nil {
+		return nil, nil, err
+	}
+	if n > 0 {
+		next = productCursor(next, order, &rows[n-1].Product, rows[n-1].Score)
+	}
+	return products, next, nil
+}
+
+// productConditions translates filter into WHERE conditions and a relevance
+// expression. MySQL uses the FULLTEXT index; the other backends require
+// every search term in the name or description, like MemoryStorage.
+func (st *sqlStorage) productConditions(filter ProductFilter) (score string, scoreArgs []interface{}, where []string, args []interface{}) {
+	score = "0"
+	if filter.Query != "" {
+		if st.DriverName() == "mysql" {
+			score = "MATCH(name, description) AGAINST(? IN NATURAL LANGUAGE MODE)"
+			scoreArgs = append(scoreArgs, filter.Query)
+			where = append(where, score+" > 0")
+			args = append(args, filter.Query)
+		} else {
+			var parts []string
+			for _, t := range searchTerms(filter.Query) {
+				like := "%" + escapeLike(t) + "%"
+				parts = append(parts, `(CASE WHEN LOWER(name) LIKE ? ESCAPE '\' THEN 2 ELSE 0 END + CASE WHEN LOWER(description) LIKE ? ESCAPE '\' THEN 1 ELSE 0 END)`)
+				scoreArgs = append(scoreArgs, like, like)
+				where = append(where, `(LOWER(name) LIKE ? ESCAPE '\' OR LOWER(description) LIKE ? ESCAPE '\')`)
+				args = append(args, like, like)
+			}
+			if len(parts) > 0 {
+				score = strings.Join(parts, " + ")
+			}
+		}
+	}
+	if filter.Category != "" {
+		where = append(where, "category_id IN (?"+strings.Repeat(", ?", len(filter.categoryIDs)-1)+")")
+		for _, id := range filter.categoryIDs {
+			args = append(args, id)
+		}
+	}
+	if filter.MinPrice != nil {
+		where = append(where, "price >= ?")
+		args = append(args, *filter.MinPrice)
+	}
+	if filter.MaxPrice != nil {
+		where = append(where, "price <= ?")
+		args = append(args, *filter.MaxPrice)
+	}
+	if filter.MinRating > 0 {
+		where = append(where, "rating >= ?")
+		args = append(args, filter.MinRating)
+	}
+	if filter.InStock {
+		where = append(where, "count_in_stock > 0")
+	}
+
+	return score, scoreArgs, where, args
+}
+
+// escapeLike escapes the LIKE wildcards in s for use with ESCAPE '\'.
+func escapeLike(s string) string {
+	return strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(s)
+}
+
+func (st *sqlStorage) ProductFacets(ctx context.Context, filter ProductFilter) (*ProductFacets, error) {
+	categories, err := st.ListCategories(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if err := filter.resolveCategory(categories); err != nil {
+		return nil, err
+	}
+
+	anyCategory, anyPrice, anyRating, anyStock := filter, filter, filter, filter
+	anyCategory.Category, anyCategory.categoryIDs = "", nil
+	anyPrice.MinPrice, anyPrice.MaxPrice = nil, nil
+	anyRating.MinRating = 0
+	anyStock.InStock = false
+
+	facets := &ProductFacets{}
+	query, args := st.productFacetQuery(anyCategory, "category_id, COUNT(*) AS count", "GROUP BY category_id")
+	if err := st.DB.SelectContext(ctx, &facets.Categories, query, args...); err != nil {
+		return nil, fmt.Errorf("error counting product categories: %w", err)
+	}
+	fillCategoryCounts(facets.Categories, categories)
+
+	bucket := "CASE"
+	var bucketArgs []interface{}
+	for i, bound := range PriceBucketBounds {
+		bucket += fmt.Sprintf(" WHEN price < ? THEN %d", i)
+		bucketArgs = append(bucketArgs, bound)
+	}
+	bucket += fmt.Sprintf(" ELSE %d END", len(PriceBucketBounds))
+	var priceRows []struct {
+		Bucket int `db:"bucket"`
+		Count  int `db:"count"`
+	}
+	query, args = st.productFacetQuery(anyPrice, bucket+" AS bucket, COUNT(*) AS count", "GROUP BY bucket")
+	if err := st.DB.SelectContext(ctx, &priceRows, query, append(bucketArgs, args...)...); err != nil {
+		return nil, fmt.Errorf("error counting product prices: %w", err)
+	}
+	prices := make([]int, len(PriceBucketBounds)+1)
+	for _, row := range priceRows {
+		prices[row.Bucket] = row.Count
+	}
+	facets.Prices = newPriceBuckets(prices)
+
+	var ratingRows []struct {
+		Rating float64 `db:"rating"`
+		Count  int     `db:"count"`
+	}
+	query, args = st.productFacetQuery(anyRating, "rating, COUNT(*) AS count", "GROUP BY rating")
+	if err := st.DB.SelectContext(ctx, &ratingRows, query, args...); err != nil {
+		return nil, fmt.Errorf("error counting product ratings: %w", err)
+	}
+	var ratings [6]int
+	for _, row := range ratingRows {
+		if row.Rating >= 0 && row.Rating <= 5 {
+			ratings[int(row.Rating)] += row.Count
+		}
+	}
+	facets.Ratings = newRatingBuckets(ratings)
+
+	var stock struct {
+		InStock    int `db:"in_stock"`
+		OutOfStock int `db:"out_of_stock"`
+	}
+	query, args = st.productFacetQuery(anyStock, "COALESCE(SUM(CASE WHEN count_in_stock > 0 THEN 1 ELSE 0 END), 0) AS in_stock, COALESCE(SUM(CASE WHEN count_in_stock > 0 THEN 0 ELSE 1 END), 0) AS out_of_stock", "")
+	if err := st.DB.GetContext(ctx, &stock, query, args...); err != nil {
+		return nil, fmt.Errorf("error counting product stock: %w", err)
+	}
+	facets.InStock = stock.InStock
+	facets.OutOfStock = stock.OutOfStock
+	return facets, nil
+}
+
+// productFacetQuery builds a query selecting columns from the products that
+// match filter, followed by the given GROUP BY/ORDER BY clause.
+func (st *sqlStorage) productFacetQuery(filter ProductFilter, columns, tail string) (string, []interface{}) {
+	_, _, where, args := st.productConditions(filter)
+	query := "SELECT " + columns + " FROM products"
+	if len(where) > 0 {
+		query += " WHERE " + strings.Join(where, " AND ")
+	}
+	if tail != "" {
+		query += " " + tail
+	}
+	return query, args
+}
+
+func (st *sqlStorage) UpdateProduct(ctx context.Context, p *Product) (*Product, error) {
+	_, err := st.DB.NamedExecContext(ctx, "UPDATE products SET name=:name, image=:image, category_id=:category_id, description=:description, price=:price, weight=:weight, count_in_stock=:count_in_stock, updated_at=:updated_at WHERE id=:id", p)
+	if err != nil {
+		return nil, fmt.Errorf("error updating product: %w", err)
+	}
+	return p, nil
+}
+
+func (st *sqlStorage) DeleteProduct(ctx context.Context, id int) error {
+	return st.execTx(ctx, func(tx *sqlx.Tx) error {
+		for _, query := range []string{
+			"DELETE FROM product_options WHERE product_id=?",
+			"DELETE FROM product_variants WHERE product_id=?",
+			"DELETE FROM product_images WHERE product_id=?",
+			"DELETE FROM reviews WHERE product_id=?",
+			"DELETE FROM cart_items WHERE product_id=?",
+			"DELETE FROM wishlist_items WHERE product_id=?",
+			"DELETE FROM products where id=?",
+		} {
+			if _, err := tx.ExecContext(ctx, query, id); err != nil {
+				return fmt.Errorf("error deleting product: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// attachProductDetails loads the options, variants and images of all
+// products with one query each.
+func (st *sqlStorage) attachProductDetails(ctx context.Context, products []Product) error {
+	if len(products) == 0 {
+		return nil
+	}
+	ids := make([]int, len(products))
+	for i, p := range products {
+		ids[i] = p.ID
+	}
+
+	query, args, err := sqlx.In("SELECT * FROM product_options WHERE product_id IN (?) ORDER BY product_id, position, id", ids)
+	if err != nil {
+		return fmt.Errorf("error building product options query: %w", err)
+	}
+	var options []ProductOption
+	if err := st.DB.SelectContext(ctx, &options, st.DB.Rebind(query), args...); err != nil {
+		return fmt.Errorf("error listing product options: %w", err)
+	}
+	query, args, err = sqlx.In("SELECT * FROM product_variants WHERE product_id IN (?) ORDER BY product_id, id", ids)
+	if err != nil {
+		return fmt.Errorf("error building product variants query: %w", err)
+	}
+	var variants []ProductVariant
+	if err := st.DB.SelectContext(ctx, &variants, st.DB.Rebind(query), args...); err != nil {
+		return fmt.Errorf("error listing product variants: %w", err)
+	}
+
+	query, args, err = sqlx.In("SELECT * FROM product_images WHERE product_id IN (?) ORDER BY product_id, position, id", ids)
+	if err != nil {
+		return fmt.Errorf("error building product images query: %w", err)
+	}
+	var images []ProductImage
+	if err := st.DB.SelectContext(ctx, &images, st.DB.Rebind(query), args...); err != nil {
+		return fmt.Errorf("error listing product images: %w", err)
+	}
+
+	byProduct := make(map[int]int, len(products))
+	for i, p := range products {
+		byProduct[p.ID] = i
+	}
+	for _, o := range options {
+		p := &products[byProduct[o.ProductID]]
+		p.Options = append(p.Options, o)
+	}
+	for _, v := range variants {
+		p := &products[byProduct[v.ProductID]]
+		p.Variants = append(p.Variants, v)
+	}
+	for _, img := range images {
+		p := &products[byProduct[img.ProductID]]
+		p.Images = append(p.Images, img)
+	}
+	return nil
+}
+
+// SetProductOptions replaces the options of a product.
+func (st *sqlStorage) SetProductOptions(ctx context.Context, productID int, options []ProductOption) ([]ProductOption, error) {
+	err := st.execTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.ExecContext(ctx, "DELETE FROM product_options WHERE product_id=?", productID)
+		if err != nil {
+			return fmt.Errorf("error deleting product options: %w", err)
+		}
+		for i := range options {
+			o := &options[i]
+			o.ProductID = productID
+			res, err := tx.NamedExecContext(ctx, "INSERT INTO product_options (product_id, name, choices, position) VALUES (:product_id, :name, :choices, :position)", o)
+			if err != nil {
+				return fmt.Errorf("error inserting product option: %w", err)
+			}
+			id, err := res.LastInsertId()
+			if err != nil {
+				return fmt.Errorf("error getting last insert ID: %w", err)
+			}
+			o.ID = int(id)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return options, nil
+}
+
+func (st *sqlStorage) CreateVariant(ctx context.Context, v *ProductVariant) (*ProductVariant, error) {
+	err := st.execTx(ctx, func(tx *sqlx.Tx) error {
+		if err := checkSKU(ctx, tx, v); err != nil {
+			return err
+		}
+		v.CreatedAt = time.Now().UTC()
+		res, err := tx.NamedExecContext(ctx, "INSERT INTO product_variants (product_id, sku, option_values, price, image, count_in_stock, created_at) VALUES (:product_id, :sku, :option_values, :price, :image, :count_in_stock, :created_at)", v)
+		if err != nil {
+			return fmt.Errorf("error inserting variant: %w", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("error getting last insert ID: %w", err)
+		}
+		v.ID = int(id)
+		return syncProductStock(ctx, tx, v.ProductID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+func (st *sqlStorage) GetVariant(ctx context.Context, id int) (*ProductVariant, error) {
+	var v ProductVariant
+	err := st.DB.GetContext(ctx, &v, "SELECT * FROM product_variants WHERE id=?", id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting variant: %w", err)
+	}
+	return &v, nil
+}
+
+func (st *sqlStorage) UpdateVariant(ctx context.Context, v *ProductVariant) (*ProductVariant, error) {
+	err := st.execTx(ctx, func(tx *sqlx.Tx) error {
+		if err := checkSKU(ctx, tx, v); err != nil {
+			return err
+		}
+		_, err := tx.NamedExecContext(ctx, "UPDATE product_variants SET sku=:sku, option_values=:option_values, price=:price, image=:image, count_in_stock=:count_in_stock, updated_at=:updated_at WHERE id=:id", v)
+		if err != nil {
+			return fmt.Errorf("error updating variant: %w", err)
+		}
+		return syncProductStock(ctx, tx, v.ProductID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return v, nil
+}
+
+// DeleteVariant removes a variant. Deleting the last variant of a product
+// leaves the product's stock at zero.
+func (st *sqlStorage) DeleteVariant(ctx context.Context, id int) error {
+	return st.execTx(ctx, func(tx *sqlx.Tx) error {
+		var productID int
+		err := tx.GetContext(ctx, &productID, "SELECT product_id FROM product_variants WHERE id=?", id)
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil
+		}
+		if err != nil {
+			return fmt.Errorf("error getting variant: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM product_variants WHERE id=?", id); err != nil {
+			return fmt.Errorf("error deleting variant: %w", err)
+		}
+		return syncProductStock(ctx, tx, productID)
+	})
+}
+
+// CreateProductImage adds img after the product's other images.
+func (st *sqlStorage) CreateProductImage(ctx context.Context, img *ProductImage) (*ProductImage, error) {
+	err := st.execTx(ctx, func(tx *sqlx.Tx) error {
+		// Locking the product keeps concurrent uploads from taking the same
+		// position.
+		var productID int
+		err := tx.GetContext(ctx, &productID, "SELECT id FROM products WHERE id=?"+forUpdate(tx), img.ProductID)
+		if err != nil {
+			return fmt.Errorf("error getting product: %w", err)
+		}
+		err = tx.GetContext(ctx, &img.Position, "SELECT COALESCE(MAX(position) + 1, 0) FROM product_images WHERE product_id=?", img.ProductID)
+		if err != nil {
+			return fmt.Errorf("error getting image position: %w", err)
+		}
+		img.CreatedAt = time.Now().UTC()
+		res, err := tx.NamedExecContext(ctx, "INSERT INTO product_images (product_id, position, blob_key, content_type, width, height, thumbnails, created_at) VALUES (:product_id, :position, :blob_key, :content_type, :width, :height, :thumbnails, :created_at)", img)
+		if err != nil {
+			return fmt.Errorf("error inserting product image: %w", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("error getting last insert ID: %w", err)
+		}
+		img.ID = int(id)
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+	return img, nil
+}
+
+func (st *sqlStorage) GetProductImage(ctx context.Context, id int) (*ProductImage, error) {
+	var img ProductImage
+	err := st.DB.GetContext(ctx, &img, "SELECT * FROM product_images WHERE id=?", id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting product image: %w", err)
+	}
+	return &img, nil
+}
+
+// ReorderProductImages positions the images of a product in the order of
+// imageIDs.
+func (st *sqlStorage) ReorderProductImages(ctx context.Context, productID int, imageIDs []int) error {
+	return st.execTx(ctx, func(tx *sqlx.Tx) error {
+		for i, id := range imageIDs {
+			_, err := tx.ExecContext(ctx, "UPDATE product_images SET position=? WHERE id=? AND product_id=?", i, id, productID)
+			if err != nil {
+				return fmt.Errorf("error reordering product images: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+func (st *sqlStorage) DeleteProductImage(ctx context.Context, id int) error {
+	_, err := st.DB.ExecContext(ctx, "DELETE FROM product_images WHERE id=?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting product image: %w", err)
+	}
+	return nil
+}
+
+// reviewColumns selects a review along with the reviewer's name.
+const reviewColumns = "SELECT reviews.*, users.name AS user_name FROM reviews JOIN users ON users.id = reviews.user_id"
+
+// CreateReview adds a review and updates the product's rating. It returns
+// ErrDuplicateReview if the customer already reviewed the product.
+func (st *sqlStorage) CreateReview(ctx context.Context, r *Review) (*Review, error) {
+	err := st.execTx(ctx, func(tx *sqlx.Tx) error {
+		// Locking the product serializes the reviews of a product, which
+		// keeps the duplicate check and the rating consistent.
+		var productID int
+		err := tx.GetContext(ctx, &productID, "SELECT id FROM products WHERE id=?"+forUpdate(tx), r.ProductID)
+		if err != nil {
+			return fmt.Errorf("error getting product: %w", err)
+		}
+		var n int
+		err = tx.GetContext(ctx, &n, "SELECT COUNT(*) FROM reviews WHERE product_id=? AND user_id=?", r.ProductID, r.UserID)
+		if err != nil {
+			return fmt.Errorf("error checking review: %w", err)
+		}
+		if n > 0 {
+			return ErrDuplicateReview
+		}
+		r.CreatedAt = time.Now().UTC()
+		res, err := tx.NamedExecContext(ctx, "INSERT INTO reviews (product_id, user_id, rating, title, body, status, moderation_reason, created_at) VALUES (:product_id, :user_id, :rating, :title, :body, :status, :moderation_reason, :created_at)", r)
+		if err != nil {
+			return fmt.Errorf("error inserting review: %w", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("error getting last insert ID: %w", err)
+		}
+		r.ID = int(id)
+		return refreshProductRating(ctx, tx, r.ProductID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return st.GetReview(ctx, r.ID)
+}
+
+func (st *sqlStorage) GetReview(ctx context.Context, id int) (*Review, error) {
+	var r Review
+	err := st.DB.GetContext(ctx, &r, reviewColumns+" WHERE reviews.id=?", id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting review: %w", err)
+	}
+	return &r, nil
+}
+
+// ListProductReviews lists the approved reviews of a product.
+func (st *sqlStorage) ListProductReviews(ctx context.Context, productID int, sort ReviewSort, page Page) ([]Review, *Cursor, error) {
+	sort = sort.effective()
+	if err := checkReviewCursor(sort, page.After); err != nil {
+		return nil, nil, err
+	}
+	query := reviewColumns + " WHERE reviews.product_id=? AND reviews.status=?"
+	args := []interface{}{productID, ReviewStatusApproved}
+	cmp, dir := ">", "ASC"
+	if sort.descending() {
+		cmp, dir = "<", "DESC"
+	}
+	if page.After != nil {
+		if sort.byRating() {
+			query += fmt.Sprintf(" AND (reviews.rating %[1]s ? OR (reviews.rating = ? AND reviews.id %[1]s ?))", cmp)
+			args = append(args, page.After.Value, page.After.Value, page.After.ID)
+		} else {
+			query += " AND reviews.id " + cmp + " ?"
+			args = append(args, page.After.ID)
+		}
+	}
+	if sort.byRating() {
+		query += " ORDER BY reviews.rating " + dir + ", reviews.id " + dir
+	} else {
+		query += " ORDER BY reviews.id " + dir
+	}
+	query += " LIMIT ?"
+	args = append(args, page.Limit+1)
+
+	var reviews []Review
+	if err := st.DB.SelectContext(ctx, &reviews, query, args...); err != nil {
+		return nil, nil, fmt.Errorf("error listing reviews: %w", err)
+	}
+	n, next := nextCursor(len(reviews), page.Limit, func(i int) int { return reviews[i].ID })
+	reviews = reviews[:n]
+	if n > 0 {
+		next = reviewCursor(next, sort, &reviews[n-1])
+	}
+	return reviews, next, nil
+}
+
+func (st *sqlStorage) UpdateReview(ctx context.Context, r *Review) (*Review, error) {
+	err := st.execTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExecContext(ctx, "UPDATE reviews SET rating=:rating, title=:title, body=:body, status=:status, moderation_reason=:moderation_reason, updated_at=:updated_at WHERE id=:id", r)
+		if err != nil {
+			return fmt.Errorf("error updating review: %w", err)
+		}
+		return refreshProductRating(ctx, tx, r.ProductID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return st.GetReview(ctx, r.ID)
+}
+
+func (st *sqlStorage) DeleteReview(ctx context.Context, id int) error {
+	return st.execTx(ctx, func(tx *sqlx.Tx) error {
+		var productID int
+		err := tx.GetContext(ctx, &productID, "SELECT product_id FROM reviews WHERE id=?", id)
+		if err != nil {
+			return fmt.Errorf("error getting review: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM reviews WHERE id=?", id); err != nil {
+			return fmt.Errorf("error deleting review: %w", err)
+		}
+		return refreshProductRating(ctx, tx, productID)
+	})
+}
+
+// ListReviewsByStatus lists the reviews of all products with status, oldest
+// first, which is the order a moderation queue is worked through.
+func (st *sqlStorage) ListReviewsByStatus(ctx context.Context, status ReviewStatus, page Page) ([]Review, *Cursor, error) {
+	query := reviewColumns + " WHERE reviews.status=? ORDER BY reviews.id LIMIT ?"
+	args := []interface{}{status, page.Limit + 1}
+	if page.After != nil {
+		query = reviewColumns + " WHERE reviews.status=? AND reviews.id > ? ORDER BY reviews.id LIMIT ?"
+		args = []interface{}{status, page.After.ID, page.Limit + 1}
+	}
+	var reviews []Review
+	if err := st.DB.SelectContext(ctx, &reviews, query, args...); err != nil {
+		return nil, nil, fmt.Errorf("error listing reviews: %w", err)
+	}
+	n, next := nextCursor(len(reviews), page.Limit, func(i int) int { return reviews[i].ID })
+	return reviews[:n], next, nil
+}
+
+// ModerateReviews sets the status of several reviews at once and updates the
+// ratings of their products. Either all reviews are updated or, if one of
+// them does not exist, none.
+func (st *sqlStorage) ModerateReviews(ctx context.Context, ids []int, status ReviewStatus, reason string) ([]Review, error) {
+	now := time.Now()
+	err := st.execTx(ctx, func(tx *sqlx.Tx) error {
+		products := make(map[int]bool)
+		for _, id := range ids {
+			var productID int
+			err := tx.GetContext(ctx, &productID, "SELECT product_id FROM reviews WHERE id=?"+forUpdate(tx), id)
+			if err != nil {
+				return fmt.Errorf("error getting review %d: %w", id, err)
+			}
+			_, err = tx.ExecContext(ctx, "UPDATE reviews SET status=?, moderation_reason=?, moderated_at=? WHERE id=?", status, reason, now, id)
+			if err != nil {
+				return fmt.Errorf("error moderating review: %w", err)
+			}
+			products[productID] = true
+		}
+		for productID := range products {
+			if err := refreshProductRating(ctx, tx, productID); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	query, args, err := sqlx.In(reviewColumns+" WHERE reviews.id IN (?) ORDER BY reviews.id", ids)
+	if err != nil {
+		return nil, fmt.Errorf("error building reviews query: %w", err)
+	}
+	var reviews []Review
+	if err := st.DB.SelectContext(ctx, &reviews, st.DB.Rebind(query), args...); err != nil {
+		return nil, fmt.Errorf("error listing reviews: %w", err)
+	}
+	return reviews, nil
+}
+
+// HasPurchased reports whether the user has a paid order for the product.
+func (st *sqlStorage) HasPurchased(ctx context.Context, userID, productID int) (bool, error) {
+	query, args, err := sqlx.In("SELECT COUNT(*) FROM order_items JOIN orders ON orders.id = order_items.order_id WHERE orders.user_id=? AND order_items.product_id=? AND orders.status IN (?)", userID, productID, purchasedStatuses)
+	if err != nil {
+		return false, fmt.Errorf("error building purchase query: %w", err)
+	}
+	var n int
+	if err := st.DB.GetContext(ctx, &n, st.DB.Rebind(query), args...); err != nil {
+		return false, fmt.Errorf("error checking purchases: %w", err)
+	}
+	return n > 0, nil
+}
+
+// refreshProductRating recomputes the rating and review count of a product
+// from its approved reviews.
+func refreshProductRating(ctx context.Context, tx *sqlx.Tx, productID int) error {
+	var agg struct {
+		Sum   int `db:"sum"`
+		Count int `db:"count"`
+	}
+	err := tx.GetContext(ctx, &agg, "SELECT COALESCE(SUM(rating), 0) AS sum, COUNT(*) AS count FROM reviews WHERE product_id=? AND status=?", productID, ReviewStatusApproved)
+	if err != nil {
+		return fmt.Errorf("error aggregating reviews: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, "UPDATE products SET rating=?, num_reviews=? WHERE id=?", averageRating(agg.Sum, agg.Count), agg.Count, productID)
+	if err != nil {
+		return fmt.Errorf("error updating product rating: %w", err)
+	}
+	return nil
+}
+
+// GetUserCart returns the cart of a user with its lines, oldest first. The
+// cart is created on first use.
+func (st *sqlStorage) GetUserCart(ctx context.Context, userID int) (*Cart, error) {
+	var c Cart
+	err := st.DB.GetContext(ctx, &c, "SELECT * FROM carts WHERE user_id=?", userID)
+	if errors.Is(err, sql.ErrNoRows) {
+		// A concurrent request may create the cart first. The unique
+		// user_id then rejects this insert and the select finds that cart.
+		_, insertErr := st.DB.ExecContext(ctx, "INSERT INTO carts (user_id) VALUES (?)", userID)
+		err = st.DB.GetContext(ctx, &c, "SELECT * FROM carts WHERE user_id=?", userID)
+		if err != nil && insertErr != nil {
+			return nil, fmt.Errorf("error inserting cart: %w", insertErr)
+		}
+	}
+	if err != nil {
+		return nil, fmt.Errorf("error getting cart: %w", err)
+	}
+	if err := st.attachCartItems(ctx, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// CreateGuestCart creates an empty cart that is found by token instead of
+// by user.
+func (st *sqlStorage) CreateGuestCart(ctx context.Context, token string) (*Cart, error) {
+	now := time.Now().UTC()
+	res, err := st.DB.ExecContext(ctx, "INSERT INTO carts (token, created_at, updated_at) VALUES (?, ?, ?)", token, now, now)
+	if err != nil {
+		return nil, fmt.Errorf("error inserting cart: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	return &Cart{ID: int(id), Token: &token, CreatedAt: now, UpdatedAt: &now}, nil
+}
+
+// GetGuestCart returns the guest cart with the token with its lines, oldest
+// first.
+func (st *sqlStorage) GetGuestCart(ctx context.Context, token string) (*Cart, error) {
+	var c Cart
+	err := st.DB.GetContext(ctx, &c, "SELECT * FROM carts WHERE token=? AND user_id IS NULL", token)
+	if err != nil {
+		return nil, fmt.Errorf("error getting cart: %w", err)
+	}
+	if err := st.attachCartItems(ctx, &c); err != nil {
+		return nil, err
+	}
+	return &c, nil
+}
+
+// MergeCarts moves the lines of cart fromID into cart toID and deletes cart
+// fromID. A line that is in both carts keeps the larger of the two
+// quantities.
+func (st *sqlStorage) MergeCarts(ctx context.Context, fromID, toID int) error {
+	return st.execTx(ctx, func(tx *sqlx.Tx) error {
+		// Lock in id order so two merges of the same carts cannot deadlock.
+		query, args, err := sqlx.In("SELECT id FROM carts WHERE id IN (?) ORDER BY id"+forUpdate(tx), []int{fromID, toID})
+		if err != nil {
+			return fmt.Errorf("error building query: %w", err)
+		}
+		var ids []int
+		if err := tx.SelectContext(ctx, &ids, tx.Rebind(query), args...); err != nil {
+			return fmt.Errorf("error getting carts: %w", err)
+		}
+		if len(ids) != 2 {
+			return fmt.Errorf("error getting carts: %w", sql.ErrNoRows)
+		}
+
+		var from, to []CartItem
+		if err := tx.SelectContext(ctx, &from, "SELECT * FROM cart_items WHERE cart_id=? ORDER BY id", fromID); err != nil {
+			return fmt.Errorf("error listing cart items: %w", err)
+		}
+		if err := tx.SelectContext(ctx, &to, "SELECT * FROM cart_items WHERE cart_id=? ORDER BY id", toID); err != nil {
+			return fmt.Errorf("error listing cart items: %w", err)
+		}
+		now := time.Now()
+	lines:
+		for _, item := range from {
+			for _, existing := range to {
+				if !existing.sameLine(&item) {
+					continue
+				}
+				quantity := mergeQuantity(item.Quantity, existing.Quantity)
+				if quantity != existing.Quantity {
+					_, err := tx.ExecContext(ctx, "UPDATE cart_items SET quantity=?, updated_at=? WHERE id=?", quantity, now, existing.ID)
+					if err != nil {
+						return fmt.Errorf("error updating cart item: %w", err)
+					}
+				}
+				if _, err := tx.ExecContext(ctx, "DELETE FROM cart_items WHERE id=?", item.ID); err != nil {
+					return fmt.Errorf("error deleting cart item: %w", err)
+				}
+				continue lines
+			}
+			if _, err := tx.ExecContext(ctx, "UPDATE cart_items SET cart_id=?, updated_at=? WHERE id=?", toID, now, item.ID); err != nil {
+				return fmt.Errorf("error moving cart item: %w", err)
+			}
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM carts WHERE id=?", fromID); err != nil {
+			return fmt.Errorf("error deleting cart: %w", err)
+		}
+		return touchCart(ctx, tx, toID)
+	})
+}
+
+// DeleteExpiredGuestCarts deletes the guest carts that have not changed
+// since before, with their lines. It returns the number of carts deleted.
+func (st *sqlStorage) DeleteExpiredGuestCarts(ctx context.Context, before time.Time) (int64, error) {
+	var n int64
+	err := st.execTx(ctx, func(tx *sqlx.Tx) error {
+		before := before.UTC()
+		_, err := tx.ExecContext(ctx, "DELETE FROM cart_items WHERE cart_id IN (SELECT id FROM carts WHERE user_id IS NULL AND updated_at < ?)", before)
+		if err != nil {
+			return fmt.Errorf("error deleting cart items: %w", err)
+		}
+		res, err := tx.ExecContext(ctx, "DELETE FROM carts WHERE user_id IS NULL AND updated_at < ?", before)
+		if err != nil {
+			return fmt.Errorf("error deleting carts: %w", err)
+		}
+		n, err = res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error getting rows affected: %w", err)
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return n, nil
+}
+
+func (st *sqlStorage) attachCartItems(ctx context.Context, c *Cart) error {
+	c.Items = nil
+	err := st.DB.SelectContext(ctx, &c.Items, "SELECT * FROM cart_items WHERE cart_id=? ORDER BY id", c.ID)
+	if err != nil {
+		return fmt.Errorf("error listing cart items: %w", err)
+	}
+	return nil
+}
+
+// AddCartItem adds a line to a cart, or raises the quantity of the line for
+// the same product and variant. It returns the resulting line.
+func (st *sqlStorage) AddCartItem(ctx context.Context, item *CartItem) (*CartItem, error) {
+	var line CartItem
+	err := st.execTx(ctx, func(tx *sqlx.Tx) error {
+		// Locking the cart keeps two concurrent adds from creating two lines
+		// for the same product.
+		var cartID int
+		err := tx.GetContext(ctx, &cartID, "SELECT id FROM carts WHERE id=?"+forUpdate(tx), item.CartID)
+		if err != nil {
+			return fmt.Errorf("error getting cart: %w", err)
+		}
+		var items []CartItem
+		err = tx.SelectContext(ctx, &items, "SELECT * FROM cart_items WHERE cart_id=? AND product_id=?", item.CartID, item.ProductID)
+		if err != nil {
+			return fmt.Errorf("error listing cart items: %w", err)
+		}
+		now := time.Now()
+		for _, existing := range items {
+			if !existing.sameLine(item) {
+				continue
+			}
+			line = existing
+			line.Quantity += item.Quantity
+			line.UpdatedAt = &now
+			_, err := tx.ExecContext(ctx, "UPDATE cart_items SET quantity=?, updated_at=? WHERE id=?", line.Quantity, line.UpdatedAt, line.ID)
+			if err != nil {
+				return fmt.Errorf("error updating cart item: %w", err)
+			}
+			return touchCart(ctx, tx, item.CartID)
+		}
+
+		res, err := tx.NamedExecContext(ctx, "INSERT INTO cart_items (cart_id, product_id, variant_id, quantity) VALUES (:cart_id, :product_id, :variant_id, :quantity)", item)
+		if err != nil {
+			return fmt.Errorf("error inserting cart item: %w", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("error getting last insert ID: %w", err)
+		}
+		line = *item
+		line.ID = int(id)
+		line.CreatedAt = now
+		return touchCart(ctx, tx, item.CartID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &line, nil
+}
+
+// UpdateCartItem sets the quantity of a line. It returns an error wrapping
+// sql.ErrNoRows if the line is not in the cart.
+func (st *sqlStorage) UpdateCartItem(ctx context.Context, cartID, itemID, quantity int) error {
+	return st.execTx(ctx, func(tx *sqlx.Tx) error {
+		var id int
+		err := tx.GetContext(ctx, &id, "SELECT id FROM cart_items WHERE id=? AND cart_id=?", itemID, cartID)
+		if err != nil {
+			return fmt.Errorf("error getting cart item: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE cart_items SET quantity=?, updated_at=? WHERE id=?", quantity, time.Now(), itemID)
+		if err != nil {
+			return fmt.Errorf("error updating cart item: %w", err)
+		}
+		return touchCart(ctx, tx, cartID)
+	})
+}
+
+// DeleteCartItem removes a line. It returns an error wrapping sql.ErrNoRows
+// if the line is not in the cart.
+func (st *sqlStorage) DeleteCartItem(ctx context.Context, cartID, itemID int) error {
+	return st.execTx(ctx, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM cart_items WHERE id=? AND cart_id=?", itemID, cartID)
+		if err != nil {
+			return fmt.Errorf("error deleting cart item: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error getting rows affected: %w", err)
+		}
+		if n == 0 {
+			return fmt.Errorf("error getting cart item: %w", sql.ErrNoRows)
+		}
+		return touchCart(ctx, tx, cartID)
+	})
+}
+
+func (st *sqlStorage) ClearCart(ctx context.Context, cartID int) error {
+	return st.execTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM cart_items WHERE cart_id=?", cartID); err != nil {
+			return fmt.Errorf("error clearing cart: %w", err)
+		}
+		return touchCart(ctx, tx, cartID)
+	})
+}
+
+// touchCart records that a cart changed. The time is stored in UTC so that
+// DeleteExpiredGuestCarts can compare it on every database.
+func touchCart(ctx context.Context, tx *sqlx.Tx, cartID int) error {
+	if _, err := tx.ExecContext(ctx, "UPDATE carts SET updated_at=? WHERE id=?", time.Now().UTC(), cartID); err != nil {
+		return fmt.Errorf("error updating cart: %w", err)
+	}
+	return nil
+}
+
+func (st *sqlStorage) CreateWishlist(ctx context.Context, w *Wishlist) (*Wishlist, error) {
+	res, err := st.DB.NamedExecContext(ctx, "INSERT INTO wishlists (user_id, name) VALUES (:user_id, :name)", w)
+	if err != nil {
+		return nil, fmt.Errorf("error inserting wishlist: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	return st.GetWishlist(ctx, int(id))
+}
+
+// GetWishlist returns a wishlist with its items, oldest first.
+func (st *sqlStorage) GetWishlist(ctx context.Context, id int) (*Wishlist, error) {
+	var w Wishlist
+	err := st.DB.GetContext(ctx, &w, "SELECT * FROM wishlists WHERE id=?", id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting wishlist: %w", err)
+	}
+	wishlists := []Wishlist{w}
+	if err := st.attachWishlistItems(ctx, wishlists); err != nil {
+		return nil, err
+	}
+	return &wishlists[0], nil
+}
+
+// GetWishlistByShareToken returns the shared wishlist with the token.
+func (st *sqlStorage) GetWishlistByShareToken(ctx context.Context, token string) (*Wishlist, error) {
+	var id int
+	err := st.DB.GetContext(ctx, &id, "SELECT id FROM wishlists WHERE share_token=?", token)
+	if err != nil {
+		return nil, fmt.Errorf("error getting wishlist: %w", err)
+	}
+	return st.GetWishlist(ctx, id)
+}
+
+// ListUserWishlists returns the wishlists of a user with their items, oldest
+// first.
+func (st *sqlStorage) ListUserWishlists(ctx context.Context, userID int) ([]Wishlist, error) {
+	var wishlists []Wishlist
+	err := st.DB.SelectContext(ctx, &wishlists, "SELECT * FROM wishlists WHERE user_id=? ORDER BY id", userID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing wishlists: %w", err)
+	}
+	if err := st.attachWishlistItems(ctx, wishlists); err != nil {
+		return nil, err
+	}
+	return wishlists, nil
+}
+
+// attachWishlistItems loads the items of all wishlists with one query.
+func (st *sqlStorage) attachWishlistItems(ctx context.Context, wishlists []Wishlist) error {
+	if len(wishlists) == 0 {
+		return nil
+	}
+	ids := make([]int, len(wishlists))
+	byID := make(map[int]*Wishlist, len(wishlists))
+	for i := range wishlists {
+		ids[i] = wishlists[i].ID
+		wishlists[i].Items = nil
+		byID[wishlists[i].ID] = &wishlists[i]
+	}
+	query, args, err := sqlx.In("SELECT * FROM wishlist_items WHERE wishlist_id IN (?) ORDER BY id", ids)
+	if err != nil {
+		return fmt.Errorf("error building query: %w", err)
+	}
+	var items []WishlistItem
+	if err := st.DB.SelectContext(ctx, &items, st.DB.Rebind(query), args...); err != nil {
+		return fmt.Errorf("error listing wishlist items: %w", err)
+	}
+	for _, item := range items {
+		w := byID[item.WishlistID]
+		w.Items = append(w.Items, item)
+	}
+	return nil
+}
+
+// UpdateWishlist saves the name and share token of a wishlist.
+func (st *sqlStorage) UpdateWishlist(ctx context.Context, w *Wishlist) (*Wishlist, error) {
+	_, err := st.DB.NamedExecContext(ctx, "UPDATE wishlists SET name=:name, share_token=:share_token, updated_at=:updated_at WHERE id=:id", w)
+	if err != nil {
+		return nil, fmt.Errorf("error updating wishlist: %w", err)
+	}
+	return st.GetWishlist(ctx, w.ID)
+}
+
+func (st *sqlStorage) DeleteWishlist(ctx context.Context, id int) error {
+	return st.execTx(ctx, func(tx *sqlx.Tx) error {
+		if _, err := tx.ExecContext(ctx, "DELETE FROM wishlist_items WHERE wishlist_id=?", id); err != nil {
+			return fmt.Errorf("error deleting wishlist items: %w", err)
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM wishlists WHERE id=?", id); err != nil {
+			return fmt.Errorf("error deleting wishlist: %w", err)
+		}
+		return nil
+	})
+}
+
+// AddWishlistItem adds a product, or one of its variants, to a wishlist. If
+// the wishlist already holds it, the existing item is returned instead.
+func (st *sqlStorage) AddWishlistItem(ctx context.Context, item *WishlistItem) (*WishlistItem, error) {
+	var added WishlistItem
+	err := st.execTx(ctx, func(tx *sqlx.Tx) error {
+		// Locking the wishlist keeps two concurrent adds from adding the
+		// same product twice.
+		var wishlistID int
+		err := tx.GetContext(ctx, &wishlistID, "SELECT id FROM wishlists WHERE id=?"+forUpdate(tx), item.WishlistID)
+		if err != nil {
+			return fmt.Errorf("error getting wishlist: %w", err)
+		}
+		var items []WishlistItem
+		err = tx.SelectContext(ctx, &items, "SELECT * FROM wishlist_items WHERE wishlist_id=? AND product_id=?", item.WishlistID, item.ProductID)
+		if err != nil {
+			return fmt.Errorf("error listing wishlist items: %w", err)
+		}
+		for _, existing := range items {
+			if existing.sameLine(item) {
+				added = existing
+				return nil
+			}
+		}
+
+		now := time.Now()
+		res, err := tx.ExecContext(ctx, "INSERT INTO wishlist_items (wishlist_id, product_id, variant_id, created_at) VALUES (?, ?, ?, ?)", item.WishlistID, item.ProductID, item.VariantID, now)
+		if err != nil {
+			return fmt.Errorf("error inserting wishlist item: %w", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("error getting last insert ID: %w", err)
+		}
+		added = *item
+		added.ID = int(id)
+		added.CreatedAt = now
+		return touchWishlist(ctx, tx, item.WishlistID)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &added, nil
+}
+
+// DeleteWishlistItem removes an item from a wishlist. It returns an error
+// wrapping sql.ErrNoRows if the item is not on the wishlist.
+func (st *sqlStorage) DeleteWishlistItem(ctx context.Context, wishlistID, itemID int) error {
+	return st.execTx(ctx, func(tx *sqlx.Tx) error {
+		res, err := tx.ExecContext(ctx, "DELETE FROM wishlist_items WHERE id=? AND wishlist_id=?", itemID, wishlistID)
+		if err != nil {
+			return fmt.Errorf("error deleting wishlist item: %w", err)
+		}
+		n, err := res.RowsAffected()
+		if err != nil {
+			return fmt.Errorf("error getting rows affected: %w", err)
+		}
+		if n == 0 {
+			return fmt.Errorf("error getting wishlist item: %w", sql.ErrNoRows)
+		}
+		return touchWishlist(ctx, tx, wishlistID)
+	})
+}
+
+// ListWishlistedProducts counts how often every wishlisted product is
+// wishlisted, most wishlisted first.
+func (st *sqlStorage) ListWishlistedProducts(ctx context.Context, page Page) ([]WishlistedProduct, *Cursor, error) {
+	if page.After != nil && page.After.Sort != wishlistedSort {
+		return nil, nil, ErrInvalidCursor
+	}
+	query := "SELECT wishlist_items.product_id, products.name, COUNT(DISTINCT wishlists.id) AS wishlists, COUNT(DISTINCT wishlists.user_id) AS users" +
+		" FROM wishlist_items JOIN wishlists ON wishlists.id = wishlist_items.wishlist_id JOIN products ON products.id = wishlist_items.product_id" +
+		" GROUP BY wishlist_items.product_id, products.name"
+	var args []interface{}
+	if page.After != nil {
+		query += " HAVING COUNT(DISTINCT wishlists.id) < ? OR (COUNT(DISTINCT wishlists.id) = ? AND wishlist_items.product_id > ?)"
+		args = append(args, page.After.Value, page.After.Value, page.After.ID)
+	}
+	query += " ORDER BY wishlists DESC, wishlist_items.product_id ASC LIMIT ?"
+	args = append(args, page.Limit+1)
+
+	var products []WishlistedProduct
+	if err := st.DB.SelectContext(ctx, &products, query, args...); err != nil {
+		return nil, nil, fmt.Errorf("error listing wishlisted products: %w", err)
+	}
+	n, next := nextCursor(len(products), page.Limit, func(i int) int { return products[i].ProductID })
+	products = products[:n]
+	if n > 0 {
+		next = wishlistedCursor(next, &products[n-1])
+	}
+	return products, next, nil
+}
+
+// touchWishlist records that a wishlist changed.
+func touchWishlist(ctx context.Context, tx *sqlx.Tx, wishlistID int) error {
+	if _, err := tx.ExecContext(ctx, "UPDATE wishlists SET updated_at=? WHERE id=?", time.Now(), wishlistID); err != nil {
+		return fmt.Errorf("error updating wishlist: %w", err)
+	}
+	return nil
+}
+
+// checkSKU returns ErrDuplicateSKU if another variant already uses the SKU
+// of v.
+func checkSKU(ctx context.Context, tx *sqlx.Tx, v *ProductVariant) error {
+	var n int
+	err := tx.GetContext(ctx, &n, "SELECT COUNT(*) FROM product_variants WHERE sku=? AND id<>?", v.SKU, v.ID)
+	if err != nil {
+		return fmt.Errorf("error checking sku: %w", err)
+	}
+	if n > 0 {
+		return ErrDuplicateSKU
+	}
+	return nil
+}
+
+// syncProductStock sets the stock of a product to the sum of its variants'.
+func syncProductStock(ctx context.Context, tx *sqlx.Tx, productID int) error {
+	_, err := tx.ExecContext(ctx, "UPDATE products SET count_in_stock = (SELECT COALESCE(SUM(count_in_stock), 0) FROM product_variants WHERE product_id=?) WHERE id=?", productID, productID)
+	if err != nil {
+		return fmt.Errorf("error updating product stock: %w", err)
+	}
+	return nil
+}
+
+func (st *sqlStorage) CreateCategory(ctx context.Context, c *Category) (*Category, error) {
+	c.CreatedAt = time.Now().UTC()
+	res, err := st.DB.NamedExecContext(ctx, "INSERT INTO categories (parent_id, name, slug, sort_order, created_at) VALUES (:parent_id, :name, :slug, :sort_order, :created_at)", c)
+	if err != nil {
+		return nil, fmt.Errorf("error inserting category: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	c.ID = int(id)
+	return c, nil
+}
+
+func (st *sqlStorage) GetCategory(ctx context.Context, id int) (*Category, error) {
+	var c Category
+	err := st.DB.GetContext(ctx, &c, "SELECT * FROM categories WHERE id=?", id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting category: %w", err)
+	}
+	return &c, nil
+}
+
+func (st *sqlStorage) ListCategories(ctx context.Context) ([]Category, error) {
+	var categories []Category
+	err := st.DB.SelectContext(ctx, &categories, "SELECT * FROM categories ORDER BY sort_order, name, id")
+	if err != nil {
+		return nil, fmt.Errorf("error listing categories: %w", err)
+	}
+	return categories, nil
+}
+
+func (st *sqlStorage) UpdateCategory(ctx context.Context, c *Category) (*Category, error) {
+	_, err := st.DB.NamedExecContext(ctx, "UPDATE categories SET parent_id=:parent_id, name=:name, slug=:slug, sort_order=:sort_order, updated_at=:updated_at WHERE id=:id", c)
+	if err != nil {
+		return nil, fmt.Errorf("error updating category: %w", err)
+	}
+	return c, nil
+}
+
+// DeleteCategory refuses to delete a category that still has subcategories
+// or products, so nothing is left pointing at a missing category.
+func (st *sqlStorage) DeleteCategory(ctx context.Context, id int) error {
+	return st.execTx(ctx, func(tx *sqlx.Tx) error {
+		var inUse int
+		err := tx.GetContext(ctx, &inUse, "SELECT (SELECT COUNT(*) FROM categories WHERE parent_id=?) + (SELECT COUNT(*) FROM products WHERE category_id=?)", id, id)
+		if err != nil {
+			return fmt.Errorf("error checking category usage: %w", err)
+		}
+		if inUse > 0 {
+			return ErrCategoryInUse
+		}
+		if _, err := tx.ExecContext(ctx, "DELETE FROM categories WHERE id=?", id); err != nil {
+			return fmt.Errorf("error deleting category: %w", err)
+		}
+		return nil
+	})
+}
+
+func (st *sqlStorage) execTx(ctx context.Context, fn func(tx *sqlx.Tx) error) error {
+	tx, err := st.DB.BeginTxx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("error starting transaction: %w", err)
+	}
+
+	err = fn(tx)
+	if err != nil {
+		if rbErr := tx.Rollback(); rbErr != nil {
+			return fmt.Errorf("error rolling back transaction: %w", err)
+		}
+		return fmt.Errorf("error in transaction: %w", err)
+	}
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("error committing transaction: %w", err)
+	}
+
+	return nil
+}
+
+func createOrder(ctx context.Context, tx *sqlx.Tx, o *Order) (*Order, error) {
+	o.CreatedAt = time.Now().UTC()
+	res, err := tx.NamedExecContext(ctx, "INSERT INTO orders (status, payment_method, shipping_country, shipping_region, shipping_method, coupon_id, coupon_code, currency, items_price, promotion_price, discount_price, tax_price, shipping_price, total_price, user_id, created_at) VALUES (:status, :payment_method, :shipping_country, :shipping_region, :shipping_method, :coupon_id, :coupon_code, :currency, :items_price, :promotion_price, :discount_price, :tax_price, :shipping_price, :total_price, :user_id, :created_at)", o)
+	if err != nil {
+		return nil, fmt.Errorf("error inserting order: %w", err)
+	}
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert id order: %w", err)
+	}
+	o.ID = int(id)
+	return o, nil
+}
+
+func createOrderItem(ctx context.Context, tx *sqlx.Tx, oi *OrderItem) error {
+	res, err := tx.NamedExecContext(ctx, "INSERT INTO order_items (name, sku, quantity, image, price, tax_rate, tax_price, tax_inclusive, product_id, variant_id, order_id) VALUES (:name, :sku, :quantity, :image, :price, :tax_rate, :tax_price, :tax_inclusive, :product_id, :variant_id, :order_id)", oi)
+	if err != nil {
+		return fmt.Errorf("error inserting order item: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error getting last insert id order item: %w", err)
+	}
+	oi.ID = int(id)
+	return nil
+}
+
+// reserveStock locks the product and variant rows referenced by items,
+// checks that each of them has enough stock for the summed quantity and
+// decrements it. Items with a variant take their stock from the variant and
+// also lower the product's total. Everything that falls short is reported
+// together in an *InsufficientStockError.
+func reserveStock(ctx context.Context, tx *sqlx.Tx, items []OrderItem) error {
+	wantedProducts := make(map[int]int)
+	wantedVariants := make(map[int]int)
+	variantProduct := make(map[int]int)
+	for _, oi := range items {
+		if oi.VariantID != nil {
+			wantedVariants[*oi.VariantID] += oi.Quantity
+			variantProduct[*oi.VariantID] = oi.ProductID
+		} else {
+			wantedProducts[oi.ProductID] += oi.Quantity
+		}
+	}
+
+	// Products are always locked before variants, each in ID order, so two
+	// orders cannot wait on each other.
+	shortProducts, err := lockStock(ctx, tx, "products", wantedProducts)
+	if err != nil {
+		return err
+	}
+	shortVariants, err := lockStock(ctx, tx, "product_variants", wantedVariants)
+	if err != nil {
+		return err
+	}
+	if len(shortProducts) > 0 || len(shortVariants) > 0 {
+		return &InsufficientStockError{ProductIDs: shortProducts, VariantIDs: shortVariants}
+	}
+
+	for id, qty := range wantedProducts {
+		_, err := tx.ExecContext(ctx, "UPDATE products SET count_in_stock = count_in_stock - ? WHERE id = ?", qty, id)
+		if err != nil {
+			return fmt.Errorf("error decrementing stock: %w", err)
+		}
+	}
+	for id, qty := range wantedVariants {
+		_, err := tx.ExecContext(ctx, "UPDATE product_variants SET count_in_stock = count_in_stock - ? WHERE id = ?", qty, id)
+		if err != nil {
+			return fmt.Errorf("error decrementing variant stock: %w", err)
+		}
+		_, err = tx.ExecContext(ctx, "UPDATE products SET count_in_stock = count_in_stock - ? WHERE id = ?", qty, variantProduct[id])
+		if err != nil {
+			return fmt.Errorf("error decrementing stock: %w", err)
+		}
+	}
+	return nil
+}
+
+// lockStock locks the rows of table with the IDs in wanted and returns, in
+// ID order, those whose count_in_stock is below the wanted quantity.
+func lockStock(ctx context.Context, tx *sqlx.Tx, table string, wanted map[int]int) ([]int, error) {
+	if len(wanted) == 0 {
+		return nil, nil
+	}
+	ids := make([]int, 0, len(wanted))
+	for id := range wanted {
+		ids = append(ids, id)
+	}
+	sort.Ints(ids)
+
+	query, args, err := sqlx.In("SELECT id, count_in_stock FROM "+table+" WHERE id IN (?) ORDER BY id"+forUpdate(tx), ids)
+	if err != nil {
+		return nil, fmt.Errorf("error building stock query: %w", err)
+	}
+	var rows []struct {
+		ID           int `db:"id"`
+		CountInStock int `db:"count_in_stock"`
+	}
+	err = tx.SelectContext(ctx, &rows, tx.Rebind(query), args...)
+	if err != nil {
+		return nil, fmt.Errorf("error locking %s: %w", table, err)
+	}
+	inStock := make(map[int]int, len(rows))
+	for _, r := range rows {
+		inStock[r.ID] = r.CountInStock
+	}
+
+	var short []int
+	for _, id := range ids {
+		if inStock[id] < wanted[id] {
+			short = append(short, id)
+		}
+	}
+	return short, nil
+}
+
+// redeemCoupon counts a use of the coupon of o. The coupon row is locked, so
+// concurrent orders cannot both take its last use.
+func redeemCoupon(ctx context.Context, tx *sqlx.Tx, o *Order) error {
+	var c Coupon
+	err := tx.GetContext(ctx, &c, "SELECT * FROM coupons WHERE id=?"+forUpdate(tx), *o.CouponID)
+	if err != nil {
+		return fmt.Errorf("error getting coupon: %w", err)
+	}
+	if c.UsageLimit > 0 && c.TimesUsed >= c.UsageLimit {
+		return fmt.Errorf("%w: coupon %s", ErrCouponLimitReached, c.Code)
+	}
+	if c.PerCustomerLimit > 0 {
+		var n int
+		err := tx.GetContext(ctx, &n, "SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id=? AND user_id=?", c.ID, o.UserId)
+		if err != nil {
+			return fmt.Errorf("error counting coupon redemptions: %w", err)
+		}
+		if n >= c.PerCustomerLimit {
+			return fmt.Errorf("%w: coupon %s for this customer", ErrCouponLimitReached, c.Code)
+		}
+	}
+
+	if _, err := tx.ExecContext(ctx, "UPDATE coupons SET times_used = times_used + 1 WHERE id=?", c.ID); err != nil {
+		return fmt.Errorf("error updating coupon: %w", err)
+	}
+	_, err = tx.ExecContext(ctx, "INSERT INTO coupon_redemptions (coupon_id, user_id, order_id, discount) VALUES (?, ?, ?, ?)", c.ID, o.UserId, o.ID, o.DiscountPrice)
+	if err != nil {
+		return fmt.Errorf("error inserting coupon redemption: %w", err)
+	}
+	return nil
+}
+
+// releaseCoupon gives back the coupon use of an order, if it used one.
+func releaseCoupon(ctx context.Context, tx *sqlx.Tx, orderID int) error {
+	var couponIDs []int
+	err := tx.SelectContext(ctx, &couponIDs, "SELECT coupon_id FROM coupon_redemptions WHERE order_id=?", orderID)
+	if err != nil {
+		return fmt.Errorf("error getting coupon redemption: %w", err)
+	}
+	for _, id := range couponIDs {
+		if _, err := tx.ExecContext(ctx, "UPDATE coupons SET times_used = times_used - 1 WHERE id=?", id); err != nil {
+			return fmt.Errorf("error updating coupon: %w", err)
+		}
+	}
+	if _, err := tx.ExecContext(ctx, "DELETE FROM coupon_redemptions WHERE order_id=?", orderID); err != nil {
+		return fmt.Errorf("error deleting coupon redemption: %w", err)
+	}
+	return nil
+}
+
+// releaseStock puts the quantities of items back into stock.
+func releaseStock(ctx context.Context, tx *sqlx.Tx, items []OrderItem) error {
+	for _, oi := range items {
+		if oi.VariantID != nil {
+			_, err := tx.ExecContext(ctx, "UPDATE product_variants SET count_in_stock = count_in_stock + ? WHERE id = ?", oi.Quantity, *oi.VariantID)
+			if err != nil {
+				return fmt.Errorf("error restocking variant: %w", err)
+			}
+		}
+		_, err := tx.ExecContext(ctx, "UPDATE products SET count_in_stock = count_in_stock + ? WHERE id = ?", oi.Quantity, oi.ProductID)
+		if err != nil {
+			return fmt.Errorf("error restocking product: %w", err)
+		}
+	}
+	return nil
+}
+
+// forUpdate returns the row locking clause for the transaction's driver.
+// SQLite has no row locks; its transactions take the database write lock
+// instead (see _txlock=immediate in db.DefaultSQLiteDSN).
+func forUpdate(tx *sqlx.Tx) string {
+	if tx.DriverName() == "mysql" {
+		return " FOR UPDATE"
+	}
+	return ""
+}
+
+func (st *sqlStorage) CreateOrder(ctx context.Context, o *Order) (*Order, error) {
+	err := st.execTx(ctx, func(tx *sqlx.Tx) error {
+		/*
+		   result, err := tx.NamedExecContext(ctx, `
+		       INSERT INTO orders (payment_method, tax_price, shipping_price, total_price, user_id, created_at, updated_at)
+		       VALUES (:payment_method, :tax_price, :shipping_price, :total_price, :user_id, :created_at, :updated_at)
+		   `, o)
+		   if err != nil {
+		       return fmt.Errorf("error inserting order: %w", err)
+		   }
+
+		   id, err := result.LastInsertId()
+		   if err != nil {
+		       return fmt.Errorf("error getting last insert ID for order: %w", err)
+		   }
+		   o.ID = int(id)
+
+		   // Insert order items
+		   for i := range o.Items {
+		       o.Items[i].OrderID = o.ID
+		       _, err = tx.NamedExecContext(ctx, `
+		           INSERT INTO order_items (name, quantity, image, price, product_id, order_id)
+		           VALUES (:name, :quantity, :image, :price, :product_id, :order_id)
+		       `, o.Items[i])
+		       if err != nil {
+		           return fmt.Errorf("error inserting order item: %w", err)
+		       }
+		   }
+		*/
+		err := reserveStock(ctx, tx, o.Items)
+		if err != nil {
+			return err
+		}
+
+		o.Status = OrderStatusPending
+		order, err := createOrder(ctx, tx, o)
+		if err != nil {
+			return fmt.Errorf("error creating order: %w", err)
+		}
+		err = createOrderStatusChange(ctx, tx, &OrderStatusChange{
+			OrderID:   order.ID,
+			ToStatus:  OrderStatusPending,
+			ChangedBy: order.UserId,
+			Reason:    "order created",
+		})
+		if err != nil {
+			return err
+		}
+		if order.CouponID != nil {
+			if err := redeemCoupon(ctx, tx, order); err != nil {
+				return err
+			}
+		}
+
+		for i := range o.Items {
+			o.Items[i].OrderID = order.ID
+			// insert into order_items
+			err = createOrderItem(ctx, tx, &o.Items[i])
+			if err != nil {
+				return fmt.Errorf("error creating order item: %w", err)
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error creating order: %w", err)
+	}
+	return o, nil
+}
+
+func (st *sqlStorage) GetOrder(ctx context.Context, id int) (*Order, error) {
+	var o Order
+	err := st.DB.GetContext(ctx, &o, "SELECT * FROM orders WHERE id=?", id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting order: %w", err)
+	}
+	var oi []OrderItem
+	err = st.DB.SelectContext(ctx, &oi, "SELECT * FROM order_items WHERE order_id=?", o.ID)
+	if err != nil {
+		return nil, fmt.Errorf("error getting order item: %w", err)
+	}
+	o.Items = oi
+	return &o, nil
+}
+
+func (st *sqlStorage) ListUserOrders(ctx context.Context, userId int, page Page) ([]Order, *Cursor, error) {
+	query := "SELECT * FROM orders WHERE user_id=? ORDER BY id DESC LIMIT ?"
+	args := []interface{}{userId, page.Limit + 1}
+	if page.After != nil {
+		query = "SELECT * FROM orders WHERE user_id=? AND id < ? ORDER BY id DESC LIMIT ?"
+		args = []interface{}{userId, page.After.ID, page.Limit + 1}
+	}
+	return st.listOrders(ctx, page, query, args...)
+}
+
+func (st *sqlStorage) ListOrders(ctx context.Context, page Page) ([]Order, *Cursor, error) {
+	query := "SELECT * FROM orders ORDER BY id DESC LIMIT ?"
+	args := []interface{}{page.Limit + 1}
+	if page.After != nil {
+		query = "SELECT * FROM orders WHERE id < ? ORDER BY id DESC LIMIT ?"
+		args = []interface{}{page.After.ID, page.Limit + 1}
+	}
+	return st.listOrders(ctx, page, query, args...)
+}
+
+// listOrders runs a query for page.Limit+1 orders, newest first, and attaches
+// the items of the returned page.
+func (st *sqlStorage) listOrders(ctx context.Context, page Page, query string, args ...interface{}) ([]Order, *Cursor, error) {
+	var orders []Order
+	err := st.DB.SelectContext(ctx, &orders, query, args...)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing order: %w", err)
+	}
+	n, next := nextCursor(len(orders), page.Limit, func(i int) int { return orders[i].ID })
+	orders = orders[:n]
+	if err := st.attachOrderItems(ctx, orders); err != nil {
+		return nil, nil, err
+	}
+	return orders, next, nil
+}
+
+// attachOrderItems loads the items of all orders with one query instead of
+// one query per order.
+func (st *sqlStorage) attachOrderItems(ctx context.Context, orders []Order) error {
+	if len(orders) == 0 {
+		return nil
+	}
+	ids := make([]int, len(orders))
+	for i, o := range orders {
+		ids[i] = o.ID
+	}
+	query, args, err := sqlx.In("SELECT * FROM order_items WHERE order_id IN (?) ORDER BY order_id, id", ids)
+	if err != nil {
+		return fmt.Errorf("error building order items query: %w", err)
+	}
+	var items []OrderItem
+	err = st.DB.SelectContext(ctx, &items, st.DB.Rebind(query), args...)
+	if err != nil {
+		return fmt.Errorf("error listing order items: %w", err)
+	}
+
+	byOrder := make(map[int][]OrderItem, len(orders))
+	for _, oi := range items {
+		byOrder[oi.OrderID] = append(byOrder[oi.OrderID], oi)
+	}
+	for i := range orders {
+		orders[i].Items = byOrder[orders[i].ID]
+	}
+	return nil
+}
+
+func createOrderStatusChange(ctx context.Context, tx *sqlx.Tx, c *OrderStatusChange) error {
+	c.CreatedAt = time.Now().UTC()
+	res, err := tx.NamedExecContext(ctx, "INSERT INTO order_status_history (order_id, from_status, to_status, changed_by, reason, created_at) VALUES (:order_id, :from_status, :to_status, :changed_by, :reason, :created_at)", c)
+	if err != nil {
+		return fmt.Errorf("error inserting order status change: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return fmt.Errorf("error getting last insert id order status change: %w", err)
+	}
+	c.ID = int(id)
+	return nil
+}
+
+// setOrderStatus moves a locked order to status and records the change. It
+// returns an *InvalidStatusTransitionError if the order's current status does
+// not allow it.
+func setOrderStatus(ctx context.Context, tx *sqlx.Tx, o *Order, status OrderStatus, changedBy int, reason string) error {
+	if !o.Status.CanTransitionTo(status) {
+		return &InvalidStatusTransitionError{From: o.Status, To: status}
+	}
+
+	now := time.Now()
+	_, err := tx.ExecContext(ctx, "UPDATE orders SET status=?, updated_at=? WHERE id=?", status, now, o.ID)
+	if err != nil {
+		return fmt.Errorf("error updating order status: %w", err)
+	}
+	err = createOrderStatusChange(ctx, tx, &OrderStatusChange{
+		OrderID:    o.ID,
+		FromStatus: o.Status,
+		ToStatus:   status,
+		ChangedBy:  changedBy,
+		Reason:     reason,
+	})
+	if err != nil {
+		return err
+	}
+
+	o.Status = status
+	o.UpdatedAt = &now
+	return nil
+}
+
+// UpdateOrderStatus moves an order to status and records the change.
+// Cancelling an order puts the stock of its items back; the order itself is
+// kept.
+func (st *sqlStorage) UpdateOrderStatus(ctx context.Context, id int, status OrderStatus, changedBy int, reason string) (*Order, error) {
+	return st.updateOrderStatus(ctx, id, nil, status, changedBy, reason)
+}
+
+// CancelOrder cancels an order whose status is one of from. Any other status
+// fails with an *InvalidStatusTransitionError.
+func (st *sqlStorage) CancelOrder(ctx context.Context, id int, from []OrderStatus, changedBy int, reason string) (*Order, error) {
+	return st.updateOrderStatus(ctx, id, from, OrderStatusCancelled, changedBy, reason)
+}
+
+// updateOrderStatus moves an order to status if allowed is nil or holds the
+// order's current status.
+func (st *sqlStorage) updateOrderStatus(ctx context.Context, id int, allowed []OrderStatus, status OrderStatus, changedBy int, reason string) (*Order, error) {
+	var o Order
+	err := st.execTx(ctx, func(tx *sqlx.Tx) error {
+		err := tx.GetContext(ctx, &o, "SELECT * FROM orders WHERE id=?"+forUpdate(tx), id)
+		if err != nil {
+			return fmt.Errorf("error getting order: %w", err)
+		}
+		from := o.Status
+		if allowed != nil && !slices.Contains(allowed, from) {
+			return &InvalidStatusTransitionError{From: from, To: status}
+		}
+		err = setOrderStatus(ctx, tx, &o, status, changedBy, reason)
+		if err != nil {
+			return err
+		}
+
+		err = tx.SelectContext(ctx, &o.Items, "SELECT * FROM order_items WHERE order_id=?", o.ID)
+		if err != nil {
+			return fmt.Errorf("error getting order item: %w", err)
+		}
+		if from.releasesReservation(status) {
+			if err := releaseCoupon(ctx, tx, o.ID); err != nil {
+				return err
+			}
+			return releaseStock(ctx, tx, o.Items)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("error updating order status: %w", err)
+	}
+	return &o, nil
+}
+
+func (st *sqlStorage) ListOrderStatusHistory(ctx context.Context, orderID int) ([]OrderStatusChange, error) {
+	var history []OrderStatusChange
+	err := st.DB.SelectContext(ctx, &history, "SELECT * FROM order_status_history WHERE order_id=? ORDER BY id", orderID)
+	if err != nil {
+		return nil, fmt.Errorf("error listing order status history: %w", err)
+	}
+	return history, nil
+}
+
+func (st *sqlStorage) CreateUser(ctx context.Context, u *User) (*User, error) {
+	u.CreatedAt = time.Now().UTC()
+	res, err := st.DB.NamedExecContext(ctx, "INSERT INTO users (name, email, password, is_admin, created_at) VALUES (:name, :email, :password, :is_admin, :created_at)", u)
+	if err != nil {
+		return nil, fmt.Errorf("error inserting user: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	u.ID = int(id)
+
+	return u, nil
+}
+
+func (st *sqlStorage) GetUser(ctx context.Context, email string) (*User, error) {
+	var u User
+	err := st.DB.GetContext(ctx, &u, "SELECT * FROM users WHERE email=?", email)
+	if err != nil {
+		return nil, fmt.Errorf("error getting user: %w", err)
+	}
+
+	return &u, nil
+}
+
+func (st *sqlStorage) ListUsers(ctx context.Context, page Page) ([]User, *Cursor, error) {
+	after := 0
+	if page.After != nil {
+		after = page.After.ID
+	}
+	var users []User
+	err := st.DB.SelectContext(ctx, &users, "SELECT * FROM users WHERE id > ? ORDER BY id LIMIT ?", after, page.Limit+1)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing users: %w", err)
+	}
+
+	n, next := nextCursor(len(users), page.Limit, func(i int) int { return users[i].ID })
+	return users[:n], next, nil
+}
+
+func (st *sqlStorage) UpdateUser(ctx context.Context, u *User) (*User, error) {
+	_, err := st.DB.NamedExecContext(ctx, "UPDATE users SET name=:name, email=:email, password=:password, is_admin=:is_admin, updated_at=:updated_at WHERE id=:id", u)
+	if err != nil {
+		return nil, fmt.Errorf("error updating user: %w", err)
+	}
+
+	return u, nil
+}
+
+func (st *sqlStorage) DeleteUser(ctx context.Context, id int) error {
+	_, err := st.DB.ExecContext(ctx, "DELETE FROM users WHERE id=?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting user: %w", err)
+	}
+
+	return nil
+}
+
+func (st *sqlStorage) CreateSession(ctx context.Context, s *Session) (*Session, error) {
+	s.CreatedAt = time.Now().UTC()
+	_, err := st.DB.NamedExecContext(ctx, "INSERT INTO sessions (id, user_email, refresh_token, is_revoked, expires_at, created_at) VALUES (:id, :user_email, :refresh_token, :is_revoked, :expires_at, :created_at)", s)
+	if err != nil {
+		return nil, fmt.Errorf("error inserting session: %w", err)
+	}
+
+	return s, nil
+}
+
+func (st *sqlStorage) GetSession(ctx context.Context, id string) (*Session, error) {
+	var s Session
+	err := st.DB.GetContext(ctx, &s, "SELECT * FROM sessions WHERE id=?", id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting session: %w", err)
+	}
+
+	return &s, nil
+}
+
+func (st *sqlStorage) RevokeSession(ctx context.Context, id string) error {
+	// menggunakan map sebagai inline parameternya, karena namedExecContext
+	// mengharapkan map/struct sebagai parameternya. jika ingin langsung menggunakan
+	// id sebagai inline condition, dapat menggunakan execContext
+	_, err := st.DB.NamedExecContext(ctx, "UPDATE sessions SET is_revoked=1 WHERE id=:id", map[string]interface{}{"id": id})
+	if err != nil {
+		return fmt.Errorf("error revoking session: %w", err)
+	}
+
+	return nil
+}
+
+func (st *sqlStorage) DeleteSession(ctx context.Context, id string) error {
+	_, err := st.DB.ExecContext(ctx, "DELETE FROM sessions WHERE id=?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting session: %w", err)
+	}
+
+	return nil
+}
+
+func (st *sqlStorage) CreateTaxRate(ctx context.Context, tr *TaxRate) (*TaxRate, error) {
+	tr.CreatedAt = time.Now().UTC()
+	res, err := st.DB.NamedExecContext(ctx, "INSERT INTO tax_rates (name, country, region, category, rate, inclusive, created_at) VALUES (:name, :country, :region, :category, :rate, :inclusive, :created_at)", tr)
+	if err != nil {
+		return nil, fmt.Errorf("error inserting tax rate: %w", err)
+	}
+
+	id, err := res.LastInsertId()
+	if err != nil {
+		return nil, fmt.Errorf("error getting last insert ID: %w", err)
+	}
+	tr.ID = int(id)
+	return tr, nil
+}
+
+func (st *sqlStorage) GetTaxRate(ctx context.Context, id int) (*TaxRate, error) {
+	var tr TaxRate
+	err := st.DB.GetContext(ctx, &tr, "SELECT * FROM tax_rates WHERE id=?", id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting tax rate: %w", err)
+	}
+	return &tr, nil
+}
+
+func (st *sqlStorage) ListTaxRates(ctx context.Context) ([]TaxRate, error) {
+	var rates []TaxRate
+	err := st.DB.SelectContext(ctx, &rates, "SELECT * FROM tax_rates ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("error listing tax rates: %w", err)
+	}
+	return rates, nil
+}
+
+func (st *sqlStorage) UpdateTaxRate(ctx context.Context, tr *TaxRate) (*TaxRate, error) {
+	_, err := st.DB.NamedExecContext(ctx, "UPDATE tax_rates SET name=:name, country=:country, region=:region, category=:category, rate=:rate, inclusive=:inclusive, updated_at=:updated_at WHERE id=:id", tr)
+	if err != nil {
+		return nil, fmt.Errorf("error updating tax rate: %w", err)
+	}
+	return tr, nil
+}
+
+func (st *sqlStorage) DeleteTaxRate(ctx context.Context, id int) error {
+	_, err := st.DB.ExecContext(ctx, "DELETE FROM tax_rates WHERE id=?", id)
+	if err != nil {
+		return fmt.Errorf("error deleting tax rate: %w", err)
+	}
+	return nil
+}
+
+func (st *sqlStorage) CreateCoupon(ctx context.Context, c *Coupon) (*Coupon, error) {
+	err := st.execTx(ctx, func(tx *sqlx.Tx) error {
+		if err := checkCouponCode(ctx, tx, c); err != nil {
+			return err
+		}
+		c.CreatedAt = time.Now().UTC()
+		res, err := tx.NamedExecContext(ctx, "INSERT INTO coupons (code, type, percent, amount, min_order_value, starts_at, ends_at, usage_limit, per_customer_limit, created_at) VALUES (:code, :type, :percent, :amount, :min_order_value, :starts_at, :ends_at, :usage_limit, :per_customer_limit, :created_at)", c)
+		if err != nil {
+			return fmt.Errorf("error inserting coupon: %w", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("error getting last insert ID: %w", err)
+		}
+		c.ID = int(id)
+		return setCouponRestrictions(ctx, tx, c)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return st.GetCoupon(ctx, c.ID)
+}
+
+func (st *sqlStorage) GetCoupon(ctx context.Context, id int) (*Coupon, error) {
+	var c Coupon
+	err := st.DB.GetContext(ctx, &c, "SELECT * FROM coupons WHERE id=?", id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting coupon: %w", err)
+	}
+	coupons := []Coupon{c}
+	if err := st.attachCouponRestrictions(ctx, coupons); err != nil {
+		return nil, err
+	}
+	return &coupons[0], nil
+}
+
+func (st *sqlStorage) GetCouponByCode(ctx context.Context, code string) (*Coupon, error) {
+	var id int
+	err := st.DB.GetContext(ctx, &id, "SELECT id FROM coupons WHERE code=?", code)
+	if err != nil {
+		return nil, fmt.Errorf("error getting coupon: %w", err)
+	}
+	return st.GetCoupon(ctx, id)
+}
+
+func (st *sqlStorage) ListCoupons(ctx context.Context) ([]Coupon, error) {
+	var coupons []Coupon
+	err := st.DB.SelectContext(ctx, &coupons, "SELECT * FROM coupons ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("error listing coupons: %w", err)
+	}
+	if err := st.attachCouponRestrictions(ctx, coupons); err != nil {
+		return nil, err
+	}
+	return coupons, nil
+}
+
+// UpdateCoupon saves c and replaces its restrictions. TimesUsed is kept as
+// it is in the database.
+func (st *sqlStorage) UpdateCoupon(ctx context.Context, c *Coupon) (*Coupon, error) {
+	err := st.execTx(ctx, func(tx *sqlx.Tx) error {
+		if err := checkCouponCode(ctx, tx, c); err != nil {
+			return err
+		}
+		_, err := tx.NamedExecContext(ctx, "UPDATE coupons SET code=:code, type=:type, percent=:percent, amount=:amount, min_order_value=:min_order_value, starts_at=:starts_at, ends_at=:ends_at, usage_limit=:usage_limit, per_customer_limit=:per_customer_limit, updated_at=:updated_at WHERE id=:id", c)
+		if err != nil {
+			return fmt.Errorf("error updating coupon: %w", err)
+		}
+		return setCouponRestrictions(ctx, tx, c)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return st.GetCoupon(ctx, c.ID)
+}
+
+// DeleteCoupon deletes a coupon that has never been redeemed. It returns
+// ErrCouponRedeemed otherwise.
+func (st *sqlStorage) DeleteCoupon(ctx context.Context, id int) error {
+	return st.execTx(ctx, func(tx *sqlx.Tx) error {
+		var n int
+		if err := tx.GetContext(ctx, &n, "SELECT COUNT(*) FROM coupon_redemptions WHERE coupon_id=?", id); err != nil {
+			return fmt.Errorf("error counting coupon redemptions: %w", err)
+		}
+		if n > 0 {
+			return ErrCouponRedeemed
+		}
+		for _, query := range []string{
+			"DELETE FROM coupon_categories WHERE coupon_id=?",
+			"DELETE FROM coupon_products WHERE coupon_id=?",
+			"DELETE FROM coupons WHERE id=?",
+		} {
+			if _, err := tx.ExecContext(ctx, query, id); err != nil {
+				return fmt.Errorf("error deleting coupon: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// attachCouponRestrictions loads the categories and products of all coupons
+// with one query each.
+func (st *sqlStorage) attachCouponRestrictions(ctx context.Context, coupons []Coupon) error {
+	ids := make([]int, len(coupons))
+	byID := make(map[int]*Coupon, len(coupons))
+	for i := range coupons {
+		ids[i] = coupons[i].ID
+		coupons[i].CategoryIDs, coupons[i].ProductIDs = nil, nil
+		byID[coupons[i].ID] = &coupons[i]
+	}
+	err := st.loadRestrictions(ctx, "coupon_categories", "coupon_id", "category_id", ids, func(couponID, id int) {
+		byID[couponID].CategoryIDs = append(byID[couponID].CategoryIDs, id)
+	})
+	if err != nil {
+		return err
+	}
+	return st.loadRestrictions(ctx, "coupon_products", "coupon_id", "product_id", ids, func(couponID, id int) {
+		byID[couponID].ProductIDs = append(byID[couponID].ProductIDs, id)
+	})
+}
+
+// setCouponRestrictions replaces the categories and products of c.
+func setCouponRestrictions(ctx context.Context, tx *sqlx.Tx, c *Coupon) error {
+	if err := saveRestrictions(ctx, tx, "coupon_categories", "coupon_id", "category_id", c.ID, c.CategoryIDs); err != nil {
+		return err
+	}
+	return saveRestrictions(ctx, tx, "coupon_products", "coupon_id", "product_id", c.ID, c.ProductIDs)
+}
+
+// loadRestrictions reads the targets of the owners with ownerIDs from
+// table, which pairs ownerColumn with targetColumn, and hands each pair to
+// add in target order.
+func (st *sqlStorage) loadRestrictions(ctx context.Context, table, ownerColumn, targetColumn string, ownerIDs []int, add func(ownerID, targetID int)) error {
+	if len(ownerIDs) == 0 {
+		return nil
+	}
+	query, args, err := sqlx.In("SELECT "+ownerColumn+" AS owner_id, "+targetColumn+" AS target_id FROM "+table+" WHERE "+ownerColumn+" IN (?) ORDER BY "+targetColumn, ownerIDs)
+	if err != nil {
+		return fmt.Errorf("error building query: %w", err)
+	}
+	var rows []struct {
+		OwnerID  int `db:"owner_id"`
+		TargetID int `db:"target_id"`
+	}
+	if err := st.DB.SelectContext(ctx, &rows, st.DB.Rebind(query), args...); err != nil {
+		return fmt.Errorf("error listing %s: %w", table, err)
+	}
+	for _, r := range rows {
+		add(r.OwnerID, r.TargetID)
+	}
+	return nil
+}
+
+// saveRestrictions replaces the targets of one owner in table.
+func saveRestrictions(ctx context.Context, tx *sqlx.Tx, table, ownerColumn, targetColumn string, ownerID int, ids []int) error {
+	if _, err := tx.ExecContext(ctx, "DELETE FROM "+table+" WHERE "+ownerColumn+"=?", ownerID); err != nil {
+		return fmt.Errorf("error deleting %s: %w", table, err)
+	}
+	for _, id := range ids {
+		_, err := tx.ExecContext(ctx, "INSERT INTO "+table+" ("+ownerColumn+", "+targetColumn+") VALUES (?, ?)", ownerID, id)
+		if err != nil {
+			return fmt.Errorf("error inserting into %s: %w", table, err)
+		}
+	}
+	return nil
+}
+
+// checkCouponCode returns ErrDuplicateCouponCode if another coupon already
+// uses the code of c.
+func checkCouponCode(ctx context.Context, tx *sqlx.Tx, c *Coupon) error {
+	var n int
+	err := tx.GetContext(ctx, &n, "SELECT COUNT(*) FROM coupons WHERE code=? AND id<>?", c.Code, c.ID)
+	if err != nil {
+		return fmt.Errorf("error checking coupon code: %w", err)
+	}
+	if n > 0 {
+		return ErrDuplicateCouponCode
+	}
+	return nil
+}
+
+func (st *sqlStorage) CreatePromotion(ctx context.Context, p *Promotion) (*Promotion, error) {
+	err := st.execTx(ctx, func(tx *sqlx.Tx) error {
+		p.CreatedAt = time.Now().UTC()
+		res, err := tx.NamedExecContext(ctx, "INSERT INTO promotions (name, type, percent, buy_quantity, get_quantity, starts_at, ends_at, created_at) VALUES (:name, :type, :percent, :buy_quantity, :get_quantity, :starts_at, :ends_at, :created_at)", p)
+		if err != nil {
+			return fmt.Errorf("error inserting promotion: %w", err)
+		}
+		id, err := res.LastInsertId()
+		if err != nil {
+			return fmt.Errorf("error getting last insert ID: %w", err)
+		}
+		p.ID = int(id)
+		return setPromotionRestrictions(ctx, tx, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return st.GetPromotion(ctx, p.ID)
+}
+
+func (st *sqlStorage) GetPromotion(ctx context.Context, id int) (*Promotion, error) {
+	var p Promotion
+	err := st.DB.GetContext(ctx, &p, "SELECT * FROM promotions WHERE id=?", id)
+	if err != nil {
+		return nil, fmt.Errorf("error getting promotion: %w", err)
+	}
+	promotions := []Promotion{p}
+	if err := st.attachPromotionRestrictions(ctx, promotions); err != nil {
+		return nil, err
+	}
+	return &promotions[0], nil
+}
+
+func (st *sqlStorage) ListPromotions(ctx context.Context) ([]Promotion, error) {
+	var promotions []Promotion
+	err := st.DB.SelectContext(ctx, &promotions, "SELECT * FROM promotions ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("error listing promotions: %w", err)
+	}
+	if err := st.attachPromotionRestrictions(ctx, promotions); err != nil {
+		return nil, err
+	}
+	return promotions, nil
+}
+
+// UpdatePromotion saves p and replaces its restrictions.
+func (st *sqlStorage) UpdatePromotion(ctx context.Context, p *Promotion) (*Promotion, error) {
+	err := st.execTx(ctx, func(tx *sqlx.Tx) error {
+		_, err := tx.NamedExecContext(ctx, "UPDATE promotions SET name=:name, type=:type, percent=:percent, buy_quantity=:buy_quantity, get_quantity=:get_quantity, starts_at=:starts_at, ends_at=:ends_at, updated_at=:updated_at WHERE id=:id", p)
+		if err != nil {
+			return fmt.Errorf("error updating promotion: %w", err)
+		}
+		return setPromotionRestrictions(ctx, tx, p)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return st.GetPromotion(ctx, p.ID)
+}
+
+func (st *sqlStorage) DeletePromotion(ctx context.Context, id int) error {
+	return st.execTx(ctx, func(tx *sqlx.Tx) error {
+		for _, query := range []string{
+			"DELETE FROM promotion_categories WHERE promotion_id=?",
+			"DELETE FROM promotion_products WHERE promotion_id=?",
+			"DELETE FROM promotions WHERE id=?",
+		} {
+			if _, err := tx.ExecContext(ctx, query, id); err != nil {
+				return fmt.Errorf("error deleting promotion: %w", err)
+			}
+		}
+		return nil
+	})
+}
+
+// attachPromotionRestrictions loads the categories and products of all
+// promotions with one query each.
+func (st *sqlStorage) attachPromotionRestrictions(ctx context.Context, promotions []Promotion) error {
+	ids := make([]int, len(promotions))
+	byID := make(map[int]*Promotion, len(promotions))
+	for i := range promotions {
+		ids[i] = promotions[i].ID
+		promotions[i].CategoryIDs, promotions[i].ProductIDs = nil, nil
+		byID[promotions[i].ID] = &promotions[i]
+	}
+	err := st.loadRestrictions(ctx, "promotion_categories", "promotion_id", "category_id", ids, func(promotionID, id int) {
+		byID[promotionID].CategoryIDs = append(byID[promotionID].CategoryIDs, id)
+	})
+	if err != nil {
+		return err
+	}
+	return st.loadRestrictions(ctx, "promotion_products", "promotion_id", "product_id", ids, func(promotionID, id int) {
+		byID[promotionID].ProductIDs = append(byID[promotionID].ProductIDs, id)
+	})
+}
+
+// setPromotionRestrictions replaces the categories and products of p.
+func setPromotionRestrictions(ctx context.Context, tx *sqlx.Tx, p *Promotion) error {
+	if err := saveRestrictions(ctx, tx, "promotion_categories", "promotion_id", "category_id", p.ID, p.CategoryIDs); err != nil {
+		return err
+	}
+	return saveRestrictions(ctx, tx, "promotion_products", "promotion_id", "product_id", p.ID, p.ProductIDs)
+}
@@ -0,0 +1,155 @@
+package storer
+
+import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"ecom_apiv1/db"
+	"fmt"
+	"sync/atomic"
+	"testing"
+
+	"github.com/jmoiron/sqlx"
+	_ "modernc.org/sqlite"
+)
+
+const itemsPerOrder = 3
+
+// BenchmarkListOrders compares loading the items of a page of orders with
+// one query per order against the batched IN query ListOrders runs.
+func BenchmarkListOrders(b *testing.B) {
+	for _, n := range []int{10, 100, 1000} {
+		st, queries := newBenchStorage(b)
+		seedOrders(b, st, n)
+
+		b.Run(fmt.Sprintf("orders=%d/per_order", n), func(b *testing.B) {
+			benchmarkListOrders(b, queries, n, st.listOrdersPerOrder)
+		})
+		b.Run(fmt.Sprintf("orders=%d/batched", n), func(b *testing.B) {
+			benchmarkListOrders(b, queries, n, st.ListOrders)
+		})
+	}
+}
+
+func benchmarkListOrders(b *testing.B, queries *atomic.Int64, n int, list func(context.Context, Page) ([]Order, *Cursor, error)) {
+	ctx := context.Background()
+	queries.Store(0)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		orders, _, err := list(ctx, Page{Limit: n})
+		if err != nil {
+			b.Fatal(err)
+		}
+		if len(orders) != n || len(orders[0].Items) != itemsPerOrder {
+			b.Fatalf("got %d orders with %d items, want %d with %d", len(orders), len(orders[0].Items), n, itemsPerOrder)
+		}
+	}
+	b.ReportMetric(float64(queries.Load())/float64(b.N), "queries/op")
+}
+
+// listOrdersPerOrder is how ListOrders used to load items: one query for the
+// orders and another for the items of each of them.
+func (st *sqlStorage) listOrdersPerOrder(ctx context.Context, page Page) ([]Order, *Cursor, error) {
+	var orders []Order
+	err := st.DB.SelectContext(ctx, &orders, "SELECT * FROM orders ORDER BY id DESC LIMIT ?", page.Limit)
+	if err != nil {
+		return nil, nil, fmt.Errorf("error listing order: %w", err)
+	}
+	for i := range orders {
+		err := st.DB.SelectContext(ctx, &orders[i].Items, "SELECT * FROM order_items WHERE order_id=?", orders[i].ID)
+		if err != nil {
+			return nil, nil, fmt.Errorf("error getting order item: %w", err)
+		}
+	}
+	return orders, nil, nil
+}
+
+// newBenchStorage returns a migrated in-memory SQLite store and a counter of
+// the queries it runs.
+func newBenchStorage(b *testing.B) (*SQLiteStorage, *atomic.Int64) {
+	b.Helper()
+	sqlite, err := sql.Open("sqlite", "")
+	if err != nil {
+		b.Fatal(err)
+	}
+	c := &countingConnector{driver: sqlite.Driver(), dsn: "file::memory:"}
+	sqlite.Close()
+
+	// Every connection to file::memory: opens a database of its own, so the
+	// pool is kept to the one that was migrated.
+	x := sqlx.NewDb(sql.OpenDB(c), "sqlite")
+	x.SetMaxOpenConns(1)
+	b.Cleanup(func() { x.Close() })
+
+	m, err := db.NewMigrator(x)
+	if err != nil {
+		b.Fatal(err)
+	}
+	if err := m.Up(context.Background()); err != nil {
+		b.Fatal(err)
+	}
+	return NewSQLiteStorage(x), &c.queries
+}
+
+func seedOrders(b *testing.B, st *SQLiteStorage, n int) {
+	b.Helper()
+	ctx := context.Background()
+	u, err := st.CreateUser(ctx, &User{Name: "bench", Email: "bench@example.com", Password: "x"})
+	if err != nil {
+		b.Fatal(err)
+	}
+	p, err := st.CreateProduct(ctx, &Product{Name: "bench", Price: 1000, CountInStock: n * itemsPerOrder})
+	if err != nil {
+		b.Fatal(err)
+	}
+	for range n {
+		o := &Order{UserId: u.ID, PaymentMethod: "card"}
+		for range itemsPerOrder {
+			o.Items = append(o.Items, OrderItem{Name: p.Name, Quantity: 1, Price: p.Price, ProductID: p.ID})
+		}
+		if _, err := st.CreateOrder(ctx, o); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+// countingConnector opens connections that count the queries run on them.
+type countingConnector struct {
+	driver  driver.Driver
+	dsn     string
+	queries atomic.Int64
+}
+
+func (c *countingConnector) Connect(ctx context.Context) (driver.Conn, error) {
+	conn, err := c.driver.Open(c.dsn)
+	if err != nil {
+		return nil, err
+	}
+	return &countingConn{Conn: conn, queries: &c.queries}, nil
+}
+
+func (c *countingConnector) Driver() driver.Driver {
+	return c.driver
+}
+
+type countingConn struct {
+	driver.Conn
+	queries *atomic.Int64
+}
+
+func (c *countingConn) QueryContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Rows, error) {
+	c.queries.Add(1)
+	return c.Conn.(driver.QueryerContext).QueryContext(ctx, query, args)
+}
+
+func (c *countingConn) ExecContext(ctx context.Context, query string, args []driver.NamedValue) (driver.Result, error) {
+	return c.Conn.(driver.ExecerContext).ExecContext(ctx, query, args)
+}
+
+func (c *countingConn) PrepareContext(ctx context.Context, query string) (driver.Stmt, error) {
+	return c.Conn.(driver.ConnPrepareContext).PrepareContext(ctx, query)
+}
+
+func (c *countingConn) BeginTx(ctx context.Context, opts driver.TxOptions) (driver.Tx, error) {
+	return c.Conn.(driver.ConnBeginTx).BeginTx(ctx, opts)
+}
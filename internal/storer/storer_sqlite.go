@@ -0,0 +1,13 @@
+package storer
+
+import "github.com/jmoiron/sqlx"
+
+type SQLiteStorage struct {
+	sqlStorage
+}
+
+func NewSQLiteStorage(db *sqlx.DB) *SQLiteStorage {
+	return &SQLiteStorage{
+		sqlStorage: sqlStorage{DB: db},
+	}
+}
@@ -0,0 +1,216 @@
+package storer
+
+import (
+	"context"
+	"database/sql"
+	"ecom_apiv1/db"
+	"errors"
+	"testing"
+	"time"
+)
+
+// testStorers returns an empty store of each backend, so that a test can run
+// the same checks against all of them.
+func testStorers(t *testing.T) map[string]Storer {
+	t.Helper()
+	// Every connection to file::memory: opens a database of its own, so the
+	// pool is kept to the one that was migrated.
+	x, err := db.GetConnection("sqlite", "file::memory:?_pragma=foreign_keys(1)&_time_format=sqlite")
+	if err != nil {
+		t.Fatal(err)
+	}
+	x.SetMaxOpenConns(1)
+	t.Cleanup(func() { x.Close() })
+
+	m, err := db.NewMigrator(x)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := m.Up(context.Background()); err != nil {
+		t.Fatal(err)
+	}
+	return map[string]Storer{
+		"memory": NewMemoryStorage(),
+		"sqlite": NewSQLiteStorage(x),
+	}
+}
+
+func TestCreateOrderReturnsItemIDs(t *testing.T) {
+	for name, st := range testStorers(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			p, err := st.CreateProduct(ctx, &Product{Name: "p", Price: 1000, CountInStock: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, err := st.CreateUser(ctx, &User{Name: "buyer", Email: "buyer@example.com", Password: "x"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			o, err := st.CreateOrder(ctx, &Order{
+				UserId:        u.ID,
+				PaymentMethod: "card",
+				Items: []OrderItem{
+					{Name: p.Name, Quantity: 1, Price: p.Price, ProductID: p.ID},
+					{Name: p.Name, Quantity: 2, Price: p.Price, ProductID: p.ID},
+				},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			for i, oi := range o.Items {
+				if oi.ID == 0 || oi.OrderID != o.ID {
+					t.Errorf("item %d has id %d and order_id %d, want an id and order_id %d", i, oi.ID, oi.OrderID, o.ID)
+				}
+			}
+		})
+	}
+}
+
+func TestCreateReturnsCreatedAt(t *testing.T) {
+	for name, st := range testStorers(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			p, err := st.CreateProduct(ctx, &Product{Name: "p", Price: 1000, CountInStock: 10})
+			if err != nil {
+				t.Fatal(err)
+			}
+			u, err := st.CreateUser(ctx, &User{Name: "buyer", Email: "buyer@example.com", Password: "x"})
+			if err != nil {
+				t.Fatal(err)
+			}
+			o, err := st.CreateOrder(ctx, &Order{
+				UserId:        u.ID,
+				PaymentMethod: "card",
+				Items:         []OrderItem{{Name: p.Name, Quantity: 1, Price: p.Price, ProductID: p.ID}},
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			c, err := st.CreateCategory(ctx, &Category{Name: "c", Slug: "c"})
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			tests := []struct {
+				name    string
+				created time.Time
+				get     func() (time.Time, error)
+			}{
+				{"product", p.CreatedAt, func() (time.Time, error) {
+					got, err := st.GetProduct(ctx, p.ID)
+					if err != nil {
+						return time.Time{}, err
+					}
+					return got.CreatedAt, nil
+				}},
+				{"user", u.CreatedAt, func() (time.Time, error) {
+					got, err := st.GetUser(ctx, u.Email)
+					if err != nil {
+						return time.Time{}, err
+					}
+					return got.CreatedAt, nil
+				}},
+				{"order", o.CreatedAt, func() (time.Time, error) {
+					got, err := st.GetOrder(ctx, o.ID)
+					if err != nil {
+						return time.Time{}, err
+					}
+					return got.CreatedAt, nil
+				}},
+				{"category", c.CreatedAt, func() (time.Time, error) {
+					got, err := st.GetCategory(ctx, c.ID)
+					if err != nil {
+						return time.Time{}, err
+					}
+					return got.CreatedAt, nil
+				}},
+			}
+			for _, tt := range tests {
+				if tt.created.IsZero() {
+					t.Errorf("created %s has no created_at", tt.name)
+					continue
+				}
+				stored, err := tt.get()
+				if err != nil {
+					t.Fatal(err)
+				}
+				if d := stored.Sub(tt.created); d < -time.Second || d > time.Second {
+					t.Errorf("created %s has created_at %v, stored %v", tt.name, tt.created, stored)
+				}
+			}
+		})
+	}
+}
+
+// TestGetMissing checks that every backend reports a missing row the same
+// way, as an error wrapping sql.ErrNoRows, which the server relies on.
+func TestGetMissing(t *testing.T) {
+	const id = 999
+	tests := []struct {
+		name string
+		get  func(ctx context.Context, st Storer) error
+	}{
+		{"product", func(ctx context.Context, st Storer) error { _, err := st.GetProduct(ctx, id); return err }},
+		{"variant", func(ctx context.Context, st Storer) error { _, err := st.GetVariant(ctx, id); return err }},
+		{"category", func(ctx context.Context, st Storer) error { _, err := st.GetCategory(ctx, id); return err }},
+		{"order", func(ctx context.Context, st Storer) error { _, err := st.GetOrder(ctx, id); return err }},
+		{"user", func(ctx context.Context, st Storer) error {
+			_, err := st.GetUser(ctx, "nobody@example.com")
+			return err
+		}},
+		{"session", func(ctx context.Context, st Storer) error { _, err := st.GetSession(ctx, "nope"); return err }},
+		{"tax rate", func(ctx context.Context, st Storer) error { _, err := st.GetTaxRate(ctx, id); return err }},
+		{"review", func(ctx context.Context, st Storer) error { _, err := st.GetReview(ctx, id); return err }},
+		{"guest cart", func(ctx context.Context, st Storer) error { _, err := st.GetGuestCart(ctx, "nope"); return err }},
+		{"wishlist", func(ctx context.Context, st Storer) error { _, err := st.GetWishlist(ctx, id); return err }},
+		{"coupon", func(ctx context.Context, st Storer) error { _, err := st.GetCoupon(ctx, id); return err }},
+		{"coupon code", func(ctx context.Context, st Storer) error { _, err := st.GetCouponByCode(ctx, "NOPE"); return err }},
+		{"promotion", func(ctx context.Context, st Storer) error { _, err := st.GetPromotion(ctx, id); return err }},
+	}
+	for name, st := range testStorers(t) {
+		for _, tt := range tests {
+			if err := tt.get(context.Background(), st); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("%s: missing %s: error = %v, want sql.ErrNoRows", name, tt.name, err)
+			}
+		}
+	}
+}
+
+func TestProductRoundTrip(t *testing.T) {
+	for name, st := range testStorers(t) {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.Background()
+			created, err := st.CreateProduct(ctx, &Product{Name: "mug", Description: "holds coffee", Price: 1250, Weight: 0.4, CountInStock: 3})
+			if err != nil {
+				t.Fatal(err)
+			}
+			got, err := st.GetProduct(ctx, created.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Name != "mug" || got.Description != "holds coffee" || got.Price != 1250 || got.Weight != 0.4 || got.CountInStock != 3 {
+				t.Errorf("stored product = %+v", got)
+			}
+
+			got.Name, got.Price = "cup", 990
+			if _, err := st.UpdateProduct(ctx, got); err != nil {
+				t.Fatal(err)
+			}
+			got, err = st.GetProduct(ctx, created.ID)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if got.Name != "cup" || got.Price != 990 {
+				t.Errorf("updated product = %+v", got)
+			}
+
+			if err := st.DeleteProduct(ctx, created.ID); err != nil {
+				t.Fatal(err)
+			}
+			if _, err := st.GetProduct(ctx, created.ID); !errors.Is(err, sql.ErrNoRows) {
+				t.Errorf("deleted product: error = %v, want sql.ErrNoRows", err)
+			}
+		})
+	}
+}
@@ -1,41 +1,90 @@
 package storer
 
-import "time"
+import (
+	"ecom_apiv1/internal/money"
+	"time"
+)
 
 type Product struct {
-	ID           int        `db:"id"`
-	Name         string     `db:"name"`
-	Image        string     `db:"image"`
-	Category     string     `db:"category"`
-	Description  string     `db:"description"`
-	Rating       int        `db:"rating"`
-	NumReviews   int        `db:"num_reviews"`
-	Price        float64    `db:"price"`
-	CountInStock int        `db:"count_in_stock"`
-	CreatedAt    time.Time  `db:"created_at"`
-	UpdatedAt    *time.Time `db:"updated_at"`
+	ID           int          `db:"id"`
+	Name         string       `db:"name"`
+	Image        string       `db:"image"`
+	CategoryID   *int         `db:"category_id"`
+	Description  string       `db:"description"`
+	Rating       float64      `db:"rating"`
+	NumReviews   int          `db:"num_reviews"`
+	Price        money.Amount `db:"price"`
+	Weight       float64      `db:"weight"`
+	CountInStock int          `db:"count_in_stock"`
+	CreatedAt    time.Time    `db:"created_at"`
+	UpdatedAt    *time.Time   `db:"updated_at"`
+
+	// Rating and NumReviews are maintained from the product's reviews.
+	// Options, Variants and Images are loaded with the product. Once a
+	// product has variants, its CountInStock is the sum of theirs.
+	Options  []ProductOption  `db:"-"`
+	Variants []ProductVariant `db:"-"`
+	Images   []ProductImage   `db:"-"`
+}
+
+// Category is a node of the category tree. Slugs are unique across the whole
+// tree; a category's path is the slugs from the root down to it, joined
+// with "/".
+type Category struct {
+	ID        int        `db:"id"`
+	ParentID  *int       `db:"parent_id"`
+	Name      string     `db:"name"`
+	Slug      string     `db:"slug"`
+	SortOrder int        `db:"sort_order"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt *time.Time `db:"updated_at"`
 }
 
 type Order struct {
-	ID            int     `db:"id"`
-	PaymentMethod string  `db:"payment_method"`
-	TaxPrice      float64 `db:"tax_price"`
-	ShippingPrice float64 `db:"shipping_price"`
-	TotalPrice    float64 `db:"total_price"`
-	UserId        int     `db:"user_id"`
-	Items         []OrderItem
-	CreatedAt     time.Time  `db:"created_at"`
-	UpdatedAt     *time.Time `db:"updated_at"`
+	ID              int            `db:"id"`
+	Status          OrderStatus    `db:"status"`
+	PaymentMethod   string         `db:"payment_method"`
+	ShippingCountry string         `db:"shipping_country"`
+	ShippingRegion  string         `db:"shipping_region"`
+	ShippingMethod  string         `db:"shipping_method"`
+	CouponID        *int           `db:"coupon_id"`
+	CouponCode      string         `db:"coupon_code"`
+	Currency        money.Currency `db:"currency"`
+	ItemsPrice      money.Amount   `db:"items_price"`
+	PromotionPrice  money.Amount   `db:"promotion_price"`
+	DiscountPrice   money.Amount   `db:"discount_price"`
+	TaxPrice        money.Amount   `db:"tax_price"`
+	ShippingPrice   money.Amount   `db:"shipping_price"`
+	TotalPrice      money.Amount   `db:"total_price"`
+	UserId          int            `db:"user_id"`
+	Items           []OrderItem
+	CreatedAt       time.Time  `db:"created_at"`
+	UpdatedAt       *time.Time `db:"updated_at"`
 }
 
 type OrderItem struct {
-	ID        int     `db:"id"`
-	Name      string  `db:"name"`
-	Quantity  int     `db:"quantity"`
-	Image     string  `db:"image"`
-	Price     float64 `db:"price"`
-	ProductID int     `db:"product_id"`
-	OrderID   int     `db:"order_id"`
+	ID           int          `db:"id"`
+	Name         string       `db:"name"`
+	SKU          string       `db:"sku"`
+	Quantity     int          `db:"quantity"`
+	Image        string       `db:"image"`
+	Price        money.Amount `db:"price"`
+	TaxRate      float64      `db:"tax_rate"`
+	TaxPrice     money.Amount `db:"tax_price"`
+	TaxInclusive bool         `db:"tax_inclusive"`
+	ProductID    int          `db:"product_id"`
+	VariantID    *int         `db:"variant_id"`
+	OrderID      int          `db:"order_id"`
+}
+
+type OrderStatusChange struct {
+	ID         int         `db:"id"`
+	OrderID    int         `db:"order_id"`
+	FromStatus OrderStatus `db:"from_status"`
+	ToStatus   OrderStatus `db:"to_status"`
+	ChangedBy  int         `db:"changed_by"`
+	Reason     string      `db:"reason"`
+	CreatedAt  time.Time   `db:"created_at"`
 }
 
 type User struct {
@@ -56,3 +105,15 @@ type Session struct {
 	CreatedAt    time.Time `db:"created_at"`
 	ExpiresAt    time.Time `db:"expires_at"`
 }
+
+type TaxRate struct {
+	ID        int        `db:"id"`
+	Name      string     `db:"name"`
+	Country   string     `db:"country"`
+	Region    string     `db:"region"`
+	Category  string     `db:"category"`
+	Rate      float64    `db:"rate"`
+	Inclusive bool       `db:"inclusive"`
+	CreatedAt time.Time  `db:"created_at"`
+	UpdatedAt *time.Time `db:"updated_at"`
+}
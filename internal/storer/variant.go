@@ -0,0 +1,77 @@
+package storer
+
+import (
+	"database/sql/driver"
+	"ecom_apiv1/internal/money"
+	"encoding/json"
+	"fmt"
+	"time"
+)
+
+// ProductOption is one way a product varies, such as "Size" with the choices
+// S, M and L. Options are listed by Position.
+type ProductOption struct {
+	ID        int        `db:"id"`
+	ProductID int        `db:"product_id"`
+	Name      string     `db:"name"`
+	Choices   StringList `db:"choices"`
+	Position  int        `db:"position"`
+}
+
+// ProductVariant is one purchasable combination of a product's options with
+// its own SKU and stock. A nil Price or an empty Image falls back to the
+// product's.
+type ProductVariant struct {
+	ID           int           `db:"id"`
+	ProductID    int           `db:"product_id"`
+	SKU          string        `db:"sku"`
+	OptionValues OptionValues  `db:"option_values"`
+	Price        *money.Amount `db:"price"`
+	Image        string        `db:"image"`
+	CountInStock int           `db:"count_in_stock"`
+	CreatedAt    time.Time     `db:"created_at"`
+	UpdatedAt    *time.Time    `db:"updated_at"`
+}
+
+// StringList is stored as a JSON array.
+type StringList []string
+
+func (l StringList) Value() (driver.Value, error) {
+	if l == nil {
+		l = StringList{}
+	}
+	b, err := json.Marshal([]string(l))
+	return string(b), err
+}
+
+func (l *StringList) Scan(src interface{}) error {
+	return scanJSON(src, (*[]string)(l))
+}
+
+// OptionValues maps option names to the chosen value. It is stored as a JSON
+// object.
+type OptionValues map[string]string
+
+func (v OptionValues) Value() (driver.Value, error) {
+	if v == nil {
+		v = OptionValues{}
+	}
+	b, err := json.Marshal(map[string]string(v))
+	return string(b), err
+}
+
+func (v *OptionValues) Scan(src interface{}) error {
+	return scanJSON(src, (*map[string]string)(v))
+}
+
+func scanJSON(src interface{}, dst interface{}) error {
+	switch s := src.(type) {
+	case []byte:
+		return json.Unmarshal(s, dst)
+	case string:
+		return json.Unmarshal([]byte(s), dst)
+	case nil:
+		return nil
+	}
+	return fmt.Errorf("cannot scan %T as JSON", src)
+}
@@ -0,0 +1,69 @@
+package storer
+
+import "time"
+
+// Wishlist is a named list of products a customer saved for later. A
+// wishlist with a ShareToken can be viewed by anyone who has the token.
+type Wishlist struct {
+	ID         int        `db:"id"`
+	UserID     int        `db:"user_id"`
+	Name       string     `db:"name"`
+	ShareToken *string    `db:"share_token"`
+	CreatedAt  time.Time  `db:"created_at"`
+	UpdatedAt  *time.Time `db:"updated_at"`
+
+	Items []WishlistItem `db:"-"`
+}
+
+// WishlistItem is a product, or one of its variants, on a wishlist. A
+// wishlist holds each product and variant at most once.
+type WishlistItem struct {
+	ID         int       `db:"id"`
+	WishlistID int       `db:"wishlist_id"`
+	ProductID  int       `db:"product_id"`
+	VariantID  *int      `db:"variant_id"`
+	CreatedAt  time.Time `db:"created_at"`
+}
+
+// WishlistedProduct counts how often a product is wishlisted: on how many
+// wishlists, and by how many customers.
+type WishlistedProduct struct {
+	ProductID int    `db:"product_id"`
+	Name      string `db:"name"`
+	Wishlists int    `db:"wishlists"`
+	Users     int    `db:"users"`
+}
+
+// wishlistedSort is the sort of the cursors of ListWishlistedProducts, which
+// lists the most wishlisted products first.
+const wishlistedSort = "wishlists"
+
+// wishlistedCursor completes a cursor from nextCursor with the count of the
+// last product on the page.
+func wishlistedCursor(c *Cursor, p *WishlistedProduct) *Cursor {
+	if c == nil {
+		return nil
+	}
+	c.Sort = wishlistedSort
+	c.Value = float64(p.Wishlists)
+	return c
+}
+
+// wishlistedBefore reports whether a comes before b in ListWishlistedProducts.
+func wishlistedBefore(a, b *WishlistedProduct) bool {
+	if a.Wishlists != b.Wishlists {
+		return a.Wishlists > b.Wishlists
+	}
+	return a.ProductID < b.ProductID
+}
+
+// sameLine reports whether a and b are for the same product and variant.
+func (a *WishlistItem) sameLine(b *WishlistItem) bool {
+	if a.ProductID != b.ProductID {
+		return false
+	}
+	if a.VariantID == nil || b.VariantID == nil {
+		return a.VariantID == nil && b.VariantID == nil
+	}
+	return *a.VariantID == *b.VariantID
+}
@@ -0,0 +1,86 @@
+package tax
+
+import (
+	"context"
+	"ecom_apiv1/internal/storer"
+	"fmt"
+	"strings"
+)
+
+type RateLister interface {
+	ListTaxRates(ctx context.Context) ([]storer.TaxRate, error)
+}
+
+// RuleCalculator applies the tax rates managed by admins. For every line the
+// most specific matching rate wins: a category match beats a region match,
+// which beats a country match, which beats a flat rate with no conditions.
+// Lines without any matching rate are not taxed. Tax is rounded per line,
+// to the nearest minor unit.
+type RuleCalculator struct {
+	rates RateLister
+}
+
+func NewRuleCalculator(rates RateLister) *RuleCalculator {
+	return &RuleCalculator{
+		rates: rates,
+	}
+}
+
+func (c *RuleCalculator) Calculate(ctx context.Context, req Request) (*Result, error) {
+	rates, err := c.rates.ListTaxRates(ctx)
+	if err != nil {
+		return nil, fmt.Errorf("error loading tax rates: %w", err)
+	}
+
+	res := &Result{}
+	for _, line := range req.Lines {
+		lt := LineTax{ProductID: line.ProductID}
+		if r := bestRate(rates, req.Country, req.Region, line.Category); r != nil {
+			lt.RateID = r.ID
+			lt.Rate = r.Rate
+			lt.Inclusive = r.Inclusive
+			if r.Inclusive {
+				lt.Amount = line.Amount.Ratio(r.Rate, 1+r.Rate)
+			} else {
+				lt.Amount = line.Amount.Ratio(r.Rate, 1)
+				res.Exclusive += lt.Amount
+			}
+			res.Total += lt.Amount
+		}
+		res.Lines = append(res.Lines, lt)
+	}
+	return res, nil
+}
+
+func bestRate(rates []storer.TaxRate, country, region, category string) *storer.TaxRate {
+	var best *storer.TaxRate
+	bestScore := -1
+	for i := range rates {
+		r := &rates[i]
+		score := 0
+		if r.Country != "" {
+			if !strings.EqualFold(r.Country, country) {
+				continue
+			}
+			score += 1
+		}
+		if r.Region != "" {
+			if !strings.EqualFold(r.Region, region) {
+				continue
+			}
+			score += 2
+		}
+		if r.Category != "" {
+			if !strings.EqualFold(r.Category, category) {
+				continue
+			}
+			score += 4
+		}
+		// rates are listed by ID, so the oldest rate wins a tie
+		if score > bestScore {
+			best = r
+			bestScore = score
+		}
+	}
+	return best
+}
@@ -0,0 +1,76 @@
+package tax
+
+import (
+	"context"
+	"ecom_apiv1/internal/money"
+	"ecom_apiv1/internal/storer"
+	"testing"
+)
+
+type rateList []storer.TaxRate
+
+func (l rateList) ListTaxRates(ctx context.Context) ([]storer.TaxRate, error) {
+	return l, nil
+}
+
+var testRates = rateList{
+	{ID: 1, Name: "Default", Rate: 0.15},
+	{ID: 2, Name: "US", Country: "US", Rate: 0.07},
+	{ID: 3, Name: "California", Country: "US", Region: "CA", Rate: 0.0725},
+	{ID: 4, Name: "Books", Category: "books", Rate: 0.05},
+	{ID: 5, Name: "Germany", Country: "DE", Rate: 0.19, Inclusive: true},
+	{ID: 6, Name: "US again", Country: "US", Rate: 0.08},
+}
+
+func TestRuleCalculatorPicksMostSpecificRate(t *testing.T) {
+	tests := []struct {
+		name          string
+		rates         rateList
+		country       string
+		region        string
+		category      string
+		amount        money.Amount
+		wantRateID    int
+		wantAmount    money.Amount
+		wantInclusive bool
+	}{
+		{"flat rate", testRates, "FR", "", "", 1000, 1, 150, false},
+		{"country", testRates, "US", "", "", 1000, 2, 70, false},
+		{"country in any case", testRates, "us", "", "", 1000, 2, 70, false},
+		{"region rounds half away from zero", testRates, "US", "CA", "", 1000, 3, 73, false},
+		{"category beats region", testRates, "US", "CA", "books", 1000, 4, 50, false},
+		{"included tax", testRates, "DE", "", "", 1190, 5, 190, true},
+		{"no matching rate", testRates[1:], "FR", "", "", 1000, 0, 0, false},
+	}
+	for _, tt := range tests {
+		res, err := NewRuleCalculator(tt.rates).Calculate(context.Background(), Request{
+			Country: tt.country,
+			Region:  tt.region,
+			Lines:   []Line{{ProductID: 1, Category: tt.category, Amount: tt.amount}},
+		})
+		if err != nil {
+			t.Fatalf("%s: %v", tt.name, err)
+		}
+		lt := res.Lines[0]
+		if lt.RateID != tt.wantRateID || lt.Amount != tt.wantAmount || lt.Inclusive != tt.wantInclusive {
+			t.Errorf("%s: got rate %d, tax %d, inclusive %v, want rate %d, tax %d, inclusive %v",
+				tt.name, lt.RateID, lt.Amount, lt.Inclusive, tt.wantRateID, tt.wantAmount, tt.wantInclusive)
+		}
+	}
+}
+
+func TestRuleCalculatorTotals(t *testing.T) {
+	res, err := NewRuleCalculator(testRates).Calculate(context.Background(), Request{
+		Country: "DE",
+		Lines: []Line{
+			{ProductID: 1, Amount: 1190},
+			{ProductID: 2, Category: "books", Amount: 1000},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	if res.Total != 240 || res.Exclusive != 50 {
+		t.Errorf("total %d, exclusive %d, want 240 and 50", res.Total, res.Exclusive)
+	}
+}
@@ -0,0 +1,43 @@
+package tax
+
+import (
+	"context"
+	"ecom_apiv1/internal/money"
+)
+
+// Calculator works out the tax owed on the lines of an order.
+type Calculator interface {
+	Calculate(ctx context.Context, req Request) (*Result, error)
+}
+
+type Request struct {
+	Country string
+	Region  string
+	Lines   []Line
+}
+
+// Line is one order item. Category is the slug of the product's category
+// and Amount is the line total (unit price times quantity) as stored in the
+// catalog, less any discount on the line.
+type Line struct {
+	ProductID int
+	Category  string
+	Amount    money.Amount
+}
+
+type Result struct {
+	Lines []LineTax
+	// Total is the tax on all lines. Exclusive is the part of Total that has
+	// to be added on top of the catalog prices; the rest is already included
+	// in them.
+	Total     money.Amount
+	Exclusive money.Amount
+}
+
+type LineTax struct {
+	ProductID int
+	RateID    int
+	Rate      float64
+	Inclusive bool
+	Amount    money.Amount
+}